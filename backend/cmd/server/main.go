@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/gatanasi/video-converter/internal/config"
 	"github.com/gatanasi/video-converter/internal/constants"
 	"github.com/gatanasi/video-converter/internal/conversion"
+	"github.com/gatanasi/video-converter/internal/drive"
 	"github.com/gatanasi/video-converter/internal/filestore"
 	"github.com/gatanasi/video-converter/internal/middleware"
 	"github.com/gatanasi/video-converter/internal/models"
@@ -27,7 +29,14 @@ func main() {
 	conf := config.New()
 
 	// Ensure required directories exist
-	for _, dir := range []string{conf.UploadsDir, conf.ConvertedDir} {
+	for _, dir := range []string{
+		conf.UploadsDir,
+		conf.ConvertedDir,
+		filepath.Join(conf.DataDir, constants.LUTsSubdir),
+		filepath.Join(conf.DataDir, constants.ThumbnailsSubdir),
+		filepath.Join(conf.DataDir, constants.MetricsSubdir),
+		filepath.Join(conf.DataDir, constants.PreviewsSubdir),
+	} {
 		if err := filestore.EnsureDirectoryExists(dir); err != nil {
 			log.Fatalf("Failed to ensure directory %s exists: %v", dir, err)
 		}
@@ -35,14 +44,39 @@ func main() {
 
 	middleware.InitCORS(conf.AllowedOrigins)
 
-	// Create conversion store for tracking active conversions
-	store := conversion.NewStore()
+	// Create conversion store for tracking conversions, persisted so job history survives restarts
+	repo, err := conversion.OpenJSONRepository(filepath.Join(conf.DataDir, constants.JobJournalFile))
+	if err != nil {
+		log.Fatalf("Failed to open job store: %v", err)
+	}
+	defer func() {
+		if err := repo.Close(); err != nil {
+			log.Printf("WARN: Failed to close job store: %v", err)
+		}
+	}()
+	store := conversion.NewStoreWithRepository(repo)
 
-	converter := conversion.NewVideoConverter(conf.WorkerCount, store)
+	converter := conversion.NewVideoConverter(conf.WorkerCount, conf.MaxQueuedJobs, store)
+	converter.SetSourceRetention(conf.SourceRetention)
+	driveClient, err := newDriveClient(conf)
+	if err != nil {
+		log.Fatalf("Failed to set up Google Drive access: %v", err)
+	}
+	converter.SetDriveDownloader(func(ctx context.Context, fileID, destinationPath string, progress func(written, total int64)) error {
+		return driveClient.DownloadFile(ctx, fileID, destinationPath, drive.DownloadOptions{
+			MaxFileSize: conf.MaxFileSize,
+			Progress:    progress,
+		})
+	})
+	converter.SetDriveUploader(driveClient.UploadFile)
 	converter.Start()
 	defer converter.Stop()
 
-	handler := api.NewHandler(conf, converter, store)
+	if err := converter.RestoreJobs(); err != nil {
+		log.Fatalf("Failed to restore jobs: %v", err)
+	}
+
+	handler := api.NewHandler(conf, converter, store, driveClient)
 
 	mux := http.NewServeMux()
 	handler.SetupRoutes(mux)
@@ -68,7 +102,7 @@ func main() {
 		IdleTimeout:  constants.HTTPIdleTimeout,
 	}
 
-	go setupFileCleanup(conf)
+	go setupFileCleanup(conf, store)
 
 	// Graceful shutdown setup
 	stop := make(chan os.Signal, 1)
@@ -98,33 +132,50 @@ func main() {
 	fmt.Println("Server gracefully stopped")
 }
 
-// setupFileCleanup schedules periodic cleanup of old files.
-func setupFileCleanup(conf models.Config) {
+// newDriveClient returns a Drive client using the configured Google credentials file,
+// or the API key when there is none.
+func newDriveClient(conf models.Config) (*drive.Client, error) {
+	if conf.GoogleDriveCredentialsFile == "" {
+		return drive.NewClient(conf.GoogleDriveAPIKey, conf.GoogleDriveAPIBaseURL), nil
+	}
+	tokens, err := drive.LoadCredentials(conf.GoogleDriveCredentialsFile, conf.GoogleDriveImpersonateUser)
+	if err != nil {
+		return nil, err
+	}
+	return drive.NewClientWithTokens(tokens, conf.GoogleDriveAPIBaseURL), nil
+}
+
+// setupFileCleanup schedules periodic cleanup of old files and finished jobs.
+func setupFileCleanup(conf models.Config, store *conversion.Store) {
 	// Run cleanup shortly after start and then periodically
 	initialDelay := constants.FileCleanupInitialDelay
 	cleanupInterval := constants.FileCleanupInterval
 
 	log.Printf("Scheduling initial file cleanup in %v...", initialDelay)
 	time.AfterFunc(initialDelay, func() {
-		cleanupFiles(conf)
+		cleanupFiles(conf, store)
 		// Start periodic cleanup after the initial run
 		ticker := time.NewTicker(cleanupInterval)
 		log.Printf("Starting periodic cleanup task (every %v)...", cleanupInterval)
 		for range ticker.C {
-			cleanupFiles(conf)
+			cleanupFiles(conf, store)
 		}
 		// Note: This ticker goroutine will exit when the program exits.
 		// If more robust lifecycle management is needed, consider using context cancellation.
 	})
 }
 
-// cleanupFiles removes old files from configured directories.
-func cleanupFiles(conf models.Config) {
+// cleanupFiles removes old files from configured directories, and the records of jobs
+// that finished as long ago.
+func cleanupFiles(conf models.Config, store *conversion.Store) {
 	maxAge := constants.FileMaxAge
 	log.Println("Running cleanup for old files...")
 
-	uploadsRemoved := filestore.CleanupOldFiles(conf.UploadsDir, maxAge)
+	uploadsRemoved := filestore.CleanupOldFilesExcept(conf.UploadsDir, maxAge, store.GetUnfinishedInputFilenames())
 	convertedRemoved := filestore.CleanupOldFiles(conf.ConvertedDir, maxAge)
+	filestore.CleanupOldDirectories(filepath.Join(conf.DataDir, constants.ThumbnailsSubdir), maxAge)
+	filestore.CleanupOldFiles(filepath.Join(conf.DataDir, constants.MetricsSubdir), maxAge)
+	filestore.CleanupOldFiles(filepath.Join(conf.DataDir, constants.PreviewsSubdir), maxAge)
 
 	totalRemoved := uploadsRemoved + convertedRemoved
 	if totalRemoved > 0 {
@@ -133,4 +184,7 @@ func cleanupFiles(conf models.Config) {
 	} else {
 		log.Println("File cleanup finished. No old files needed removal.")
 	}
+	if pruned := store.PruneFinished(maxAge); pruned > 0 {
+		log.Printf("Removed %d finished jobs older than %v from the job history.", pruned, maxAge)
+	}
 }
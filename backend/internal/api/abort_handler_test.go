@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"testing"
@@ -49,6 +50,7 @@ func TestAbortConversionHandler(t *testing.T) {
 		assert.NoError(t, decodeErr)
 		assert.False(t, response.Success)
 		assert.Contains(t, response.Error, "already complete")
+		assert.Equal(t, models.ErrorCodeConflict, response.ErrorCode)
 	})
 
 	t.Run("successful abort", func(t *testing.T) {
@@ -89,6 +91,12 @@ func TestAbortConversionHandler(t *testing.T) {
 		assert.NoError(t, decodeErr)
 		assert.True(t, response.Success)
 
+		stored, ok := env.store.GetStatus(conversionID)
+		require.True(t, ok)
+		assert.Equal(t, models.PhaseCanceled, stored.Phase)
+		assert.Equal(t, "Conversion aborted by user", stored.Error)
+		assert.Equal(t, models.ErrorCodeAborted, stored.ErrorCode)
+
 		// Verify that the process was actually terminated by the handler
 		// Use a channel with a timeout to ensure the test doesn't hang if the process isn't killed
 		waitCh := make(chan error, 1)
@@ -104,4 +112,34 @@ func TestAbortConversionHandler(t *testing.T) {
 			t.Fatal("process was not killed by the handler within the timeout")
 		}
 	})
+	t.Run("queued job", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		conversionID := "queued-conversion"
+		uploadedFilePath := filepath.Join(env.uploadsDir, "queued.mov")
+		require.NoError(t, os.WriteFile(uploadedFilePath, []byte("input"), 0o644))
+		status := &models.ConversionStatus{
+			InputPath:  uploadedFilePath,
+			OutputPath: filepath.Join(env.convertedDir, "queued.mp4"),
+			Phase:      models.PhaseQueued,
+		}
+		env.store.SetStatus(conversionID, status)
+		require.NoError(t, env.handler.Converter.QueueJob(models.ConversionJob{
+			ConversionID:     conversionID,
+			UploadedFilePath: uploadedFilePath,
+			OutputFilePath:   status.OutputPath,
+			Status:           status,
+		}))
+
+		req := httptest.NewRequest(http.MethodPost, RouteConversionAbort+conversionID, nil)
+		res := httptest.NewRecorder()
+
+		env.handler.AbortConversionHandler(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		stored, ok := env.store.GetStatus(conversionID)
+		require.True(t, ok)
+		assert.Equal(t, models.PhaseCanceled, stored.Phase)
+		assert.NoFileExists(t, uploadedFilePath)
+	})
 }
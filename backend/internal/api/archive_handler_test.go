@@ -0,0 +1,106 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readArchive returns the contents of the ZIP archive in a response, keyed by file name.
+func readArchive(t *testing.T, res *httptest.ResponseRecorder) map[string]string {
+	t.Helper()
+	body := res.Body.Bytes()
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, file := range archive.File {
+		assert.Equal(t, zip.Store, file.Method, "video is stored without compression")
+		rc, err := file.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		files[file.Name] = string(data)
+	}
+	return files
+}
+
+func TestDownloadArchiveHandler(t *testing.T) {
+	t.Run("streams files selected by name", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		require.NoError(t, os.WriteFile(filepath.Join(env.convertedDir, "a.mp4"), []byte("first"), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(env.convertedDir, "b.mov"), []byte("second"), 0o644))
+
+		query := url.Values{"file": {"a.mp4", "b.mov", "a.mp4"}}
+		res := httptest.NewRecorder()
+		env.handler.DownloadArchiveHandler(res, httptest.NewRequest(http.MethodGet, RouteDownloadArchive+"?"+query.Encode(), nil))
+
+		require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+		assert.Equal(t, "application/zip", res.Header().Get("Content-Type"))
+		assert.Contains(t, res.Header().Get("Content-Disposition"), "converted-files.zip")
+		assert.Equal(t, map[string]string{"a.mp4": "first", "b.mov": "second"}, readArchive(t, res))
+	})
+
+	t.Run("streams the outputs of a batch", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		for _, id := range []string{"one", "two"} {
+			outputPath := filepath.Join(env.convertedDir, id+".mp4")
+			require.NoError(t, os.WriteFile(outputPath, []byte(id), 0o644))
+			env.store.SetStatus(id, &models.ConversionStatus{OutputPath: outputPath, Format: "mp4", BatchID: "batch-123456789"})
+			env.store.UpdateStatusOnSuccess(id)
+		}
+
+		body := strings.NewReader(`{"batchId": "batch-123456789"}`)
+		res := httptest.NewRecorder()
+		env.handler.DownloadArchiveHandler(res, httptest.NewRequest(http.MethodPost, RouteDownloadArchive, body))
+
+		require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+		assert.Contains(t, res.Header().Get("Content-Disposition"), "batch-batch-12.zip")
+		assert.Equal(t, map[string]string{"one.mp4": "one", "two.mp4": "two"}, readArchive(t, res))
+	})
+
+	tooMany := make(url.Values)
+	for i := 0; i <= constants.MaxArchiveFiles; i++ {
+		tooMany.Add("file", strings.Repeat("x", i+1)+".mp4")
+	}
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{"method not allowed", http.MethodDelete, RouteDownloadArchive, "", http.StatusMethodNotAllowed},
+		{"no files", http.MethodGet, RouteDownloadArchive, "", http.StatusBadRequest},
+		{"invalid body", http.MethodPost, RouteDownloadArchive, `{`, http.StatusBadRequest},
+		{"directory traversal", http.MethodPost, RouteDownloadArchive, `{"files": ["ok.mp4", "../secret.mp4"]}`, http.StatusBadRequest},
+		{"missing file", http.MethodGet, RouteDownloadArchive + "?file=ok.mp4&file=missing.mp4", "", http.StatusNotFound},
+		{"unknown batch", http.MethodGet, RouteDownloadArchive + "?batchId=missing", "", http.StatusNotFound},
+		{"too many files", http.MethodGet, RouteDownloadArchive + "?" + tooMany.Encode(), "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newHandlerTestEnv(t)
+			require.NoError(t, os.WriteFile(filepath.Join(env.convertedDir, "ok.mp4"), []byte("video"), 0o644))
+
+			res := httptest.NewRecorder()
+			env.handler.DownloadArchiveHandler(res, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantStatus, res.Code, res.Body.String())
+			assert.NotEqual(t, "application/zip", res.Header().Get("Content-Type"))
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+// archiveEntry is a converted file that has passed the path-safety checks.
+type archiveEntry struct {
+	name string
+	path string
+	info os.FileInfo
+}
+
+// DownloadArchiveHandler streams several converted files as one ZIP archive. Files are
+// selected by name, by batch ID, or both: GET takes repeated "file" and a "batchId" query
+// parameter, POST takes the same as a JSON ArchiveRequest. Every file is checked before
+// the response starts, so a bad selection fails with a normal error response. The archive
+// is written on the fly without compression, since video does not compress.
+func (h *Handler) DownloadArchiveHandler(w http.ResponseWriter, r *http.Request) {
+	var request models.ArchiveRequest
+	switch r.Method {
+	case http.MethodGet:
+		request.Files = r.URL.Query()["file"]
+		request.BatchID = r.URL.Query().Get("batchId")
+	case http.MethodPost:
+		r.Body = http.MaxBytesReader(w, r.Body, constants.MaxJSONRequestSize)
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			h.sendErrorResponse(w, fmt.Sprintf("Failed to parse request: %v", err), http.StatusBadRequest)
+			return
+		}
+	default:
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	names := request.Files
+	archiveName := "converted-files.zip"
+	if request.BatchID != "" {
+		batch, exists := h.Store.GetBatchStatus(request.BatchID)
+		if !exists {
+			h.sendErrorResponse(w, "Batch not found", http.StatusNotFound)
+			return
+		}
+		for _, output := range batch.Outputs {
+			names = append(names, output.FileName)
+		}
+		archiveName = fmt.Sprintf("batch-%.8s.zip", request.BatchID)
+	}
+	names = uniqueNames(names)
+	if len(names) == 0 {
+		h.sendErrorResponse(w, "No converted files selected", http.StatusBadRequest)
+		return
+	}
+	if len(names) > constants.MaxArchiveFiles {
+		h.sendErrorResponse(w, fmt.Sprintf("An archive holds at most %d files", constants.MaxArchiveFiles), http.StatusBadRequest)
+		return
+	}
+
+	entries, ok := h.resolveArchiveEntries(w, names)
+	if !ok {
+		return
+	}
+
+	// Large archives take longer than the server's default write timeout.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("WARN [archive]: Could not clear write deadline: %v", err)
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", archiveName))
+	w.WriteHeader(http.StatusOK)
+
+	if err := writeArchive(w, entries); err != nil {
+		// The status has been sent; the client sees a truncated archive.
+		log.Printf("ERROR [archive]: Failed to stream %s: %v", archiveName, err)
+		return
+	}
+	log.Printf("Streamed archive %s with %d files", archiveName, len(entries))
+}
+
+// resolveArchiveEntries applies the download path-safety checks to every selected file.
+// It sends an error response and returns false if any file cannot be archived.
+func (h *Handler) resolveArchiveEntries(w http.ResponseWriter, names []string) ([]archiveEntry, bool) {
+	activeFiles := h.Store.GetActiveOutputFilenames()
+	entries := make([]archiveEntry, 0, len(names))
+	for _, name := range names {
+		filePath, err := h.resolveConvertedFileName(name, "archive request")
+		if err != nil {
+			if errors.Is(err, errServerConfig) {
+				h.sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+			} else {
+				h.sendErrorResponse(w, fmt.Sprintf("%s: %v", name, err), http.StatusBadRequest)
+			}
+			return nil, false
+		}
+		if _, active := activeFiles[name]; active {
+			h.sendErrorResponse(w, fmt.Sprintf("%s: file is still being converted", name), http.StatusConflict)
+			return nil, false
+		}
+
+		info, err := h.safeAccessFile(h.Config.ConvertedDir, filePath, fmt.Sprintf("archive %s", name))
+		if err != nil {
+			switch {
+			case errors.Is(err, errFileNotFound):
+				h.sendErrorResponse(w, fmt.Sprintf("%s: file not found", name), http.StatusNotFound)
+			case errors.Is(err, errInvalidFilePath), errors.Is(err, errInvalidFileType):
+				h.sendErrorResponse(w, fmt.Sprintf("%s: invalid file request", name), http.StatusBadRequest)
+			default:
+				h.sendErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+			}
+			return nil, false
+		}
+		entries = append(entries, archiveEntry{name: name, path: filePath, info: info})
+	}
+	return entries, true
+}
+
+// writeArchive writes the files to w as a ZIP archive using store-only compression.
+func writeArchive(w io.Writer, entries []archiveEntry) error {
+	archive := zip.NewWriter(w)
+	for _, entry := range entries {
+		header := &zip.FileHeader{
+			Name:     entry.name,
+			Method:   zip.Store,
+			Modified: entry.info.ModTime(),
+		}
+		header.SetMode(constants.FilePermissions)
+		dst, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if err := copyFileTo(dst, entry.path); err != nil {
+			return fmt.Errorf("failed to add %s: %w", entry.name, err)
+		}
+	}
+	return archive.Close()
+}
+
+// copyFileTo copies the contents of the file at path to w.
+func copyFileTo(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// uniqueNames returns names without duplicates, keeping the first occurrence of each.
+func uniqueNames(names []string) []string {
+	seen := make(map[string]struct{}, len(names))
+	unique := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, dup := seen[name]; dup {
+			continue
+		}
+		seen[name] = struct{}{}
+		unique = append(unique, name)
+	}
+	return unique
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// submitBatch posts a batch request and returns the decoded response.
+func submitBatch(t *testing.T, env *handlerTestEnv, body string) models.BatchResponse {
+	t.Helper()
+	res := httptest.NewRecorder()
+	env.handler.ConvertBatchHandler(res, httptest.NewRequest(http.MethodPost, RouteConvertBatch, strings.NewReader(body)))
+	require.Equal(t, http.StatusAccepted, res.Code, res.Body.String())
+	var response models.BatchResponse
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+	return response
+}
+
+func TestConvertBatchHandler(t *testing.T) {
+	t.Run("rejects batches the queue cannot hold", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		stubDriveDownloads(env)
+		files := make([]string, env.handler.Config.MaxQueuedJobs+1)
+		for i := range files {
+			files[i] = fmt.Sprintf(`{"fileId": "f%d", "fileName": "f%d.mov"}`, i, i)
+		}
+		payload := `{"options": {"targetFormat": "mp4"}, "files": [` + strings.Join(files, ",") + `]}`
+
+		res := httptest.NewRecorder()
+		env.handler.ConvertBatchHandler(res, httptest.NewRequest(http.MethodPost, RouteConvertBatch, strings.NewReader(payload)))
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+		assert.Contains(t, res.Body.String(), models.ErrorCodeQueueFull)
+		assert.Empty(t, env.store.GetAllStatuses(), "no job of the batch is kept")
+	})
+
+	t.Run("queues every file with shared and per-file options", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		stubDriveDownloads(env)
+
+		response := submitBatch(t, env, `{
+			"options": {"targetFormat": "mp4", "quality": "high"},
+			"files": [
+				{"fileId": "a", "fileName": "a.mov"},
+				{"fileId": "b", "fileName": "b.mov", "targetFormat": "mov"}
+			]
+		}`)
+		require.NotEmpty(t, response.BatchID)
+		require.Len(t, response.ConversionIDs, 2)
+		waitForDownloads(t, env, response.ConversionIDs)
+
+		first, ok := env.store.GetJob(response.ConversionIDs[0])
+		require.True(t, ok)
+		assert.Equal(t, "a", first.FileID)
+		assert.Equal(t, "mp4", first.TargetFormat)
+		assert.Equal(t, "high", first.Quality)
+		assert.FileExists(t, first.UploadedFilePath)
+
+		second, ok := env.store.GetJob(response.ConversionIDs[1])
+		require.True(t, ok)
+		assert.Equal(t, "mov", second.TargetFormat, "per-file options override shared ones")
+		assert.Equal(t, "high", second.Quality)
+
+		for _, id := range response.ConversionIDs {
+			status, _ := env.store.GetStatus(id)
+			assert.Equal(t, response.BatchID, status.BatchID)
+			assert.Equal(t, models.PhaseQueued, status.Phase)
+		}
+	})
+
+	t.Run("failed download fails only that job", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		stubDriveDownloads(env, "gone")
+
+		response := submitBatch(t, env, `{"options": {"targetFormat": "mp4"}, "files": [
+			{"fileId": "gone", "fileName": "gone.mov"},
+			{"fileId": "here", "fileName": "here.mov"}
+		]}`)
+		waitForDownloads(t, env, response.ConversionIDs)
+
+		summary, ok := env.store.GetBatchStatus(response.BatchID)
+		require.True(t, ok)
+		assert.Equal(t, 1, summary.Failed)
+		assert.Equal(t, 1, summary.Pending)
+
+		status, _ := env.store.GetStatus(response.ConversionIDs[0])
+		assert.Equal(t, models.ErrorCodeDriveNotFound, status.ErrorCode)
+	})
+
+	tooMany := make([]string, constants.MaxBatchFiles+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf(`{"fileId": "f%d", "fileName": "f%d.mov"}`, i, i)
+	}
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantError  string
+	}{
+		{"method not allowed", http.MethodGet, "", http.StatusMethodNotAllowed, "Method not allowed"},
+		{"invalid body", http.MethodPost, `{`, http.StatusBadRequest, "Failed to parse request"},
+		{"no files", http.MethodPost, `{"options": {"targetFormat": "mp4"}, "files": []}`, http.StatusBadRequest, "files"},
+		{"too many files", http.MethodPost, `{"options": {"targetFormat": "mp4"}, "files": [` + strings.Join(tooMany, ",") + `]}`, http.StatusBadRequest, "at most"},
+		{"file without ID", http.MethodPost, `{"options": {"targetFormat": "mp4"}, "files": [{"fileId": "a", "fileName": "a.mov"}, {"fileName": "b.mov"}]}`, http.StatusBadRequest, "File 2: missing required fields"},
+		{"invalid format", http.MethodPost, `{"files": [{"fileId": "a", "fileName": "a.mov", "targetFormat": "avi"}]}`, http.StatusBadRequest, "File 1: invalid target format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newHandlerTestEnv(t)
+			stubDriveDownloads(env)
+
+			res := httptest.NewRecorder()
+			env.handler.ConvertBatchHandler(res, httptest.NewRequest(tt.method, RouteConvertBatch, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantStatus, res.Code)
+			assert.Contains(t, res.Body.String(), tt.wantError)
+			assert.Empty(t, env.store.GetAllStatuses(), "nothing is queued for an invalid batch")
+		})
+	}
+}
+
+func TestBatchStatusHandler(t *testing.T) {
+	env := newHandlerTestEnv(t)
+	stubDriveDownloads(env)
+	response := submitBatch(t, env, `{"options": {"targetFormat": "mp4"}, "files": [{"fileId": "a", "fileName": "a.mov"}]}`)
+	waitForDownloads(t, env, response.ConversionIDs)
+
+	res := httptest.NewRecorder()
+	env.handler.BatchStatusHandler(res, httptest.NewRequest(http.MethodGet, RouteBatchStatus+response.BatchID, nil))
+	require.Equal(t, http.StatusOK, res.Code)
+	var summary models.BatchStatusResponse
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&summary))
+	assert.Equal(t, response.BatchID, summary.ID)
+	assert.Equal(t, 1, summary.Total)
+	assert.Equal(t, 1, summary.Pending)
+	assert.Equal(t, response.ConversionIDs, summary.ConversionIDs)
+
+	res = httptest.NewRecorder()
+	env.handler.BatchStatusHandler(res, httptest.NewRequest(http.MethodGet, RouteBatchStatus+"missing", nil))
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestCancelBatchHandler(t *testing.T) {
+	env := newHandlerTestEnv(t)
+	stubDriveDownloads(env)
+	response := submitBatch(t, env, `{"options": {"targetFormat": "mp4"}, "files": [
+		{"fileId": "a", "fileName": "a.mov"},
+		{"fileId": "b", "fileName": "b.mov"}
+	]}`)
+	waitForDownloads(t, env, response.ConversionIDs)
+
+	res := httptest.NewRecorder()
+	env.handler.CancelBatchHandler(res, httptest.NewRequest(http.MethodPost, RouteBatchCancel+response.BatchID, nil))
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	var canceled models.BatchResponse
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&canceled))
+	assert.ElementsMatch(t, response.ConversionIDs, canceled.ConversionIDs)
+
+	summary, _ := env.store.GetBatchStatus(response.BatchID)
+	assert.Equal(t, 2, summary.Canceled)
+	assert.True(t, summary.Complete)
+	entries, err := os.ReadDir(env.uploadsDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "downloaded sources are removed")
+
+	res = httptest.NewRecorder()
+	env.handler.CancelBatchHandler(res, httptest.NewRequest(http.MethodPost, RouteBatchCancel+"missing", nil))
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
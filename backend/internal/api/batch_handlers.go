@@ -0,0 +1,163 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/conversion"
+	"github.com/gatanasi/video-converter/internal/filestore"
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/google/uuid"
+)
+
+// ConvertBatchHandler queues conversions of several Google Drive files at once and
+// returns a batch ID. The request is validated as a whole: if any file is invalid,
+// nothing is queued. Like single Drive conversions, the files are downloaded in the
+// background; their progress is reported by the batch status and by "batch" events on
+// the conversion stream.
+func (h *Handler) ConvertBatchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request models.BatchConversionRequest
+	r.Body = http.MaxBytesReader(w, r.Body, constants.MaxJSONRequestSize)
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.sendErrorResponse(w, fmt.Sprintf("Failed to parse request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(request.Files) == 0 {
+		h.sendErrorResponse(w, "Missing required field: files", http.StatusBadRequest)
+		return
+	}
+	if len(request.Files) > constants.MaxBatchFiles {
+		h.sendErrorResponse(w, fmt.Sprintf("A batch holds at most %d files", constants.MaxBatchFiles), http.StatusBadRequest)
+		return
+	}
+
+	jobs := make([]models.ConversionJob, 0, len(request.Files))
+	for i, file := range request.Files {
+		job, err := h.newBatchJob(request.Options, file)
+		if err != nil {
+			h.sendErrorResponse(w, fmt.Sprintf("File %d: %v", i+1, err), http.StatusBadRequest)
+			return
+		}
+		job.ClientID = h.clientIdentity(r)
+		jobs = append(jobs, job)
+	}
+
+	batchID := uuid.NewString()
+	ids := make([]string, len(jobs))
+	for i, job := range jobs {
+		job.Status.BatchID = batchID
+		h.Store.SetStatus(job.ConversionID, job.Status)
+		ids[i] = job.ConversionID
+	}
+	if err := h.Converter.SubmitDriveJobs(jobs); err != nil {
+		for _, id := range ids {
+			h.Store.DeleteStatus(id)
+		}
+		h.sendCodedErrorResponse(w, models.ErrorCodeQueueFull, "Server busy, conversion queue cannot hold this batch", http.StatusServiceUnavailable)
+		return
+	}
+	log.Printf("Batch %s submitted with %d files", batchID, len(jobs))
+
+	response := models.BatchResponse{
+		Success:       true,
+		Message:       "Batch accepted",
+		BatchID:       batchID,
+		ConversionIDs: ids,
+	}
+	h.sendJSONResponse(w, response, http.StatusAccepted)
+}
+
+// newBatchJob builds the job for one file of a batch from the shared options and the
+// file's own request, whose fields take precedence.
+func (h *Handler) newBatchJob(options, file json.RawMessage) (models.ConversionJob, error) {
+	var request models.DriveConversionRequest
+	for _, layer := range []json.RawMessage{options, file} {
+		if len(bytes.TrimSpace(layer)) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(layer, &request); err != nil {
+			return models.ConversionJob{}, fmt.Errorf("invalid request format: %w", err)
+		}
+	}
+
+	if request.FileID == "" || request.FileName == "" || request.TargetFormat == "" {
+		return models.ConversionJob{}, errors.New("missing required fields: fileId, fileName, targetFormat")
+	}
+	if !validFormats[request.TargetFormat] {
+		return models.ConversionJob{}, errors.New("invalid target format specified")
+	}
+
+	sanitizedBaseName := filestore.SanitizeFilename(request.FileName)
+	if sanitizedBaseName == "" {
+		sanitizedBaseName = fmt.Sprintf("gdrive-video-%s", request.FileID)
+	}
+	conversionID := uuid.NewString()
+	uploadedFilePath, outputFilePath, err := h.resolveAndValidatePaths(sanitizedBaseName, request.TargetFormat, conversionID)
+	if err != nil {
+		return models.ConversionJob{}, err
+	}
+	return h.newConversionJob(conversionID, request, uploadedFilePath, outputFilePath)
+}
+
+// BatchStatusHandler returns the aggregate status of a batch.
+func (h *Handler) BatchStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	batchID := strings.TrimPrefix(r.URL.Path, RouteBatchStatus)
+	if batchID == "" {
+		h.sendErrorResponse(w, "Missing batch ID", http.StatusBadRequest)
+		return
+	}
+
+	summary, exists := h.Store.GetBatchStatus(batchID)
+	if !exists {
+		h.sendErrorResponse(w, "Batch not found", http.StatusNotFound)
+		return
+	}
+	h.sendJSONResponse(w, summary, http.StatusOK)
+}
+
+// CancelBatchHandler cancels every unfinished job of a batch.
+func (h *Handler) CancelBatchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	batchID := strings.TrimPrefix(r.URL.Path, RouteBatchCancel)
+	if batchID == "" {
+		h.sendErrorResponse(w, "Missing batch ID", http.StatusBadRequest)
+		return
+	}
+
+	canceled, err := h.Converter.CancelBatch(batchID)
+	if err != nil {
+		if errors.Is(err, conversion.ErrBatchNotFound) {
+			h.sendErrorResponse(w, "Batch not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("ERROR: Failed to cancel batch %s: %v", batchID, err)
+		h.sendErrorResponse(w, "Failed to cancel batch", http.StatusInternalServerError)
+		return
+	}
+
+	response := models.BatchResponse{
+		Success:       true,
+		Message:       fmt.Sprintf("Canceled %d conversions", len(canceled)),
+		BatchID:       batchID,
+		ConversionIDs: canceled,
+	}
+	h.sendJSONResponse(w, response, http.StatusOK)
+}
@@ -5,13 +5,81 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
 	"testing"
 
+	"github.com/gatanasi/video-converter/internal/drive"
 	"github.com/gatanasi/video-converter/internal/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestConvertFromDriveHandler(t *testing.T) {
+	t.Run("responds before the download and queues the job after it", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		stubDriveDownloads(env)
+
+		payload := `{"fileId": "drive-file", "fileName": "clip.mov", "targetFormat": "mp4"}`
+		res := httptest.NewRecorder()
+		env.handler.ConvertFromDriveHandler(res, httptest.NewRequest(http.MethodPost, RouteConvertFromDrive, strings.NewReader(payload)))
+
+		id := decodeQueuedID(t, res)
+		waitForDownloads(t, env, []string{id})
+		status, ok := env.store.GetStatus(id)
+		require.True(t, ok)
+		assert.Equal(t, models.PhaseQueued, status.Phase)
+		assert.Equal(t, 1, status.QueuePosition)
+		require.NotNil(t, status.Download)
+		assert.Equal(t, status.Download.TotalBytes, status.Download.BytesDownloaded)
+		require.GreaterOrEqual(t, len(status.Phases), 2)
+		assert.Equal(t, models.PhaseDownloading, status.Phases[len(status.Phases)-2].Phase)
+
+		job, ok := env.store.GetJob(id)
+		require.True(t, ok)
+		assert.False(t, job.DownloadPending)
+		assert.FileExists(t, job.UploadedFilePath)
+	})
+
+	t.Run("failed download fails the job", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		stubDriveDownloads(env, "missing-file")
+
+		payload := `{"fileId": "missing-file", "fileName": "clip.mov", "targetFormat": "mp4"}`
+		res := httptest.NewRecorder()
+		env.handler.ConvertFromDriveHandler(res, httptest.NewRequest(http.MethodPost, RouteConvertFromDrive, strings.NewReader(payload)))
+
+		id := decodeQueuedID(t, res)
+		waitForDownloads(t, env, []string{id})
+		status, _ := env.store.GetStatus(id)
+		assert.Equal(t, models.PhaseFailed, status.Phase)
+		assert.Equal(t, models.ErrorCodeDriveNotFound, status.ErrorCode)
+		entries, err := os.ReadDir(env.uploadsDir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("rejects jobs when the queue is full", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		stubDriveDownloads(env)
+		payload := `{"fileId": "drive-file", "fileName": "clip.mov", "targetFormat": "mp4"}`
+		for range env.handler.Config.MaxQueuedJobs {
+			res := httptest.NewRecorder()
+			env.handler.ConvertFromDriveHandler(res, httptest.NewRequest(http.MethodPost, RouteConvertFromDrive, strings.NewReader(payload)))
+			decodeQueuedID(t, res)
+		}
+
+		res := httptest.NewRecorder()
+		env.handler.ConvertFromDriveHandler(res, httptest.NewRequest(http.MethodPost, RouteConvertFromDrive, strings.NewReader(payload)))
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+		var response models.ConversionResponse
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+		assert.Equal(t, models.ErrorCodeQueueFull, response.ErrorCode)
+		assert.Len(t, env.store.GetAllStatuses(), env.handler.Config.MaxQueuedJobs)
+	})
+
 	t.Run("missing fields", func(t *testing.T) {
 		env := newHandlerTestEnv(t)
 
@@ -36,6 +104,7 @@ func TestConvertFromDriveHandler(t *testing.T) {
 		assert.NoError(t, decodeErr)
 		assert.False(t, response.Success)
 		assert.Contains(t, response.Error, "required fields")
+		assert.Equal(t, models.ErrorCodeInvalidRequest, response.ErrorCode)
 	})
 
 	t.Run("invalid format", func(t *testing.T) {
@@ -65,6 +134,68 @@ func TestConvertFromDriveHandler(t *testing.T) {
 	})
 }
 
+func TestConvertFromDriveHandler_Destination(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     string
+		credentials bool
+		wantErr     string
+	}{
+		{
+			name:        "defaults to the source folder",
+			payload:     `{"fileId": "drive-file", "fileName": "clip.mov", "targetFormat": "mp4", "destination": {}}`,
+			credentials: true,
+		},
+		{
+			name:        "chosen folder",
+			payload:     `{"fileId": "drive-file", "fileName": "clip.mov", "targetFormat": "mp4", "destination": {"folderId": "exports"}}`,
+			credentials: true,
+		},
+		{
+			name:    "requires credentials",
+			payload: `{"fileId": "drive-file", "fileName": "clip.mov", "targetFormat": "mp4", "destination": {}}`,
+			wantErr: "Google credentials",
+		},
+		{
+			name:        "scene detection has no single output",
+			payload:     `{"fileId": "drive-file", "fileName": "clip.mov", "targetFormat": "mp4", "jobType": "scenes", "destination": {}}`,
+			credentials: true,
+			wantErr:     "scene detection",
+		},
+		{
+			name:        "silence previews have no output",
+			payload:     `{"fileId": "drive-file", "fileName": "clip.mov", "targetFormat": "mp4", "jobType": "silence", "silence": {"previewOnly": true}, "destination": {}}`,
+			credentials: true,
+			wantErr:     "silence previews",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newHandlerTestEnv(t)
+			stubDriveDownloads(env)
+			if tt.credentials {
+				useDriveCredentials(env)
+			}
+
+			res := httptest.NewRecorder()
+			env.handler.ConvertFromDriveHandler(res, httptest.NewRequest(http.MethodPost, RouteConvertFromDrive, strings.NewReader(tt.payload)))
+
+			if tt.wantErr != "" {
+				assert.Equal(t, http.StatusBadRequest, res.Code)
+				assert.Contains(t, res.Body.String(), tt.wantErr)
+				return
+			}
+			id := decodeQueuedID(t, res)
+			waitForDownloads(t, env, []string{id})
+			job, ok := env.store.GetJob(id)
+			require.True(t, ok)
+			var request models.DriveConversionRequest
+			require.NoError(t, json.Unmarshal([]byte(tt.payload), &request))
+			assert.Equal(t, request.Destination, job.Destination)
+		})
+	}
+}
+
 func TestListDriveVideosHandler(t *testing.T) {
 	t.Run("missing folder id", func(t *testing.T) {
 		env := newHandlerTestEnv(t)
@@ -83,6 +214,79 @@ func TestListDriveVideosHandler(t *testing.T) {
 		assert.Contains(t, response.Error, "folderId")
 	})
 
+	t.Run("lists the videos of a folder", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		var query url.Values
+		useFakeDrive(t, env, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query = r.URL.Query()
+			_, _ = w.Write([]byte(`{"files": [{"id": "v1", "name": "clip.mov", "mimeType": "video/quicktime", "size": "2048"}]}`))
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, RouteListDriveVideos+"?folderId=folder&search=clip&minSize=1024&modifiedAfter=2024-05-01", nil)
+		res := httptest.NewRecorder()
+		env.handler.ListDriveVideosHandler(res, req)
+
+		require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+		var files []models.GoogleDriveFile
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&files))
+		require.Len(t, files, 1)
+		assert.Equal(t, "v1", files[0].ID)
+		assert.Equal(t, "test-api-key", query.Get("key"))
+		assert.Contains(t, query.Get("q"), "name contains 'clip'")
+		assert.Contains(t, query.Get("q"), "modifiedTime > '2024-05-01T00:00:00Z'")
+	})
+
+	t.Run("lists subfolders as a tree", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		useFakeDrive(t, env, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.URL.Query().Get("q"), "'folder' in parents") {
+				_, _ = w.Write([]byte(`{"files": [{"id": "sub", "name": "Trip", "mimeType": "application/vnd.google-apps.folder"}]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"files": [{"id": "v1", "name": "clip.mov", "mimeType": "video/quicktime"}]}`))
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, RouteListDriveVideos+"?folderId=folder&recursive=true", nil)
+		res := httptest.NewRecorder()
+		env.handler.ListDriveVideosHandler(res, req)
+
+		require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+		var tree models.GoogleDriveFolder
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&tree))
+		assert.Equal(t, "folder", tree.ID)
+		assert.Empty(t, tree.Files)
+		require.Len(t, tree.Folders, 1)
+		assert.Equal(t, "Trip", tree.Folders[0].Name)
+		require.Len(t, tree.Folders[0].Files, 1)
+		assert.Equal(t, "v1", tree.Folders[0].Files[0].ID)
+	})
+
+	t.Run("missing folder on Drive", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		useFakeDrive(t, env, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"error":{"code":404,"message":"File not found"}}`, http.StatusNotFound)
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, RouteListDriveVideos+"?folderId=missing", nil)
+		res := httptest.NewRecorder()
+		env.handler.ListDriveVideosHandler(res, req)
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+		assert.Contains(t, res.Body.String(), models.ErrorCodeDriveNotFound)
+	})
+
+	for _, query := range []string{"recursive=maybe", "minSize=-1", "maxSize=big", "minSize=10&maxSize=5", "modifiedBefore=yesterday"} {
+		t.Run("invalid filter "+query, func(t *testing.T) {
+			env := newHandlerTestEnv(t)
+
+			req := httptest.NewRequest(http.MethodGet, RouteListDriveVideos+"?folderId=folder&"+query, nil)
+			res := httptest.NewRecorder()
+			env.handler.ListDriveVideosHandler(res, req)
+
+			assert.Equal(t, http.StatusBadRequest, res.Code)
+		})
+	}
+
 	t.Run("method not allowed", func(t *testing.T) {
 		env := newHandlerTestEnv(t)
 
@@ -94,3 +298,82 @@ func TestListDriveVideosHandler(t *testing.T) {
 		assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
 	})
 }
+
+func TestDriveThumbnailHandler(t *testing.T) {
+	newEnv := func(t *testing.T) *handlerTestEnv {
+		env := newHandlerTestEnv(t)
+		var server *httptest.Server
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/drive/v3/files/video":
+				_, _ = w.Write([]byte(`{"thumbnailLink": "` + server.URL + `/thumbnail.png"}`))
+			case "/drive/v3/files/no-thumbnail":
+				_, _ = w.Write([]byte(`{}`))
+			case "/thumbnail.png":
+				w.Header().Set("Content-Type", "image/png")
+				_, _ = w.Write([]byte("png data"))
+			default:
+				http.Error(w, `{"error":{"code":404,"message":"File not found"}}`, http.StatusNotFound)
+			}
+		}))
+		t.Cleanup(server.Close)
+		env.handler.Drive = drive.NewClient(env.handler.Config.GoogleDriveAPIKey, server.URL)
+		return env
+	}
+
+	t.Run("proxies the thumbnail", func(t *testing.T) {
+		env := newEnv(t)
+
+		res := httptest.NewRecorder()
+		env.handler.DriveThumbnailHandler(res, httptest.NewRequest(http.MethodGet, RouteDriveThumbnail+"video", nil))
+
+		require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+		assert.Equal(t, "image/png", res.Header().Get("Content-Type"))
+		assert.Equal(t, "nosniff", res.Header().Get("X-Content-Type-Options"))
+		assert.Contains(t, res.Header().Get("Cache-Control"), "max-age=")
+		assert.Equal(t, "png data", res.Body.String())
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		fileID     string
+		wantStatus int
+	}{
+		{"no thumbnail", http.MethodGet, "no-thumbnail", http.StatusNotFound},
+		{"missing file", http.MethodGet, "missing", http.StatusNotFound},
+		{"nested path", http.MethodGet, "video/other", http.StatusBadRequest},
+		{"missing file ID", http.MethodGet, "", http.StatusBadRequest},
+		{"method not allowed", http.MethodPost, "video", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newEnv(t)
+
+			res := httptest.NewRecorder()
+			env.handler.DriveThumbnailHandler(res, httptest.NewRequest(tt.method, RouteDriveThumbnail+tt.fileID, nil))
+
+			assert.Equal(t, tt.wantStatus, res.Code)
+		})
+	}
+}
+
+func TestDriveErrorStatus(t *testing.T) {
+	tests := []struct {
+		code       string
+		wantStatus int
+	}{
+		{models.ErrorCodeDriveNotFound, http.StatusNotFound},
+		{models.ErrorCodeDriveQuota, http.StatusTooManyRequests},
+		{models.ErrorCodeDriveAuth, http.StatusBadGateway},
+		{models.ErrorCodeFileTooLarge, http.StatusRequestEntityTooLarge},
+		{models.ErrorCodeDiskFull, http.StatusInsufficientStorage},
+		{models.ErrorCodeDriveError, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			assert.Equal(t, tt.wantStatus, driveErrorStatus(tt.code))
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunHandler(t *testing.T) {
+	t.Run("returns plan without side effects", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		request := models.DriveConversionRequest{
+			FileID:       "drive-file",
+			FileName:     "holiday clip.mov",
+			TargetFormat: "mp4",
+			Quality:      "fast",
+		}
+		payload, marshalErr := json.Marshal(request)
+		require.NoError(t, marshalErr)
+
+		req := httptest.NewRequest(http.MethodPost, RouteConvertDryRun, bytes.NewReader(payload))
+		res := httptest.NewRecorder()
+
+		env.handler.DryRunHandler(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		var plan models.ConversionPlan
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&plan))
+		assert.True(t, plan.DryRun)
+		assert.Equal(t, "fast", plan.Quality.Name)
+		assert.Regexp(t, `^holiday_clip-[0-9a-f-]{3}\.mp4$`, plan.OutputFileName)
+		assert.Len(t, plan.Commands, 3)
+
+		// Nothing is queued or written
+		assert.Empty(t, env.store.GetAllStatuses())
+		entries, readErr := os.ReadDir(env.uploadsDir)
+		require.NoError(t, readErr)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		payload := []byte(`{"fileName":"a.mov","targetFormat":"avi"}`)
+		req := httptest.NewRequest(http.MethodPost, RouteConvertDryRun, bytes.NewReader(payload))
+		res := httptest.NewRecorder()
+
+		env.handler.DryRunHandler(res, req)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("unknown priority", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		payload := []byte(`{"fileName":"a.mov","targetFormat":"mp4","priority":"urgent"}`)
+		req := httptest.NewRequest(http.MethodPost, RouteConvertDryRun, bytes.NewReader(payload))
+		res := httptest.NewRecorder()
+
+		env.handler.DryRunHandler(res, req)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+		assert.Contains(t, res.Body.String(), "unknown priority")
+	})
+}
+
+func TestPlanHandler(t *testing.T) {
+	t.Run("plan recorded on job", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		conversionID := "job-with-plan"
+		env.store.SetStatus(conversionID, &models.ConversionStatus{
+			OutputPath: filepath.Join(env.convertedDir, "out.mp4"),
+			Plan: &models.ConversionPlan{
+				ConversionID:   conversionID,
+				OutputFileName: "out.mp4",
+				Commands:       []models.CommandInvocation{{Program: "ffmpeg", Args: []string{"-i", "in.mov"}}},
+			},
+		})
+
+		req := httptest.NewRequest(http.MethodGet, RouteConversionPlan+conversionID, nil)
+		res := httptest.NewRecorder()
+
+		env.handler.PlanHandler(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		var plan models.ConversionPlan
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&plan))
+		assert.Equal(t, "out.mp4", plan.OutputFileName)
+		assert.False(t, plan.DryRun)
+	})
+
+	t.Run("conversion not found", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		req := httptest.NewRequest(http.MethodGet, RouteConversionPlan+"missing-id", nil)
+		res := httptest.NewRecorder()
+
+		env.handler.PlanHandler(res, req)
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gatanasi/video-converter/internal/constants"
 	"github.com/gatanasi/video-converter/internal/models"
 	"github.com/stretchr/testify/assert"
 )
@@ -91,6 +92,31 @@ func TestListFilesHandler(t *testing.T) {
 		assert.Len(t, files, 1, "Only completed files should be returned")
 		assert.Equal(t, "completed.mp4", files[0].Name)
 	})
+
+	t.Run("includes quality metrics", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		assert.NoError(t, os.WriteFile(filepath.Join(env.convertedDir, "measured.mp4"), []byte("video"), 0o644))
+		metricsDir := filepath.Join(env.dataDir, constants.MetricsSubdir)
+		assert.NoError(t, os.MkdirAll(metricsDir, 0o755))
+		sidecar := `{"vmaf":94.5,"ssim":0.98,"psnr":41.2,"mode":"sampled","comparedSeconds":10,"quality":{"name":"default","preset":"medium","crf":23}}`
+		assert.NoError(t, os.WriteFile(filepath.Join(metricsDir, "measured.mp4.json"), []byte(sidecar), 0o644))
+
+		req := httptest.NewRequest(http.MethodGet, RouteListFiles, nil)
+		res := httptest.NewRecorder()
+
+		env.handler.ListFilesHandler(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		var files []models.FileInfo
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&files))
+		assert.Len(t, files, 1)
+		if assert.NotNil(t, files[0].Metrics) && assert.NotNil(t, files[0].Metrics.VMAF) {
+			assert.Equal(t, 94.5, *files[0].Metrics.VMAF)
+			assert.Equal(t, 23, files[0].Metrics.Quality.CRF)
+		}
+	})
 }
 
 func TestDeleteFileHandler(t *testing.T) {
@@ -117,6 +143,26 @@ func TestDeleteFileHandler(t *testing.T) {
 		assert.True(t, os.IsNotExist(statErr))
 	})
 
+	t.Run("removes quality metrics", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		target := filepath.Join(env.convertedDir, "measured.mp4")
+		assert.NoError(t, os.WriteFile(target, []byte("video"), 0o644))
+		metricsDir := filepath.Join(env.dataDir, constants.MetricsSubdir)
+		assert.NoError(t, os.MkdirAll(metricsDir, 0o755))
+		sidecar := filepath.Join(metricsDir, "measured.mp4.json")
+		assert.NoError(t, os.WriteFile(sidecar, []byte(`{"mode":"full"}`), 0o644))
+
+		req := httptest.NewRequest(http.MethodDelete, RouteDeleteFile+"measured.mp4", nil)
+		res := httptest.NewRecorder()
+
+		env.handler.DeleteFileHandler(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		_, statErr := os.Stat(sidecar)
+		assert.True(t, os.IsNotExist(statErr))
+	})
+
 	t.Run("file not found", func(t *testing.T) {
 		env := newHandlerTestEnv(t)
 
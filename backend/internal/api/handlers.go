@@ -1,19 +1,22 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"mime/multipart"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
-	"runtime"
 	"sort"
 	"strconv"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/gatanasi/video-converter/internal/constants"
@@ -25,19 +28,24 @@ import (
 	"github.com/google/uuid"
 )
 
+// validFormats are the target formats accepted by every conversion endpoint.
+var validFormats = map[string]bool{"mov": true, "mp4": true}
+
 // Handler encapsulates dependencies for API handlers.
 type Handler struct {
 	Config    models.Config
 	Converter *conversion.VideoConverter
 	Store     *conversion.Store
+	Drive     *drive.Client
 }
 
 // NewHandler creates a new API handler.
-func NewHandler(config models.Config, converter *conversion.VideoConverter, store *conversion.Store) *Handler {
+func NewHandler(config models.Config, converter *conversion.VideoConverter, store *conversion.Store, driveClient *drive.Client) *Handler {
 	return &Handler{
 		Config:    config,
 		Converter: converter,
 		Store:     store,
+		Drive:     driveClient,
 	}
 }
 
@@ -45,14 +53,32 @@ func NewHandler(config models.Config, converter *conversion.VideoConverter, stor
 func (h *Handler) SetupRoutes(mux *http.ServeMux) {
 	// API Routes
 	mux.HandleFunc(RouteConfig, h.ConfigHandler)
+	mux.HandleFunc(RouteLUTs, h.LUTsHandler)
 	mux.HandleFunc(RouteListDriveVideos, h.ListDriveVideosHandler)
+	mux.HandleFunc(RouteDriveThumbnail, h.DriveThumbnailHandler)
 	mux.HandleFunc(RouteConvertFromDrive, h.ConvertFromDriveHandler)
 	mux.HandleFunc(RouteConvertUpload, h.UploadConvertHandler)
+	mux.HandleFunc(RouteConvertDryRun, h.DryRunHandler)
+	mux.HandleFunc(RouteConvertPreview, h.PreviewHandler)
+	mux.HandleFunc(RouteConvertLibrary, h.ReconvertLibraryFileHandler)
+	mux.HandleFunc(RouteConvertBatch, h.ConvertBatchHandler)
+	mux.HandleFunc(RouteBatchStatus, h.BatchStatusHandler)
+	mux.HandleFunc(RouteBatchCancel, h.CancelBatchHandler)
+	mux.HandleFunc(RoutePreviewFile, h.PreviewFileHandler)
 	mux.HandleFunc(RouteActiveConversions, h.ActiveConversionsHandler)
 	mux.HandleFunc(RouteActiveConversionsStream, h.ActiveConversionsStreamHandler)
 	mux.HandleFunc(RouteConversionStatus, h.StatusHandler)
 	mux.HandleFunc(RouteConversionAbort, h.AbortConversionHandler)
+	mux.HandleFunc(RouteConversionPause, h.PauseConversionHandler)
+	mux.HandleFunc(RouteConversionResume, h.ResumeConversionHandler)
+	mux.HandleFunc(RouteConversionRetry, h.RetryConversionHandler)
+	mux.HandleFunc(RouteConversionRerun, h.RerunConversionHandler)
+	mux.HandleFunc(RouteConversionsPauseAll, h.PauseAllHandler)
+	mux.HandleFunc(RouteConversionsResumeAll, h.ResumeAllHandler)
+	mux.HandleFunc(RouteConversionPlan, h.PlanHandler)
+	mux.HandleFunc(RouteConversionThumbnail, h.ThumbnailHandler)
 	mux.HandleFunc(RouteListFiles, h.ListFilesHandler)
+	mux.HandleFunc(RouteDownloadArchive, h.DownloadArchiveHandler)
 	mux.HandleFunc(RouteDeleteFile, h.DeleteFileHandler)
 	mux.HandleFunc(RouteDownload, h.DownloadHandler)
 
@@ -129,7 +155,10 @@ func (h *Handler) SetupRoutes(mux *http.ServeMux) {
 	})
 }
 
-// ListDriveVideosHandler lists videos from a Google Drive folder.
+// ListDriveVideosHandler lists videos from a Google Drive folder. Videos can be filtered
+// by name ("search"), size in bytes ("minSize", "maxSize") and modification time
+// ("modifiedAfter", "modifiedBefore", RFC 3339 or YYYY-MM-DD). The videos of the folder
+// are returned as a list, or with "recursive=true" as a tree including its subfolders.
 func (h *Handler) ListDriveVideosHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -141,34 +170,133 @@ func (h *Handler) ListDriveVideosHandler(w http.ResponseWriter, r *http.Request)
 		h.sendErrorResponse(w, "Missing 'folderId' query parameter", http.StatusBadRequest)
 		return
 	}
+	opts, err := parseDriveListOptions(r.URL.Query())
+	if err != nil {
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts.FolderID = folderID
 
-	log.Printf("Listing videos for folder: %s", folderID)
-	responseBytes, err := drive.ListVideos(folderID, h.Config.GoogleDriveAPIKey)
+	log.Printf("Listing videos for folder: %s (recursive: %t)", folderID, opts.Recursive)
+	folder, err := h.Drive.ListVideos(r.Context(), opts)
 	if err != nil {
 		errMsg := fmt.Sprintf("Failed to list videos from Google Drive: %v", err)
 		log.Printf("ERROR: %s", errMsg)
-		h.sendErrorResponse(w, errMsg, http.StatusInternalServerError)
+		code := conversion.DriveErrorCode(err)
+		h.sendCodedErrorResponse(w, code, errMsg, driveErrorStatus(code))
 		return
 	}
 
-	var fileList models.GoogleDriveFileList
-	if err := json.Unmarshal(responseBytes, &fileList); err != nil {
-		errMsg := fmt.Sprintf("Failed to parse response from Google Drive: %v", err)
-		log.Printf("ERROR: %s", errMsg)
-		h.sendErrorResponse(w, errMsg, http.StatusInternalServerError)
+	if opts.Recursive {
+		h.sendJSONResponse(w, folder, http.StatusOK)
+		return
+	}
+	h.sendJSONResponse(w, folder.Files, http.StatusOK)
+}
+
+// DriveThumbnailHandler serves the thumbnail of a Google Drive file, so that browsers
+// can show thumbnails without access to Drive.
+func (h *Handler) DriveThumbnailHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	fileID := strings.TrimPrefix(r.URL.Path, RouteDriveThumbnail)
+	if fileID == "" || strings.Contains(fileID, "/") {
+		h.sendErrorResponse(w, "Invalid file ID", http.StatusBadRequest)
 		return
 	}
 
-	h.sendJSONResponse(w, fileList.Files, http.StatusOK)
+	thumbnail, err := h.Drive.GetThumbnail(r.Context(), fileID)
+	if err != nil {
+		if errors.Is(err, drive.ErrNoThumbnail) {
+			h.sendErrorResponse(w, "No thumbnail available", http.StatusNotFound)
+			return
+		}
+		log.Printf("ERROR: Failed to fetch thumbnail of Drive file %s: %v", fileID, err)
+		code := conversion.DriveErrorCode(err)
+		h.sendCodedErrorResponse(w, code, "Failed to fetch thumbnail from Google Drive", driveErrorStatus(code))
+		return
+	}
+	defer func() {
+		if closeErr := thumbnail.Body.Close(); closeErr != nil {
+			log.Printf("WARN: Error closing thumbnail of Drive file %s: %v", fileID, closeErr)
+		}
+	}()
+
+	w.Header().Set("Content-Type", thumbnail.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(constants.DriveThumbnailCacheMaxAge.Seconds())))
+	if _, err := io.Copy(w, thumbnail.Body); err != nil {
+		log.Printf("WARN: Failed to send thumbnail of Drive file %s: %v", fileID, err)
+	}
+}
+
+// parseDriveListOptions reads the filters of a Drive listing from its query parameters.
+func parseDriveListOptions(query url.Values) (drive.ListOptions, error) {
+	var opts drive.ListOptions
+	var err error
+	if value := query.Get("recursive"); value != "" {
+		if opts.Recursive, err = strconv.ParseBool(value); err != nil {
+			return opts, fmt.Errorf("invalid 'recursive' value %q", value)
+		}
+	}
+	opts.NameContains = strings.TrimSpace(query.Get("search"))
+
+	for _, param := range []struct {
+		name   string
+		target *int64
+	}{{"minSize", &opts.MinSize}, {"maxSize", &opts.MaxSize}} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		if *param.target, err = strconv.ParseInt(value, 10, 64); err != nil || *param.target < 0 {
+			return opts, fmt.Errorf("invalid '%s' value %q: expected a number of bytes", param.name, value)
+		}
+	}
+	if opts.MaxSize > 0 && opts.MinSize > opts.MaxSize {
+		return opts, errors.New("'minSize' must not exceed 'maxSize'")
+	}
+
+	for _, param := range []struct {
+		name   string
+		target *time.Time
+	}{{"modifiedAfter", &opts.ModifiedAfter}, {"modifiedBefore", &opts.ModifiedBefore}} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		if *param.target, err = parseListTime(value); err != nil {
+			return opts, fmt.Errorf("invalid '%s' value %q: expected an RFC 3339 time or a YYYY-MM-DD date", param.name, value)
+		}
+	}
+	return opts, nil
+}
+
+// parseListTime parses an RFC 3339 time or a date, taken as midnight UTC.
+func parseListTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
 }
 
+// Errors returned by the path validation helpers for callers to branch on.
+var (
+	errServerConfig    = errors.New("internal server configuration error")
+	errFileNotFound    = errors.New("file not found")
+	errInvalidFilePath = errors.New("invalid file path")
+	errInvalidFileType = errors.New("invalid file type")
+)
+
 // isPathWithinBase checks if the targetPath is safely within the baseDir.
 // It resolves both paths to absolute paths for comparison.
 func isPathWithinBase(baseDir, targetPath string) (bool, error) {
 	absBaseDir, err := filepath.Abs(baseDir)
 	if err != nil {
 		log.Printf("CRITICAL: Could not determine absolute path for base directory '%s': %v", baseDir, err)
-		return false, fmt.Errorf("internal server configuration error (base dir)")
+		return false, fmt.Errorf("%w (base dir)", errServerConfig)
 	}
 
 	absTargetPath, err := filepath.Abs(targetPath)
@@ -194,7 +322,7 @@ func resolveAndValidateSubPath(baseDirConfig string, relativeSubPath string) (st
 	if err != nil {
 		log.Printf("CRITICAL: Could not determine absolute path for base directory '%s': %v", baseDirConfig, err)
 		// Use a generic error message to avoid leaking internal paths
-		return "", fmt.Errorf("internal server configuration error (base dir)")
+		return "", fmt.Errorf("%w (base dir)", errServerConfig)
 	}
 
 	// Clean and resolve the full path for the subpath
@@ -248,29 +376,135 @@ func (h *Handler) resolveAndValidatePaths(baseFileName, targetFormat, conversion
 	return absInputPath, absOutputPath, nil
 }
 
+// newConversionJob builds a conversion job and its initial status for the given request,
+// attaching the execution plan so it can be inspected while and after the job runs.
+func (h *Handler) newConversionJob(conversionID string, request models.DriveConversionRequest, uploadedFilePath, outputFilePath string) (models.ConversionJob, error) {
+	qualitySetting := conversion.ResolveQualitySetting(request.Quality)
+	if request.Quality != "" && !conversion.IsValidQualityName(request.Quality) {
+		log.Printf("WARN: Unknown quality '%s' requested, defaulting to '%s'", request.Quality, qualitySetting.Name)
+	}
+
+	job := models.ConversionJob{
+		ConversionID:     conversionID,
+		FileID:           request.FileID,
+		FileName:         request.FileName,
+		TargetFormat:     request.TargetFormat,
+		Quality:          qualitySetting.Name,
+		VideoPreset:      qualitySetting.Preset,
+		VideoCRF:         qualitySetting.CRF,
+		UploadedFilePath: uploadedFilePath,
+		OutputFilePath:   outputFilePath,
+		ReverseVideo:     request.ReverseVideo,
+		RemoveSound:      request.RemoveSound,
+		Stabilization:    request.Stabilization,
+		Filters:          request.Filters,
+		JobType:          request.JobType,
+		Scenes:           request.Scenes,
+		Silence:          request.Silence,
+		ThumbnailsDir:    filepath.Join(h.Config.DataDir, constants.ThumbnailsSubdir, conversionID),
+		Metrics:          request.Metrics,
+		TargetVMAF:       request.TargetVMAF,
+		Priority:         request.Priority,
+	}
+	priority, err := conversion.ResolvePriority(job)
+	if err != nil {
+		return models.ConversionJob{}, fmt.Errorf("invalid conversion options: %w", err)
+	}
+	job.Priority = priority
+	if err := h.validateDestination(request); err != nil {
+		return models.ConversionJob{}, fmt.Errorf("invalid conversion options: %w", err)
+	}
+	job.Destination = request.Destination
+	if request.Metrics != nil {
+		job.MetricsPath = conversion.MetricsSidecarPath(h.metricsDir(), filepath.Base(outputFilePath))
+	}
+
+	if request.Filters != nil && request.Filters.LUT != "" {
+		lutPath, err := h.resolveLUTPath(request.Filters.LUT)
+		if err != nil {
+			return models.ConversionJob{}, err
+		}
+		job.LUTPath = lutPath
+	}
+
+	plan, err := conversion.BuildPlan(job)
+	if err != nil {
+		return models.ConversionJob{}, fmt.Errorf("invalid conversion options: %w", err)
+	}
+	plan.ConversionID = conversionID
+
+	job.Status = &models.ConversionStatus{
+		InputPath:  uploadedFilePath,
+		OutputPath: outputFilePath,
+		Format:     request.TargetFormat,
+		Quality:    qualitySetting.Name,
+		Progress:   0,
+		Complete:   false,
+		Plan:       &plan,
+		JobType:    request.JobType,
+		Phase:      models.PhaseQueued,
+		Phases:     []models.PhaseRecord{{Phase: models.PhaseQueued, StartedAt: time.Now()}},
+	}
+	return job, nil
+}
+
+// validateDestination checks that the output of a request can be uploaded to its Drive
+// destination, if it has one. Without a folder, the output goes to the folder of the
+// source, so uploaded sources must name one.
+func (h *Handler) validateDestination(request models.DriveConversionRequest) error {
+	switch {
+	case request.Destination == nil:
+		return nil
+	case h.Drive == nil || !h.Drive.CanUpload():
+		return errors.New("uploading to Google Drive requires the server to be configured with Google credentials")
+	case request.JobType == models.JobTypeScenes:
+		return errors.New("scene detection jobs cannot be uploaded to Google Drive")
+	case request.Silence != nil && request.Silence.PreviewOnly:
+		return errors.New("silence previews produce no file to upload to Google Drive")
+	case request.Destination.FolderID == "" && request.FileID == "":
+		return errors.New("destination.folderId is required for files not converted from Google Drive")
+	}
+	return nil
+}
+
+// metricsDir returns the directory holding quality metrics sidecar files of converted files.
+func (h *Handler) metricsDir() string {
+	return filepath.Join(h.Config.DataDir, constants.MetricsSubdir)
+}
+
 func (h *Handler) resolveAndValidateConvertedFilePath(r *http.Request, urlPrefix string) (absFilePath, filename string, err error) {
 	filename = strings.TrimPrefix(r.URL.Path, urlPrefix)
+	absFilePath, err = h.resolveConvertedFileName(filename, fmt.Sprintf("URL '%s'", r.URL.Path))
+	if err != nil {
+		return "", "", err
+	}
+	return absFilePath, filename, nil
+}
+
+// resolveConvertedFileName validates a filename of the converted library and returns its
+// absolute path. source describes where the name came from, for logging.
+func (h *Handler) resolveConvertedFileName(filename, source string) (string, error) {
 	// Basic filename validation (prevent directory traversal, empty names, etc.)
 	if filename == "" || strings.Contains(filename, "..") || strings.ContainsAny(filename, "/\\") {
-		log.Printf("WARN: Invalid filename requested via URL %s: %s", r.URL.Path, filename)
-		return "", "", fmt.Errorf("invalid filename")
+		log.Printf("WARN: Invalid filename requested via %s: %s", source, filename)
+		return "", fmt.Errorf("invalid filename")
 	}
 
 	// Resolve and validate the path using the helper
-	absFilePath, err = resolveAndValidateSubPath(h.Config.ConvertedDir, filename)
+	absFilePath, err := resolveAndValidateSubPath(h.Config.ConvertedDir, filename)
 	if err != nil {
-		log.Printf("WARN: Converted file path validation failed for filename '%s' from URL '%s': %v", filename, r.URL.Path, err)
+		log.Printf("WARN: Converted file path validation failed for filename '%s' from %s: %v", filename, source, err)
 		// Map the generic helper error to a more context-specific one for the user.
 		if strings.Contains(err.Error(), "security check failed") || strings.Contains(err.Error(), "invalid file path generated") {
-			return "", "", fmt.Errorf("invalid filename")
+			return "", fmt.Errorf("invalid filename")
 		}
-		if strings.Contains(err.Error(), "internal server configuration error") {
-			return "", "", fmt.Errorf("internal server configuration error")
+		if errors.Is(err, errServerConfig) {
+			return "", errServerConfig
 		}
-		return "", "", fmt.Errorf("failed to validate file path: %w", err)
+		return "", fmt.Errorf("failed to validate file path: %w", err)
 	}
 
-	return absFilePath, filename, nil
+	return absFilePath, nil
 }
 
 // validateFileSafety performs security validations for file operations:
@@ -289,14 +523,14 @@ func (h *Handler) validateFileSafety(baseDir, filePath, operationContext string)
 	if !validPath {
 		log.Printf("SECURITY [%s]: Rejected file operation - path outside allowed directory: %s",
 			operationContext, filePath)
-		return nil, fmt.Errorf("invalid file path")
+		return nil, errInvalidFilePath
 	}
 
 	// 2. Check that the path exists and is a regular file (not a symlink or directory)
 	fileInfo, err := os.Lstat(filePath) // Lstat doesn't follow symlinks
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("file not found")
+			return nil, errFileNotFound
 		}
 		log.Printf("ERROR [%s]: Cannot stat file for operation - %s: %v",
 			operationContext, filePath, err)
@@ -307,7 +541,7 @@ func (h *Handler) validateFileSafety(baseDir, filePath, operationContext string)
 	if !fileInfo.Mode().IsRegular() {
 		log.Printf("SECURITY [%s]: Rejected file operation - not a regular file: %s",
 			operationContext, filePath)
-		return nil, fmt.Errorf("invalid file type")
+		return nil, errInvalidFileType
 	}
 
 	return fileInfo, nil
@@ -318,7 +552,7 @@ func (h *Handler) validateFileSafety(baseDir, filePath, operationContext string)
 func (h *Handler) safeRemoveFile(baseDir, filePath, operationContext string) bool {
 	_, err := h.validateFileSafety(baseDir, filePath, operationContext)
 	if err != nil {
-		if err.Error() == "file not found" {
+		if errors.Is(err, errFileNotFound) {
 			// File doesn't exist anyway, so removal is "successful"
 			return true
 		}
@@ -363,17 +597,11 @@ func (h *Handler) ConvertFromDriveHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	validFormats := map[string]bool{"mov": true, "mp4": true}
 	if !validFormats[request.TargetFormat] {
 		h.sendErrorResponse(w, "Invalid target format specified", http.StatusBadRequest)
 		return
 	}
 
-	qualitySetting := conversion.ResolveQualitySetting(request.Quality)
-	if request.Quality != "" && !conversion.IsValidQualityName(request.Quality) {
-		log.Printf("WARN: Unknown quality '%s' requested, defaulting to '%s'", request.Quality, qualitySetting.Name)
-	}
-
 	sanitizedBaseName := filestore.SanitizeFilename(request.FileName)
 	if sanitizedBaseName == "" {
 		sanitizedBaseName = fmt.Sprintf("gdrive-video-%s", request.FileID) // Fallback
@@ -390,72 +618,188 @@ func (h *Handler) ConvertFromDriveHandler(w http.ResponseWriter, r *http.Request
 	}
 	// --- End Path Resolution ---
 
-	status := &models.ConversionStatus{
-		InputPath:  uploadedFilePath,
-		OutputPath: outputFilePath,
-		Format:     request.TargetFormat,
-		Quality:    qualitySetting.Name,
-		Progress:   0,
-		Complete:   false,
+	job, err := h.newConversionJob(conversionID, request, uploadedFilePath, outputFilePath)
+	if err != nil {
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	job.ClientID = h.clientIdentity(r)
+	h.Store.SetStatus(conversionID, job.Status)
+	if err := h.Converter.SubmitDriveJob(job); err != nil {
+		h.Store.DeleteStatus(conversionID)
+		h.sendCodedErrorResponse(w, models.ErrorCodeQueueFull, "Server busy, conversion queue is full", http.StatusServiceUnavailable)
+		return
 	}
-	h.Store.SetStatus(conversionID, status)
 
-	log.Printf("Starting download for job %s (File ID: %s) to %s", conversionID, request.FileID, uploadedFilePath)
-	if err := drive.DownloadFile(request.FileID, h.Config.GoogleDriveAPIKey, uploadedFilePath, h.Config.MaxFileSize); err != nil {
-		errMsg := fmt.Sprintf("Failed to download file from Google Drive: %v", err)
-		log.Printf("ERROR [job %s]: %s", conversionID, errMsg)
-		genericErrMsg := "Failed to download file from Google Drive"
-		h.Store.UpdateStatusWithError(conversionID, genericErrMsg)
+	response := models.ConversionResponse{
+		Success:      true,
+		Message:      "Conversion job accepted; the file is being downloaded from Google Drive",
+		ConversionID: conversionID,
+	}
+	h.sendJSONResponse(w, response, http.StatusAccepted)
+}
 
-		// Replace the vulnerable path removal with our safe version
-		h.safeRemoveFile(h.Config.UploadsDir, uploadedFilePath, fmt.Sprintf("job %s", conversionID))
+// UploadConvertHandler handles requests to upload a video file and convert it.
+func (h *Handler) UploadConvertHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-		h.sendErrorResponse(w, genericErrMsg, http.StatusInternalServerError)
+	file, handler, ok := h.parseMultipartUpload(w, r)
+	if !ok {
 		return
 	}
-	log.Printf("Download complete for job %s", conversionID)
+	defer func() {
+		if err := r.MultipartForm.RemoveAll(); err != nil {
+			log.Printf("WARN: Error removing multipart temp files: %v", err)
+		}
+	}()
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			log.Printf("WARN: Error closing uploaded file handle: %v", closeErr)
+		}
+	}()
 
-	job := models.ConversionJob{
-		ConversionID:     conversionID,
-		FileID:           request.FileID,
-		FileName:         request.FileName,
-		TargetFormat:     request.TargetFormat,
-		Quality:          qualitySetting.Name,
-		VideoPreset:      qualitySetting.Preset,
-		VideoCRF:         qualitySetting.CRF,
-		UploadedFilePath: uploadedFilePath,
-		OutputFilePath:   outputFilePath,
-		Status:           status,
-		ReverseVideo:     request.ReverseVideo,
-		RemoveSound:      request.RemoveSound,
+	// Get conversion options from form values
+	request, err := parseConversionForm(r)
+	if err != nil {
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	targetFormat := request.TargetFormat
+
+	// --- Prepare file paths and job details ---
+	originalFileName := filepath.Base(handler.Filename)
+	sanitizedBaseName := filestore.SanitizeFilename(originalFileName)
+	// Generate a unique Conversion ID using UUID
+	conversionID := uuid.NewString()
+	if sanitizedBaseName == "" {
+		// Use the generated conversionID for a unique fallback name
+		sanitizedBaseName = fmt.Sprintf("upload-%s", conversionID)
+		log.Printf("WARN: Sanitized filename was empty for original '%s', using fallback: %s", originalFileName, sanitizedBaseName)
+	}
+
+	// --- Resolve and Validate Paths ---
+	uploadedFilePath, outputFilePath, err := h.resolveAndValidatePaths(sanitizedBaseName, targetFormat, conversionID)
+	if err != nil {
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	// --- End Path Resolution ---
+
+	// --- Save the uploaded file ---
+	log.Printf("Saving uploaded file for job %s: %s -> %s", conversionID, originalFileName, uploadedFilePath)
+	written, ok := h.saveUploadedFile(w, file, uploadedFilePath, fmt.Sprintf("job %s", conversionID))
+	if !ok {
+		return
+	}
+	log.Printf("Successfully saved %s for job %s from upload %s", utils.FormatBytesToMB(written), conversionID, originalFileName)
+
+	// --- Queue the conversion job ---
+	request.FileName = originalFileName // Store original uploaded name
+	job, err := h.newConversionJob(conversionID, request, uploadedFilePath, outputFilePath)
+	if err != nil {
+		h.safeRemoveFile(h.Config.UploadsDir, uploadedFilePath, fmt.Sprintf("job %s", conversionID))
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	job.ClientID = h.clientIdentity(r)
+	h.Store.SetStatus(conversionID, job.Status)
 
 	if err := h.Converter.QueueJob(job); err != nil {
-		log.Printf("ERROR [job %s]: Failed to queue job: %v", conversionID, err)
+		log.Printf("ERROR [job %s]: Failed to queue upload job: %v", conversionID, err)
 		h.Store.DeleteStatus(conversionID)
-
-		// Replace with safe removal
+		// Attempt to remove the saved uploaded file
+		// Replace with safe file removal
 		h.safeRemoveFile(h.Config.UploadsDir, uploadedFilePath, fmt.Sprintf("job %s", conversionID))
 
-		h.sendErrorResponse(w, "Server busy, conversion queue is full", http.StatusServiceUnavailable)
+		h.sendCodedErrorResponse(w, models.ErrorCodeQueueFull, "Server busy, conversion queue is full", http.StatusServiceUnavailable)
 		return
 	}
 
 	response := models.ConversionResponse{
 		Success:      true,
-		Message:      "Conversion job queued successfully",
+		Message:      "Upload successful, conversion job queued",
 		ConversionID: conversionID,
 	}
 	h.sendJSONResponse(w, response, http.StatusAccepted)
 }
 
-// UploadConvertHandler handles requests to upload a video file and convert it.
-func (h *Handler) UploadConvertHandler(w http.ResponseWriter, r *http.Request) {
+// DryRunHandler returns the planned conversion pipeline for a conversion payload without running anything.
+func (h *Handler) DryRunHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	var request models.DriveConversionRequest
+	r.Body = http.MaxBytesReader(w, r.Body, constants.MaxJSONRequestSize)
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		errMsg := fmt.Sprintf("Failed to parse request: %v", err)
+		log.Printf("WARN: %s", errMsg)
+		h.sendErrorResponse(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	if request.FileName == "" || request.TargetFormat == "" {
+		h.sendErrorResponse(w, "Missing required fields: fileName, targetFormat", http.StatusBadRequest)
+		return
+	}
+
+	if !validFormats[request.TargetFormat] {
+		h.sendErrorResponse(w, "Invalid target format specified", http.StatusBadRequest)
+		return
+	}
+
+	// Generate the ID exactly as a real job would, so the planned filenames match the naming scheme.
+	conversionID := uuid.NewString()
+	uploadedFilePath, outputFilePath, err := h.resolveAndValidatePaths(filestore.SanitizeFilename(request.FileName), request.TargetFormat, conversionID)
+	if err != nil {
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.newConversionJob(conversionID, request, uploadedFilePath, outputFilePath)
+	if err != nil {
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	plan := *job.Status.Plan
+	plan.DryRun = true
+	h.sendJSONResponse(w, plan, http.StatusOK)
+}
+
+// PlanHandler returns the execution plan recorded for an existing conversion job.
+func (h *Handler) PlanHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, RouteConversionPlan)
+	if id == "" {
+		h.sendErrorResponse(w, "Conversion ID not specified", http.StatusBadRequest)
+		return
+	}
+
+	status, exists := h.Store.GetStatus(id)
+	if !exists {
+		h.sendErrorResponse(w, "Conversion not found or expired", http.StatusNotFound)
+		return
+	}
+	if status.Plan == nil {
+		h.sendErrorResponse(w, "No plan recorded for this conversion", http.StatusNotFound)
+		return
+	}
+
+	h.sendJSONResponse(w, status.Plan, http.StatusOK)
+}
+
+// parseMultipartUpload parses a multipart upload request and returns the uploaded video part.
+// On failure it writes the error response and returns false.
+func (h *Handler) parseMultipartUpload(w http.ResponseWriter, r *http.Request) (multipart.File, *multipart.FileHeader, bool) {
 	// Set max upload size using MaxFileSize from config (ensure it's reasonable for uploads)
 	// Add a buffer for other form fields
 	maxUploadSize := h.Config.MaxFileSize + constants.UploadSizeBuffer
@@ -475,17 +819,15 @@ func (h *Handler) UploadConvertHandler(w http.ResponseWriter, r *http.Request) {
 			log.Printf("WARN: %s", errMsg)
 			h.sendErrorResponse(w, errMsg, http.StatusBadRequest)
 		}
-		return
+		return nil, nil, false
 	}
-	defer func() {
-		if err := r.MultipartForm.RemoveAll(); err != nil {
-			log.Printf("WARN: Error removing multipart temp files: %v", err)
-		}
-	}()
 
 	// Get the file from the form
 	file, handler, err := r.FormFile("videoFile")
 	if err != nil {
+		if removeErr := r.MultipartForm.RemoveAll(); removeErr != nil {
+			log.Printf("WARN: Error removing multipart temp files: %v", removeErr)
+		}
 		if errors.Is(err, http.ErrMissingFile) {
 			h.sendErrorResponse(w, "Missing 'videoFile' part in form data", http.StatusBadRequest)
 		} else {
@@ -493,68 +835,24 @@ func (h *Handler) UploadConvertHandler(w http.ResponseWriter, r *http.Request) {
 			log.Printf("WARN: %s", errMsg)
 			h.sendErrorResponse(w, errMsg, http.StatusBadRequest)
 		}
-		return
-	}
-	defer func() {
-		if closeErr := file.Close(); closeErr != nil {
-			log.Printf("WARN: Error closing uploaded file handle: %v", closeErr)
-		}
-	}()
-
-	// Get conversion options from form values
-	targetFormat := r.FormValue("targetFormat")
-	reverseVideoStr := r.FormValue("reverseVideo")
-	removeSoundStr := r.FormValue("removeSound")
-	qualityStr := r.FormValue("quality")
-
-	if targetFormat == "" {
-		h.sendErrorResponse(w, "Missing required field: targetFormat", http.StatusBadRequest)
-		return
-	}
-	validFormats := map[string]bool{"mov": true, "mp4": true}
-	if !validFormats[targetFormat] {
-		h.sendErrorResponse(w, "Invalid target format specified", http.StatusBadRequest)
-		return
-	}
-
-	reverseVideo := reverseVideoStr == "true"
-	removeSound := removeSoundStr == "true"
-	qualitySetting := conversion.ResolveQualitySetting(qualityStr)
-	if qualityStr != "" && !conversion.IsValidQualityName(qualityStr) {
-		log.Printf("WARN: Unknown quality '%s' requested via upload, defaulting to '%s'", qualityStr, qualitySetting.Name)
+		return nil, nil, false
 	}
+	return file, handler, true
+}
 
-	// --- Prepare file paths and job details ---
-	originalFileName := filepath.Base(handler.Filename)
-	sanitizedBaseName := filestore.SanitizeFilename(originalFileName)
-	// Generate a unique Conversion ID using UUID
-	conversionID := uuid.NewString()
-	if sanitizedBaseName == "" {
-		// Use the generated conversionID for a unique fallback name
-		sanitizedBaseName = fmt.Sprintf("upload-%s", conversionID)
-		log.Printf("WARN: Sanitized filename was empty for original '%s', using fallback: %s", originalFileName, sanitizedBaseName)
-	}
-
-	// --- Resolve and Validate Paths ---
-	uploadedFilePath, outputFilePath, err := h.resolveAndValidatePaths(sanitizedBaseName, targetFormat, conversionID)
-	if err != nil {
-		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-	// --- End Path Resolution ---
-
-	// --- Save the uploaded file ---
-	log.Printf("Saving uploaded file for job %s: %s -> %s", conversionID, originalFileName, uploadedFilePath)
-	outFile, err := os.Create(uploadedFilePath)
+// saveUploadedFile copies an uploaded file to destPath, enforcing the maximum file size.
+// On failure it removes the partial file, writes the error response and returns false.
+func (h *Handler) saveUploadedFile(w http.ResponseWriter, file multipart.File, destPath, logContext string) (int64, bool) {
+	outFile, err := os.Create(destPath)
 	if err != nil {
 		errMsg := fmt.Sprintf("Failed to create file for saving upload: %v", err)
-		log.Printf("ERROR [job %s]: %s", conversionID, errMsg)
+		log.Printf("ERROR [%s]: %s", logContext, errMsg)
 		h.sendErrorResponse(w, errMsg, http.StatusInternalServerError)
-		return
+		return 0, false
 	}
 	defer func() {
 		if closeErr := outFile.Close(); closeErr != nil {
-			log.Printf("WARN [job %s]: Error closing saved upload file %s: %v", conversionID, uploadedFilePath, closeErr)
+			log.Printf("WARN [%s]: Error closing saved upload file %s: %v", logContext, destPath, closeErr)
 		}
 	}()
 
@@ -563,69 +861,171 @@ func (h *Handler) UploadConvertHandler(w http.ResponseWriter, r *http.Request) {
 	written, err := io.Copy(outFile, limitedReader)
 	if err != nil {
 		// Clean up partially written file
-		// Replace with safe file removal
-		h.safeRemoveFile(h.Config.UploadsDir, uploadedFilePath, fmt.Sprintf("job %s", conversionID))
+		h.safeRemoveFile(h.Config.UploadsDir, destPath, logContext)
 
 		errMsg := fmt.Sprintf("Failed to save uploaded file: %v", err)
-		log.Printf("ERROR [job %s]: %s", conversionID, errMsg)
-		h.sendErrorResponse(w, errMsg, http.StatusInternalServerError)
-		return
+		log.Printf("ERROR [%s]: %s", logContext, errMsg)
+		if utils.IsDiskFull(err) {
+			h.sendCodedErrorResponse(w, models.ErrorCodeDiskFull, errMsg, http.StatusInsufficientStorage)
+		} else {
+			h.sendErrorResponse(w, errMsg, http.StatusInternalServerError)
+		}
+		return 0, false
 	}
 
 	// Check if the limit was hit during copy
 	if limitedReader.N <= 0 {
 		// Clean up oversized file
-		// Replace with safe file removal
-		h.safeRemoveFile(h.Config.UploadsDir, uploadedFilePath, fmt.Sprintf("job %s", conversionID))
+		h.safeRemoveFile(h.Config.UploadsDir, destPath, logContext)
 
 		h.sendErrorResponse(w, fmt.Sprintf("Upload failed: File exceeds maximum allowed size (%d MB)", h.Config.MaxFileSize/(1024*1024)), http.StatusRequestEntityTooLarge)
-		return
+		return 0, false
 	}
-	log.Printf("Successfully saved %s for job %s from upload %s", utils.FormatBytesToMB(written), conversionID, originalFileName)
+	return written, true
+}
 
-	// --- Queue the conversion job ---
-	status := &models.ConversionStatus{
-		InputPath:  uploadedFilePath,
-		OutputPath: outputFilePath,
-		Format:     targetFormat,
-		Quality:    qualitySetting.Name,
-		Progress:   0,
-		Complete:   false,
+// clientIdentity identifies the client submitting a request, for fair scheduling between
+// clients: by remote IP address, unless the server trusts client headers, in which case
+// by API key when one is sent and then by the user set by an authenticating proxy. The
+// server itself authenticates neither, so untrusted clients could otherwise claim a new
+// identity, and a new fair share, with every request. API keys are hashed so they never
+// reach the job store.
+func (h *Handler) clientIdentity(r *http.Request) string {
+	if h.Config.TrustClientHeaders {
+		if key := r.Header.Get("X-API-Key"); key != "" {
+			sum := sha256.Sum256([]byte(key))
+			return "key:" + hex.EncodeToString(sum[:8])
+		}
+		if user := r.Header.Get("X-Forwarded-User"); user != "" {
+			return "user:" + user
+		}
 	}
-	h.Store.SetStatus(conversionID, status)
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
 
-	job := models.ConversionJob{
-		ConversionID:     conversionID,
-		FileID:           "",               // No Drive File ID for uploads
-		FileName:         originalFileName, // Store original uploaded name
-		TargetFormat:     targetFormat,
-		Quality:          qualitySetting.Name,
-		VideoPreset:      qualitySetting.Preset,
-		VideoCRF:         qualitySetting.CRF,
-		UploadedFilePath: uploadedFilePath,
-		OutputFilePath:   outputFilePath,
-		Status:           status,
-		ReverseVideo:     reverseVideo,
-		RemoveSound:      removeSound,
+// parseConversionForm reads the conversion options of a multipart upload form.
+func parseConversionForm(r *http.Request) (models.DriveConversionRequest, error) {
+	targetFormat := r.FormValue("targetFormat")
+	if targetFormat == "" {
+		return models.DriveConversionRequest{}, fmt.Errorf("Missing required field: targetFormat")
+	}
+	if !validFormats[targetFormat] {
+		return models.DriveConversionRequest{}, fmt.Errorf("Invalid target format specified")
 	}
 
-	if err := h.Converter.QueueJob(job); err != nil {
-		log.Printf("ERROR [job %s]: Failed to queue upload job: %v", conversionID, err)
-		h.Store.DeleteStatus(conversionID)
-		// Attempt to remove the saved uploaded file
-		// Replace with safe file removal
-		h.safeRemoveFile(h.Config.UploadsDir, uploadedFilePath, fmt.Sprintf("job %s", conversionID))
+	request := models.DriveConversionRequest{
+		TargetFormat: targetFormat,
+		Quality:      r.FormValue("quality"),
+		ReverseVideo: r.FormValue("reverseVideo") == "true",
+		RemoveSound:  r.FormValue("removeSound") == "true",
+		JobType:      r.FormValue("jobType"),
+		Priority:     r.FormValue("priority"),
+	}
+
+	stabilization, err := parseStabilizationForm(r)
+	if err != nil {
+		return models.DriveConversionRequest{}, err
+	}
+	request.Stabilization = stabilization
+
+	if filtersJSON := r.FormValue("filters"); filtersJSON != "" {
+		request.Filters = &models.FilterOptions{}
+		if err := json.Unmarshal([]byte(filtersJSON), request.Filters); err != nil {
+			return models.DriveConversionRequest{}, fmt.Errorf("Invalid filters value: %v", err)
+		}
+	}
+	if scenesJSON := r.FormValue("scenes"); scenesJSON != "" {
+		request.Scenes = &models.SceneOptions{}
+		if err := json.Unmarshal([]byte(scenesJSON), request.Scenes); err != nil {
+			return models.DriveConversionRequest{}, fmt.Errorf("Invalid scenes value: %v", err)
+		}
+	}
+	if silenceJSON := r.FormValue("silence"); silenceJSON != "" {
+		request.Silence = &models.SilenceOptions{}
+		if err := json.Unmarshal([]byte(silenceJSON), request.Silence); err != nil {
+			return models.DriveConversionRequest{}, fmt.Errorf("Invalid silence value: %v", err)
+		}
+	}
+	if folderID := r.FormValue("destinationFolderId"); folderID != "" {
+		request.Destination = &models.DriveDestination{FolderID: folderID}
+	}
+	if metricsMode := r.FormValue("metrics"); metricsMode != "" {
+		request.Metrics = &models.MetricsOptions{Mode: metricsMode}
+	}
+	if targetVMAFStr := r.FormValue("targetVmaf"); targetVMAFStr != "" {
+		request.TargetVMAF, err = strconv.ParseFloat(targetVMAFStr, 64)
+		if err != nil {
+			return models.DriveConversionRequest{}, fmt.Errorf("Invalid targetVmaf value")
+		}
+	}
+	return request, nil
+}
+
+// parseStabilizationForm reads the optional stabilization fields of a multipart upload form.
+// It returns nil when stabilization was not requested.
+func parseStabilizationForm(r *http.Request) (*models.StabilizationOptions, error) {
+	if r.FormValue("stabilize") != "true" {
+		return nil, nil
+	}
+
+	opts := &models.StabilizationOptions{}
+	if value := r.FormValue("stabilizeSmoothing"); value != "" {
+		smoothing, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stabilizeSmoothing value '%s'", value)
+		}
+		opts.Smoothing = smoothing
+	}
+	if value := r.FormValue("stabilizeZoom"); value != "" {
+		zoom, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stabilizeZoom value '%s'", value)
+		}
+		opts.Zoom = zoom
+	}
+	if err := conversion.ValidateStabilization(opts); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
 
-		h.sendErrorResponse(w, "Server busy, conversion queue is full", http.StatusServiceUnavailable)
+// ThumbnailHandler serves a scene thumbnail generated by a scene detection job.
+func (h *Handler) ThumbnailHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	response := models.ConversionResponse{
-		Success:      true,
-		Message:      "Upload successful, conversion job queued",
-		ConversionID: conversionID,
+	// Expected form: {conversionID}/{thumbnail file}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, RouteConversionThumbnail), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" ||
+		strings.Contains(r.URL.Path, "..") || filepath.Ext(parts[1]) != ".jpg" {
+		http.Error(w, "invalid thumbnail path", http.StatusBadRequest)
+		return
 	}
-	h.sendJSONResponse(w, response, http.StatusAccepted)
+
+	thumbnailsDir := filepath.Join(h.Config.DataDir, constants.ThumbnailsSubdir)
+	thumbPath, err := resolveAndValidateSubPath(thumbnailsDir, filepath.Join(parts[0], parts[1]))
+	if err != nil {
+		http.Error(w, "invalid thumbnail path", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.safeAccessFile(thumbnailsDir, thumbPath, fmt.Sprintf("thumbnail %s", parts[0])); err != nil {
+		if errors.Is(err, errFileNotFound) {
+			http.Error(w, "Thumbnail not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Invalid thumbnail request", http.StatusBadRequest)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	http.ServeFile(w, r, thumbPath)
 }
 
 // StatusHandler returns the status of a conversion job.
@@ -642,7 +1042,7 @@ func (h *Handler) StatusHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := buildStatusResponse(id, status)
+	response := h.Store.BuildStatusResponse(id, status)
 
 	h.sendJSONResponse(w, response, http.StatusOK)
 }
@@ -651,7 +1051,7 @@ func (h *Handler) StatusHandler(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) DownloadHandler(w http.ResponseWriter, r *http.Request) {
 	filePath, filename, err := h.resolveAndValidateConvertedFilePath(r, RouteDownload)
 	if err != nil {
-		if err.Error() == "internal server configuration error" {
+		if errors.Is(err, errServerConfig) {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		} else {
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -662,11 +1062,11 @@ func (h *Handler) DownloadHandler(w http.ResponseWriter, r *http.Request) {
 	fileInfo, err := h.safeAccessFile(h.Config.ConvertedDir, filePath, fmt.Sprintf("download %s", filename))
 	if err != nil {
 		// Handle different error types with appropriate status codes
-		switch err.Error() {
-		case "file not found":
+		switch {
+		case errors.Is(err, errFileNotFound):
 			log.Printf("WARN: Requested download file not found: %s", filePath)
 			http.Error(w, "File not found", http.StatusNotFound)
-		case "invalid file path", "invalid file type":
+		case errors.Is(err, errInvalidFilePath), errors.Is(err, errInvalidFileType):
 			log.Printf("WARN: Invalid file requested for download: %s", filePath)
 			http.Error(w, "Invalid file request", http.StatusBadRequest)
 		default:
@@ -727,12 +1127,19 @@ func (h *Handler) ListFilesHandler(w http.ResponseWriter, r *http.Request) {
 				log.Printf("WARN: Could not get info for file %s: %v", entry.Name(), err)
 				continue
 			}
-			fileInfos = append(fileInfos, models.FileInfo{
+			fileInfo := models.FileInfo{
 				Name:    entry.Name(),
 				Size:    info.Size(),
 				ModTime: info.ModTime(),
 				URL:     fmt.Sprintf("%s%s", RouteDownload, entry.Name()),
-			})
+			}
+			metrics, err := conversion.ReadMetricsSidecar(conversion.MetricsSidecarPath(h.metricsDir(), entry.Name()))
+			if err == nil {
+				fileInfo.Metrics = metrics
+			} else if !os.IsNotExist(err) {
+				log.Printf("WARN: Could not read quality metrics for file %s: %v", entry.Name(), err)
+			}
+			fileInfos = append(fileInfos, fileInfo)
 		}
 	}
 
@@ -752,7 +1159,7 @@ func (h *Handler) DeleteFileHandler(w http.ResponseWriter, r *http.Request) {
 
 	filePath, filename, err := h.resolveAndValidateConvertedFilePath(r, RouteDeleteFile)
 	if err != nil {
-		if err.Error() == "internal server configuration error" {
+		if errors.Is(err, errServerConfig) {
 			h.sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
 			return
 		} else {
@@ -767,6 +1174,9 @@ func (h *Handler) DeleteFileHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Drop the quality metrics recorded for the file, if any
+	h.safeRemoveFile(h.metricsDir(), conversion.MetricsSidecarPath(h.metricsDir(), filename), fmt.Sprintf("delete metrics %s", filename))
+
 	log.Printf("Deleted file: %s", filePath)
 	h.sendJSONResponse(w, map[string]interface{}{"success": true, "message": fmt.Sprintf("File '%s' deleted successfully", filename)}, http.StatusOK)
 }
@@ -795,42 +1205,21 @@ func (h *Handler) AbortConversionHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	cmd, active := h.Store.GetActiveCmd(id)
-	if !active {
-		status, exists = h.Store.GetStatus(id)
-		if exists && status.Complete {
+	log.Printf("INFO [job %s]: Attempting to abort conversion in phase %s", id, status.Phase)
+	if err := h.Converter.CancelJob(id); err != nil {
+		switch {
+		case errors.Is(err, conversion.ErrJobNotFound):
+			h.sendErrorResponse(w, "Conversion not found", http.StatusNotFound)
+		case errors.Is(err, conversion.ErrJobComplete):
 			log.Printf("WARN [job %s]: Abort requested but conversion completed before processing", id)
 			h.sendErrorResponse(w, "Conversion completed before abort request processed", http.StatusConflict)
-		} else {
-			log.Printf("WARN [job %s]: Abort requested but active conversion process not found", id)
-			h.sendErrorResponse(w, "Active conversion process not found (may have already finished)", http.StatusNotFound)
-		}
-		return
-	}
-
-	log.Printf("INFO [job %s]: Attempting to abort conversion process", id)
-	var abortErr error
-	if runtime.GOOS == "windows" {
-		abortErr = cmd.Process.Kill()
-	} else {
-		abortErr = cmd.Process.Signal(syscall.SIGTERM)
-		if abortErr != nil && !errors.Is(abortErr, os.ErrProcessDone) {
-			log.Printf("WARN [job %s]: SIGTERM failed, trying SIGKILL: %v", id, abortErr)
-			abortErr = cmd.Process.Signal(syscall.SIGKILL)
-		} else if abortErr == nil {
-			log.Printf("INFO [job %s]: Sent SIGTERM to process", id)
+		default:
+			errMsg := fmt.Sprintf("Failed to stop FFmpeg process: %v", err)
+			log.Printf("ERROR [job %s]: %s", id, errMsg)
+			h.sendErrorResponse(w, errMsg, http.StatusInternalServerError)
 		}
-	}
-
-	if abortErr != nil && !errors.Is(abortErr, os.ErrProcessDone) {
-		errMsg := fmt.Sprintf("Failed to stop FFmpeg process: %v", abortErr)
-		log.Printf("ERROR [job %s]: %s", id, errMsg)
-		h.Store.UpdateStatusWithError(id, "Abort requested, but process termination failed: "+abortErr.Error())
-		h.sendErrorResponse(w, errMsg, http.StatusInternalServerError)
 		return
 	}
-
-	h.Store.UpdateStatusWithError(id, "Conversion aborted by user")
 	log.Printf("INFO [job %s]: Conversion abort request processed successfully", id)
 
 	response := models.ConversionResponse{
@@ -888,7 +1277,7 @@ func (h *Handler) ActiveConversionsStreamHandler(w http.ResponseWriter, r *http.
 			continue
 		}
 
-		response := buildStatusResponse(id, status)
+		response := h.Store.BuildStatusResponse(id, status)
 		event := conversion.StoreEvent{
 			Type:         "status",
 			ConversionID: id,
@@ -899,6 +1288,13 @@ func (h *Handler) ActiveConversionsStreamHandler(w http.ResponseWriter, r *http.
 			return
 		}
 	}
+	for _, batch := range h.Store.ActiveBatches() {
+		event := conversion.StoreEvent{Type: "batch", BatchID: batch.ID, Batch: &batch}
+		if err := writeSSEEvent(w, flusher, event.Type, event); err != nil {
+			log.Printf("WARN: Failed to send initial SSE status for batch %s: %v", batch.ID, err)
+			return
+		}
+	}
 
 	heartbeat := time.NewTicker(constants.SSEHeartbeatInterval)
 	defer heartbeat.Stop()
@@ -912,7 +1308,7 @@ func (h *Handler) ActiveConversionsStreamHandler(w http.ResponseWriter, r *http.
 				return
 			}
 			if err := writeSSEEvent(w, flusher, event.Type, event); err != nil {
-				log.Printf("WARN: SSE send error for conversion %s: %v", event.ConversionID, err)
+				log.Printf("WARN: SSE send error for %s event: %v", event.Type, err)
 				return
 			}
 		case <-heartbeat.C:
@@ -951,24 +1347,6 @@ func writeSSEHeartbeat(w http.ResponseWriter, flusher http.Flusher) error {
 	return nil
 }
 
-func buildStatusResponse(id string, status models.ConversionStatus) models.ConversionStatusResponse {
-	response := models.ConversionStatusResponse{
-		ID:       id,
-		FileName: filepath.Base(status.OutputPath),
-		Progress: status.Progress,
-		Complete: status.Complete,
-		Error:    status.Error,
-		Format:   status.Format,
-		Quality:  status.Quality,
-	}
-
-	if status.Complete && status.Error == "" && status.OutputPath != "" {
-		response.DownloadURL = fmt.Sprintf("%s%s", RouteDownload, filepath.Base(status.OutputPath))
-	}
-
-	return response
-}
-
 // ConfigHandler returns relevant configuration values to the client.
 func (h *Handler) ConfigHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -994,13 +1372,60 @@ func (h *Handler) sendJSONResponse(w http.ResponseWriter, data interface{}, stat
 	}
 }
 
-// sendErrorResponse sends a standardized JSON error response.
+// sendErrorResponse sends a standardized JSON error response whose error code matches the HTTP status.
 func (h *Handler) sendErrorResponse(w http.ResponseWriter, errMsg string, statusCode int) {
+	h.sendCodedErrorResponse(w, errorCodeForStatus(statusCode), errMsg, statusCode)
+}
+
+// sendCodedErrorResponse sends an error response carrying a specific error code.
+func (h *Handler) sendCodedErrorResponse(w http.ResponseWriter, code, errMsg string, statusCode int) {
 	response := models.ConversionResponse{
-		Success: false,
-		Error:   errMsg,
+		Success:   false,
+		Error:     errMsg,
+		ErrorCode: code,
 	}
 	// Log the error being sent to the client
-	log.Printf("WARN: Sending error response (status %d): %s", statusCode, errMsg)
+	log.Printf("WARN: Sending error response (status %d, %s): %s", statusCode, code, errMsg)
 	h.sendJSONResponse(w, response, statusCode)
 }
+
+// errorCodeForStatus returns the generic error code for an HTTP error status.
+func errorCodeForStatus(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return models.ErrorCodeInvalidRequest
+	case http.StatusNotFound:
+		return models.ErrorCodeNotFound
+	case http.StatusMethodNotAllowed:
+		return models.ErrorCodeMethodNotAllowed
+	case http.StatusConflict:
+		return models.ErrorCodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return models.ErrorCodeFileTooLarge
+	case http.StatusServiceUnavailable:
+		return models.ErrorCodeServiceUnavailable
+	case http.StatusInsufficientStorage:
+		return models.ErrorCodeDiskFull
+	default:
+		return models.ErrorCodeInternal
+	}
+}
+
+// driveErrorStatus returns the HTTP status answering a failed Google Drive request,
+// given its error code from conversion.DriveErrorCode.
+func driveErrorStatus(code string) int {
+	switch code {
+	case models.ErrorCodeDriveNotFound:
+		return http.StatusNotFound
+	case models.ErrorCodeDriveQuota:
+		return http.StatusTooManyRequests
+	case models.ErrorCodeDriveAuth:
+		return http.StatusBadGateway
+	case models.ErrorCodeFileTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case models.ErrorCodeDiskFull:
+		return http.StatusInsufficientStorage
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/filestore"
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconvertLibraryFileHandler(t *testing.T) {
+	t.Run("queues a job with a link to the library file", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		libraryPath := filepath.Join(env.convertedDir, "clip-abc.mov")
+		require.NoError(t, os.WriteFile(libraryPath, []byte("hevc video"), 0o644))
+
+		body := strings.NewReader(`{"targetFormat":"mp4","quality":"high"}`)
+		res := httptest.NewRecorder()
+		env.handler.ReconvertLibraryFileHandler(res, httptest.NewRequest(http.MethodPost, RouteConvertLibrary+"clip-abc.mov", body))
+
+		newID := decodeQueuedID(t, res)
+		status, ok := env.store.GetStatus(newID)
+		require.True(t, ok)
+		assert.Equal(t, models.PhaseQueued, status.Phase)
+		assert.Equal(t, "mp4", status.Format)
+
+		job, ok := env.store.GetJob(newID)
+		require.True(t, ok)
+		assert.Equal(t, "clip-abc.mov", job.FileName)
+		assert.Equal(t, "high", job.Quality)
+		assert.Equal(t, env.uploadsDir, filepath.Dir(job.UploadedFilePath))
+		assert.NotEqual(t, libraryPath, job.OutputFilePath)
+
+		data, err := os.ReadFile(job.UploadedFilePath)
+		require.NoError(t, err)
+		assert.Equal(t, "hevc video", string(data))
+		assert.FileExists(t, libraryPath, "the library file is kept")
+	})
+
+	t.Run("file cleanup keeps the source of the queued job", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		libraryPath := filepath.Join(env.convertedDir, "clip-abc.mov")
+		require.NoError(t, os.WriteFile(libraryPath, []byte("hevc video"), 0o644))
+		backdated := time.Now().Add(-2 * constants.FileMaxAge)
+		require.NoError(t, os.Chtimes(libraryPath, backdated, backdated))
+
+		res := httptest.NewRecorder()
+		env.handler.ReconvertLibraryFileHandler(res, httptest.NewRequest(http.MethodPost, RouteConvertLibrary+"clip-abc.mov", strings.NewReader(`{"targetFormat":"mp4"}`)))
+		job, ok := env.store.GetJob(decodeQueuedID(t, res))
+		require.True(t, ok)
+
+		filestore.CleanupOldFilesExcept(env.uploadsDir, constants.FileMaxAge, env.store.GetUnfinishedInputFilenames())
+		assert.FileExists(t, job.UploadedFilePath)
+	})
+
+	t.Run("rejects outputs of running conversions", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		libraryPath := filepath.Join(env.convertedDir, "clip-abc.mov")
+		require.NoError(t, os.WriteFile(libraryPath, []byte("partial"), 0o644))
+		env.store.SetStatus("running", &models.ConversionStatus{OutputPath: libraryPath, Format: "mov"})
+		env.store.RegisterActiveCmd("running", &exec.Cmd{})
+
+		res := httptest.NewRecorder()
+		env.handler.ReconvertLibraryFileHandler(res, httptest.NewRequest(http.MethodPost, RouteConvertLibrary+"clip-abc.mov", strings.NewReader(`{"targetFormat":"mp4"}`)))
+
+		assert.Equal(t, http.StatusConflict, res.Code)
+		entries, err := os.ReadDir(env.uploadsDir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{"method not allowed", http.MethodGet, RouteConvertLibrary + "clip.mov", "", http.StatusMethodNotAllowed},
+		{"missing filename", http.MethodPost, RouteConvertLibrary, `{"targetFormat":"mp4"}`, http.StatusBadRequest},
+		{"directory traversal", http.MethodPost, RouteConvertLibrary + "..%2Fsecret.mov", `{"targetFormat":"mp4"}`, http.StatusBadRequest},
+		{"file not in library", http.MethodPost, RouteConvertLibrary + "missing.mov", `{"targetFormat":"mp4"}`, http.StatusNotFound},
+		{"invalid format", http.MethodPost, RouteConvertLibrary + "clip.mov", `{"targetFormat":"avi"}`, http.StatusBadRequest},
+		{"invalid body", http.MethodPost, RouteConvertLibrary + "clip.mov", `{`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newHandlerTestEnv(t)
+			require.NoError(t, os.WriteFile(filepath.Join(env.convertedDir, "clip.mov"), []byte("video"), 0o644))
+
+			res := httptest.NewRecorder()
+			env.handler.ReconvertLibraryFileHandler(res, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantStatus, res.Code, res.Body.String())
+			entries, err := os.ReadDir(env.uploadsDir)
+			require.NoError(t, err)
+			assert.Empty(t, entries, "nothing is copied for rejected requests")
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/filestore"
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/google/uuid"
+)
+
+// ReconvertLibraryFileHandler queues a conversion of a file from the converted library
+// with a new set of options, so it does not have to be uploaded again. The JSON body is a
+// conversion request without a file ID; the file name defaults to the library file's name.
+// The library file is linked, or copied across file systems, into the uploads directory, so
+// the job owns its source like any other job and the library file is left untouched.
+// Outputs of running conversions are rejected until they are complete.
+func (h *Handler) ReconvertLibraryFileHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sourcePath, filename, err := h.resolveAndValidateConvertedFilePath(r, RouteConvertLibrary)
+	if err != nil {
+		if errors.Is(err, errServerConfig) {
+			h.sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		} else {
+			h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+	if _, err := h.validateFileSafety(h.Config.ConvertedDir, sourcePath, fmt.Sprintf("re-convert %s", filename)); err != nil {
+		switch {
+		case errors.Is(err, errFileNotFound):
+			h.sendErrorResponse(w, "File not found", http.StatusNotFound)
+		case errors.Is(err, errInvalidFilePath), errors.Is(err, errInvalidFileType):
+			h.sendErrorResponse(w, "Invalid file request", http.StatusBadRequest)
+		default:
+			h.sendErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if _, active := h.Store.GetActiveOutputFilenames()[filename]; active {
+		h.sendErrorResponse(w, "File is still being converted", http.StatusConflict)
+		return
+	}
+
+	var request models.DriveConversionRequest
+	r.Body = http.MaxBytesReader(w, r.Body, constants.MaxJSONRequestSize)
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.sendErrorResponse(w, fmt.Sprintf("Failed to parse request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if !validFormats[request.TargetFormat] {
+		h.sendErrorResponse(w, "Invalid target format specified", http.StatusBadRequest)
+		return
+	}
+	request.FileID = ""
+	if request.FileName == "" {
+		request.FileName = filename
+	}
+
+	conversionID := uuid.NewString()
+	uploadedFilePath, outputFilePath, err := h.resolveAndValidatePaths(filestore.SanitizeFilename(request.FileName), request.TargetFormat, conversionID)
+	if err != nil {
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.newConversionJob(conversionID, request, uploadedFilePath, outputFilePath)
+	if err != nil {
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	job.ClientID = h.clientIdentity(r)
+
+	if err := filestore.LinkOrCopyFile(sourcePath, uploadedFilePath); err != nil {
+		if os.IsNotExist(err) {
+			h.sendErrorResponse(w, "File not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("ERROR [job %s]: Failed to link library file %s: %v", conversionID, filename, err)
+		h.sendErrorResponse(w, "Failed to prepare the source file", http.StatusInternalServerError)
+		return
+	}
+	h.Store.SetStatus(conversionID, job.Status)
+
+	if err := h.Converter.QueueJob(job); err != nil {
+		log.Printf("ERROR [job %s]: Failed to queue re-conversion of %s: %v", conversionID, filename, err)
+		h.Store.DeleteStatus(conversionID)
+		if removeErr := os.Remove(uploadedFilePath); removeErr != nil && !os.IsNotExist(removeErr) {
+			log.Printf("WARN [job %s]: Failed to remove linked source %s: %v", conversionID, uploadedFilePath, removeErr)
+		}
+		h.sendCodedErrorResponse(w, models.ErrorCodeQueueFull, "Server busy, conversion queue is full", http.StatusServiceUnavailable)
+		return
+	}
+	log.Printf("Job %s queued to re-convert library file %s", conversionID, filename)
+
+	response := models.ConversionResponse{
+		Success:      true,
+		Message:      "Conversion job queued successfully",
+		ConversionID: conversionID,
+	}
+	h.sendJSONResponse(w, response, http.StatusAccepted)
+}
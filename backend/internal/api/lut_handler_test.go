@@ -0,0 +1,82 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLUTUploadRequest(t *testing.T, fileName string, content []byte) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	fileWriter, err := writer.CreateFormFile("lutFile", fileName)
+	require.NoError(t, err)
+	_, err = fileWriter.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, RouteLUTs, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestLUTsHandler(t *testing.T) {
+	t.Run("upload then list", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		res := httptest.NewRecorder()
+		env.handler.LUTsHandler(res, newLUTUploadRequest(t, "S-Log3 Rec709.cube", []byte("LUT_3D_SIZE 2\n")))
+		assert.Equal(t, http.StatusCreated, res.Code)
+
+		req := httptest.NewRequest(http.MethodGet, RouteLUTs, nil)
+		res = httptest.NewRecorder()
+		env.handler.LUTsHandler(res, req)
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		var luts []models.LUTInfo
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&luts))
+		require.Len(t, luts, 1)
+		assert.Equal(t, "S-Log3_Rec709.cube", luts[0].Name)
+	})
+
+	t.Run("rejects non-cube upload", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		res := httptest.NewRecorder()
+		env.handler.LUTsHandler(res, newLUTUploadRequest(t, "look.png", []byte("png")))
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("conversion with unknown LUT is rejected", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		payload := []byte(`{"fileName":"a.mov","targetFormat":"mp4","filters":{"lut":"missing.cube"}}`)
+		req := httptest.NewRequest(http.MethodPost, RouteConvertDryRun, bytes.NewReader(payload))
+		res := httptest.NewRecorder()
+
+		env.handler.DryRunHandler(res, req)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+		var response models.ConversionResponse
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+		assert.Contains(t, response.Error, "not found")
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		req := httptest.NewRequest(http.MethodDelete, RouteLUTs, nil)
+		res := httptest.NewRecorder()
+		env.handler.LUTsHandler(res, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	})
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/conversion"
+	"github.com/gatanasi/video-converter/internal/filestore"
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+// lutsDir returns the directory holding the LUT library.
+func (h *Handler) lutsDir() string {
+	return filepath.Join(h.Config.DataDir, constants.LUTsSubdir)
+}
+
+// resolveLUTPath validates a LUT name and returns the absolute path of the matching library file.
+func (h *Handler) resolveLUTPath(name string) (string, error) {
+	if !conversion.IsLUTFileName(name) {
+		return "", fmt.Errorf("invalid LUT name '%s'", name)
+	}
+
+	lutPath, err := resolveAndValidateSubPath(h.lutsDir(), name)
+	if err != nil {
+		return "", fmt.Errorf("invalid LUT name '%s'", name)
+	}
+
+	if _, err := h.validateFileSafety(h.lutsDir(), lutPath, fmt.Sprintf("lut %s", name)); err != nil {
+		if errors.Is(err, errFileNotFound) {
+			return "", fmt.Errorf("LUT '%s' not found", name)
+		}
+		return "", fmt.Errorf("LUT '%s' is not accessible", name)
+	}
+	return lutPath, nil
+}
+
+// LUTsHandler lists the LUT library (GET) or adds an uploaded .cube file to it (POST).
+func (h *Handler) LUTsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listLUTs(w)
+	case http.MethodPost:
+		h.uploadLUT(w, r)
+	default:
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) listLUTs(w http.ResponseWriter) {
+	entries, err := os.ReadDir(h.lutsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			h.sendJSONResponse(w, []models.LUTInfo{}, http.StatusOK)
+			return
+		}
+		errMsg := fmt.Sprintf("Failed to list LUTs: %v", err)
+		log.Printf("ERROR: %s", errMsg)
+		h.sendErrorResponse(w, errMsg, http.StatusInternalServerError)
+		return
+	}
+
+	luts := make([]models.LUTInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !conversion.IsLUTFileName(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			log.Printf("WARN: Could not get info for LUT %s: %v", entry.Name(), err)
+			continue
+		}
+		luts = append(luts, models.LUTInfo{
+			Name:    entry.Name(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+
+	sort.Slice(luts, func(i, j int) bool {
+		return strings.ToLower(luts[i].Name) < strings.ToLower(luts[j].Name)
+	})
+
+	h.sendJSONResponse(w, luts, http.StatusOK)
+}
+
+func (h *Handler) uploadLUT(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, constants.MaxLUTFileSize+constants.UploadSizeBuffer)
+	if err := r.ParseMultipartForm(constants.MultipartMemoryLimit); err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			h.sendErrorResponse(w, fmt.Sprintf("Upload failed: LUT exceeds maximum allowed size (%d MB)", constants.MaxLUTFileSize/(1024*1024)), http.StatusRequestEntityTooLarge)
+		} else {
+			h.sendErrorResponse(w, fmt.Sprintf("Failed to parse multipart form: %v", err), http.StatusBadRequest)
+		}
+		return
+	}
+	defer func() {
+		if err := r.MultipartForm.RemoveAll(); err != nil {
+			log.Printf("WARN: Error removing multipart temp files: %v", err)
+		}
+	}()
+
+	file, header, err := r.FormFile("lutFile")
+	if err != nil {
+		if errors.Is(err, http.ErrMissingFile) {
+			h.sendErrorResponse(w, "Missing 'lutFile' part in form data", http.StatusBadRequest)
+		} else {
+			h.sendErrorResponse(w, fmt.Sprintf("Failed to get file from form: %v", err), http.StatusBadRequest)
+		}
+		return
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			log.Printf("WARN: Error closing uploaded LUT handle: %v", closeErr)
+		}
+	}()
+
+	name := filestore.SanitizeFilename(header.Filename)
+	if !conversion.IsLUTFileName(name) {
+		h.sendErrorResponse(w, "LUT must be a .cube file", http.StatusBadRequest)
+		return
+	}
+
+	lutPath, err := resolveAndValidateSubPath(h.lutsDir(), name)
+	if err != nil {
+		h.sendErrorResponse(w, "Invalid LUT file name", http.StatusBadRequest)
+		return
+	}
+	if err := filestore.EnsureDirectoryExists(h.lutsDir()); err != nil {
+		log.Printf("ERROR: %v", err)
+		h.sendErrorResponse(w, "Failed to prepare LUT directory", http.StatusInternalServerError)
+		return
+	}
+
+	// Write to a temporary file first so a failed upload never replaces an existing LUT.
+	tmpFile, err := os.CreateTemp(h.lutsDir(), ".upload-*")
+	if err != nil {
+		log.Printf("ERROR: Failed to create temp file for LUT upload: %v", err)
+		h.sendErrorResponse(w, "Failed to save LUT", http.StatusInternalServerError)
+		return
+	}
+	tmpPath := tmpFile.Name()
+	limitedReader := &io.LimitedReader{R: file, N: constants.MaxLUTFileSize + 1}
+	written, copyErr := io.Copy(tmpFile, limitedReader)
+	closeErr := tmpFile.Close()
+	if copyErr != nil || closeErr != nil || limitedReader.N <= 0 {
+		if removeErr := os.Remove(tmpPath); removeErr != nil && !os.IsNotExist(removeErr) {
+			log.Printf("WARN: Failed to remove temp LUT file %s: %v", tmpPath, removeErr)
+		}
+		if limitedReader.N <= 0 {
+			h.sendErrorResponse(w, fmt.Sprintf("Upload failed: LUT exceeds maximum allowed size (%d MB)", constants.MaxLUTFileSize/(1024*1024)), http.StatusRequestEntityTooLarge)
+			return
+		}
+		log.Printf("ERROR: Failed to save LUT %s: copy=%v close=%v", name, copyErr, closeErr)
+		h.sendErrorResponse(w, "Failed to save LUT", http.StatusInternalServerError)
+		return
+	}
+
+	if err := os.Rename(tmpPath, lutPath); err != nil {
+		log.Printf("ERROR: Failed to move LUT %s into place: %v", name, err)
+		if removeErr := os.Remove(tmpPath); removeErr != nil && !os.IsNotExist(removeErr) {
+			log.Printf("WARN: Failed to remove temp LUT file %s: %v", tmpPath, removeErr)
+		}
+		h.sendErrorResponse(w, "Failed to save LUT", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Saved LUT %s (%d bytes)", name, written)
+	h.sendJSONResponse(w, models.ConversionResponse{
+		Success: true,
+		Message: fmt.Sprintf("LUT '%s' uploaded successfully", name),
+	}, http.StatusCreated)
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPauseConversionHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		handler    func(*Handler) http.HandlerFunc
+		wantStatus int
+	}{
+		{
+			name:       "method not allowed",
+			method:     http.MethodGet,
+			path:       RouteConversionPause + "job",
+			handler:    func(h *Handler) http.HandlerFunc { return h.PauseConversionHandler },
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "conversion not found",
+			method:     http.MethodPost,
+			path:       RouteConversionPause + "missing",
+			handler:    func(h *Handler) http.HandlerFunc { return h.PauseConversionHandler },
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "queued job cannot be paused",
+			method:     http.MethodPost,
+			path:       RouteConversionPause + "queued",
+			handler:    func(h *Handler) http.HandlerFunc { return h.PauseConversionHandler },
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "resume a job that is not paused",
+			method:     http.MethodPost,
+			path:       RouteConversionResume + "queued",
+			handler:    func(h *Handler) http.HandlerFunc { return h.ResumeConversionHandler },
+			wantStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newHandlerTestEnv(t)
+			env.store.SetStatus("queued", &models.ConversionStatus{Phase: models.PhaseQueued})
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			res := httptest.NewRecorder()
+
+			tt.handler(env.handler)(res, req)
+
+			assert.Equal(t, tt.wantStatus, res.Code)
+		})
+	}
+}
+
+func TestPauseAllHandler(t *testing.T) {
+	env := newHandlerTestEnv(t)
+
+	res := httptest.NewRecorder()
+	env.handler.PauseAllHandler(res, httptest.NewRequest(http.MethodPost, RouteConversionsPauseAll, nil))
+	require.Equal(t, http.StatusOK, res.Code)
+
+	var response models.PauseAllResponse
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+	assert.True(t, response.Success)
+	assert.True(t, response.Paused)
+	assert.Empty(t, response.ConversionIDs)
+
+	res = httptest.NewRecorder()
+	env.handler.ResumeAllHandler(res, httptest.NewRequest(http.MethodPost, RouteConversionsResumeAll, nil))
+	require.Equal(t, http.StatusOK, res.Code)
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+	assert.False(t, response.Paused)
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gatanasi/video-converter/internal/conversion"
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+// PauseConversionHandler suspends the running FFmpeg process of a conversion.
+func (h *Handler) PauseConversionHandler(w http.ResponseWriter, r *http.Request) {
+	h.handlePauseRequest(w, r, RouteConversionPause, h.Converter.PauseJob, "Conversion paused")
+}
+
+// ResumeConversionHandler continues a paused conversion.
+func (h *Handler) ResumeConversionHandler(w http.ResponseWriter, r *http.Request) {
+	h.handlePauseRequest(w, r, RouteConversionResume, h.Converter.ResumeJob, "Conversion resumed")
+}
+
+// handlePauseRequest applies a pause or resume action to the conversion named in the path.
+func (h *Handler) handlePauseRequest(w http.ResponseWriter, r *http.Request, route string, action func(string) error, message string) {
+	if r.Method != http.MethodPost {
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, route)
+	if id == "" {
+		h.sendErrorResponse(w, "Missing conversion ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := action(id); err != nil {
+		h.sendPauseError(w, err)
+		return
+	}
+	h.sendJSONResponse(w, models.ConversionResponse{Success: true, Message: message, ConversionID: id}, http.StatusOK)
+}
+
+// PauseAllHandler pauses every running conversion and holds queued jobs until resumed.
+func (h *Handler) PauseAllHandler(w http.ResponseWriter, r *http.Request) {
+	h.handlePauseAllRequest(w, r, h.Converter.PauseAll)
+}
+
+// ResumeAllHandler resumes every paused conversion and releases the queue.
+func (h *Handler) ResumeAllHandler(w http.ResponseWriter, r *http.Request) {
+	h.handlePauseAllRequest(w, r, h.Converter.ResumeAll)
+}
+
+// handlePauseAllRequest applies a pause-all or resume-all action.
+func (h *Handler) handlePauseAllRequest(w http.ResponseWriter, r *http.Request, action func() ([]string, error)) {
+	if r.Method != http.MethodPost {
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ids, err := action()
+	if err != nil {
+		h.sendPauseError(w, err)
+		return
+	}
+	response := models.PauseAllResponse{
+		Success:       true,
+		Paused:        h.Converter.AllPaused(),
+		ConversionIDs: ids,
+	}
+	h.sendJSONResponse(w, response, http.StatusOK)
+}
+
+// sendPauseError maps a pause or resume failure onto an error response.
+func (h *Handler) sendPauseError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, conversion.ErrJobNotFound):
+		h.sendErrorResponse(w, "Conversion not found", http.StatusNotFound)
+	case errors.Is(err, conversion.ErrJobComplete),
+		errors.Is(err, conversion.ErrJobNotRunning),
+		errors.Is(err, conversion.ErrJobNotPaused):
+		h.sendErrorResponse(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, conversion.ErrPauseUnsupported):
+		h.sendErrorResponse(w, err.Error(), http.StatusNotImplemented)
+	default:
+		h.sendErrorResponse(w, "Failed to pause or resume conversion: "+err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPreviewRequest(t *testing.T, fields map[string]string) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	fileWriter, err := writer.CreateFormFile("videoFile", "talk.mov")
+	require.NoError(t, err)
+	_, err = fileWriter.Write([]byte("fake video content"))
+	require.NoError(t, err)
+	for name, value := range fields {
+		require.NoError(t, writer.WriteField(name, value))
+	}
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, RouteConvertPreview, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestPreviewHandler(t *testing.T) {
+	t.Run("method not allowed", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		req := httptest.NewRequest(http.MethodGet, RouteConvertPreview, nil)
+		res := httptest.NewRecorder()
+
+		env.handler.PreviewHandler(res, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	})
+
+	t.Run("invalid offset", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		req := newPreviewRequest(t, map[string]string{"targetFormat": "mp4", "previewOffset": "soon"})
+		res := httptest.NewRecorder()
+
+		env.handler.PreviewHandler(res, req)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("non-finite offset", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		req := newPreviewRequest(t, map[string]string{"targetFormat": "mp4", "previewOffset": "NaN"})
+		res := httptest.NewRecorder()
+
+		env.handler.PreviewHandler(res, req)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("stabilization cannot be previewed", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		req := newPreviewRequest(t, map[string]string{"targetFormat": "mp4", "stabilize": "true"})
+		res := httptest.NewRecorder()
+
+		env.handler.PreviewHandler(res, req)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+
+		var payload models.ConversionResponse
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&payload))
+		assert.Contains(t, payload.Error, "stabilization")
+
+		// The uploaded source is removed once the preview request is done.
+		entries, err := os.ReadDir(env.uploadsDir)
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
+
+func TestPreviewFileHandler(t *testing.T) {
+	t.Run("serves preview", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		previewsDir := filepath.Join(env.dataDir, constants.PreviewsSubdir)
+		require.NoError(t, os.MkdirAll(previewsDir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(previewsDir, "preview-abc.mp4"), []byte("sample"), 0o644))
+
+		req := httptest.NewRequest(http.MethodGet, RoutePreviewFile+"preview-abc.mp4", nil)
+		res := httptest.NewRecorder()
+
+		env.handler.PreviewFileHandler(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "sample", res.Body.String())
+	})
+
+	t.Run("missing preview", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		req := httptest.NewRequest(http.MethodGet, RoutePreviewFile+"preview-missing.mp4", nil)
+		res := httptest.NewRecorder()
+
+		env.handler.PreviewFileHandler(res, req)
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
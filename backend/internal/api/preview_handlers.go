@@ -0,0 +1,159 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/conversion"
+	"github.com/gatanasi/video-converter/internal/filestore"
+	"github.com/google/uuid"
+)
+
+// previewsDir returns the directory holding preview samples.
+func (h *Handler) previewsDir() string {
+	return filepath.Join(h.Config.DataDir, constants.PreviewsSubdir)
+}
+
+// PreviewHandler encodes a short sample of an uploaded video with the requested conversion
+// options and returns it together with the projected size and encode time of the full job.
+// It accepts the same multipart form as UploadConvertHandler plus optional previewOffset and
+// previewSeconds fields. The sample is encoded right away rather than queued.
+func (h *Handler) PreviewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	file, handler, ok := h.parseMultipartUpload(w, r)
+	if !ok {
+		return
+	}
+	defer func() {
+		if err := r.MultipartForm.RemoveAll(); err != nil {
+			log.Printf("WARN: Error removing multipart temp files: %v", err)
+		}
+	}()
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			log.Printf("WARN: Error closing uploaded file handle: %v", closeErr)
+		}
+	}()
+
+	request, err := parseConversionForm(r)
+	if err != nil {
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	offset, err := parseOptionalFloat(r.FormValue("previewOffset"))
+	if err != nil {
+		h.sendErrorResponse(w, "Invalid previewOffset value", http.StatusBadRequest)
+		return
+	}
+	length, err := parseOptionalFloat(r.FormValue("previewSeconds"))
+	if err != nil {
+		h.sendErrorResponse(w, "Invalid previewSeconds value", http.StatusBadRequest)
+		return
+	}
+
+	previewID := uuid.NewString()
+	request.FileName = filepath.Base(handler.Filename)
+	sanitizedBaseName := filestore.SanitizeFilename(request.FileName)
+	if sanitizedBaseName == "" {
+		sanitizedBaseName = "upload"
+	}
+	uploadedFilePath, err := resolveAndValidateSubPath(h.Config.UploadsDir, fmt.Sprintf("preview-%s-%s", previewID, sanitizedBaseName))
+	if err != nil {
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	previewFilePath, err := resolveAndValidateSubPath(h.previewsDir(), fmt.Sprintf("preview-%s.%s", previewID, request.TargetFormat))
+	if err != nil {
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := filestore.EnsureDirectoryExists(h.previewsDir()); err != nil {
+		log.Printf("ERROR: %v", err)
+		h.sendErrorResponse(w, "Failed to prepare preview directory", http.StatusInternalServerError)
+		return
+	}
+
+	logContext := fmt.Sprintf("preview %s", previewID)
+	if _, ok := h.saveUploadedFile(w, file, uploadedFilePath, logContext); !ok {
+		return
+	}
+	defer h.safeRemoveFile(h.Config.UploadsDir, uploadedFilePath, logContext)
+
+	job, err := h.newConversionJob(previewID, request, uploadedFilePath, previewFilePath)
+	if err != nil {
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The encode can outlast the server's default write timeout.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(constants.PreviewTimeout + time.Minute)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("WARN [%s]: Could not extend write deadline: %v", logContext, err)
+	}
+
+	result, err := h.Converter.Preview(job, offset, length)
+	if err != nil {
+		switch {
+		case errors.Is(err, conversion.ErrPreviewBusy):
+			h.sendErrorResponse(w, err.Error(), http.StatusServiceUnavailable)
+		case errors.Is(err, conversion.ErrInvalidPreview):
+			h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Printf("ERROR [%s]: %v", logContext, err)
+			h.sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	result.PreviewURL = RoutePreviewFile + result.FileName
+	h.sendJSONResponse(w, result, http.StatusOK)
+}
+
+// PreviewFileHandler serves a preview sample produced by PreviewHandler.
+func (h *Handler) PreviewFileHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filename := strings.TrimPrefix(r.URL.Path, RoutePreviewFile)
+	if filename == "" || strings.Contains(filename, "..") || strings.ContainsAny(filename, "/\\") {
+		http.Error(w, "invalid preview path", http.StatusBadRequest)
+		return
+	}
+
+	previewPath, err := resolveAndValidateSubPath(h.previewsDir(), filename)
+	if err != nil {
+		http.Error(w, "invalid preview path", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.safeAccessFile(h.previewsDir(), previewPath, fmt.Sprintf("preview %s", filename)); err != nil {
+		if errors.Is(err, errFileNotFound) {
+			http.Error(w, "Preview not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Invalid preview request", http.StatusBadRequest)
+		}
+		return
+	}
+
+	http.ServeFile(w, r, previewPath)
+}
+
+// parseOptionalFloat parses a form value that may be empty, in which case it returns zero.
+func parseOptionalFloat(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
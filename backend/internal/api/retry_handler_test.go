@@ -0,0 +1,202 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addFinishedJob registers a finished upload job whose source was retained.
+func addFinishedJob(t *testing.T, env *handlerTestEnv, id string, failed bool) string {
+	t.Helper()
+	sourcePath := filepath.Join(env.uploadsDir, id+"-clip.mov")
+	require.NoError(t, os.WriteFile(sourcePath, []byte("input"), 0o644))
+
+	env.store.SetStatus(id, &models.ConversionStatus{
+		InputPath:  sourcePath,
+		OutputPath: filepath.Join(env.convertedDir, "clip-abc.mp4"),
+		Format:     "mp4",
+		Quality:    "default",
+		Phase:      models.PhaseQueued,
+	})
+	env.store.TrackJob(models.ConversionJob{
+		ConversionID:     id,
+		FileName:         "clip.mov",
+		TargetFormat:     "mp4",
+		Quality:          "default",
+		UploadedFilePath: sourcePath,
+		RemoveSound:      true,
+	})
+	if failed {
+		env.store.UpdateStatusWithError(id, models.ErrorCodeConversionFailed, "FFmpeg execution failed")
+	} else {
+		env.store.UpdateStatusOnSuccess(id)
+	}
+	return sourcePath
+}
+
+// decodeQueuedID returns the ID of the job queued by a retry or re-run request.
+func decodeQueuedID(t *testing.T, res *httptest.ResponseRecorder) string {
+	t.Helper()
+	require.Equal(t, http.StatusAccepted, res.Code, res.Body.String())
+	var response models.ConversionResponse
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+	require.NotEmpty(t, response.ConversionID)
+	return response.ConversionID
+}
+
+func TestRetryConversionHandler(t *testing.T) {
+	t.Run("retries a failed job with its retained source", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		sourcePath := addFinishedJob(t, env, "failed-job", true)
+
+		res := httptest.NewRecorder()
+		env.handler.RetryConversionHandler(res, httptest.NewRequest(http.MethodPost, RouteConversionRetry+"failed-job", nil))
+
+		newID := decodeQueuedID(t, res)
+		status, ok := env.store.GetStatus(newID)
+		require.True(t, ok)
+		assert.Equal(t, "failed-job", status.PredecessorID)
+		assert.Equal(t, models.PhaseQueued, status.Phase)
+		assert.Equal(t, 1, status.QueuePosition)
+
+		job, ok := env.store.GetJob(newID)
+		require.True(t, ok)
+		assert.True(t, job.RemoveSound, "options are kept")
+		assert.FileExists(t, job.UploadedFilePath)
+		assert.NoFileExists(t, sourcePath, "the source moves to the new job")
+	})
+
+	t.Run("restarts the age of the retained source", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		sourcePath := addFinishedJob(t, env, "failed-job", true)
+		backdated := time.Now().Add(-constants.FileMaxAge)
+		require.NoError(t, os.Chtimes(sourcePath, backdated, backdated))
+
+		res := httptest.NewRecorder()
+		env.handler.RetryConversionHandler(res, httptest.NewRequest(http.MethodPost, RouteConversionRetry+"failed-job", nil))
+
+		job, ok := env.store.GetJob(decodeQueuedID(t, res))
+		require.True(t, ok)
+		info, err := os.Stat(job.UploadedFilePath)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), info.ModTime(), time.Minute)
+	})
+
+	t.Run("only failed jobs", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		addFinishedJob(t, env, "done-job", false)
+
+		res := httptest.NewRecorder()
+		env.handler.RetryConversionHandler(res, httptest.NewRequest(http.MethodPost, RouteConversionRetry+"done-job", nil))
+
+		assert.Equal(t, http.StatusConflict, res.Code)
+	})
+
+	t.Run("source not retained", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		sourcePath := addFinishedJob(t, env, "failed-job", true)
+		require.NoError(t, os.Remove(sourcePath))
+
+		res := httptest.NewRecorder()
+		env.handler.RetryConversionHandler(res, httptest.NewRequest(http.MethodPost, RouteConversionRetry+"failed-job", nil))
+
+		assert.Equal(t, http.StatusGone, res.Code)
+		var response models.ConversionResponse
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+		assert.Equal(t, models.ErrorCodeSourceUnavailable, response.ErrorCode)
+		assert.Len(t, env.store.GetAllStatuses(), 1, "no job is created")
+	})
+
+	t.Run("downloads the source of a failed Drive job again", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		stubDriveDownloads(env)
+		env.store.SetStatus("drive-job", &models.ConversionStatus{Format: "mp4", Phase: models.PhaseDownloading})
+		env.store.TrackJob(models.ConversionJob{
+			ConversionID:     "drive-job",
+			FileID:           "drive-file",
+			FileName:         "clip.mov",
+			TargetFormat:     "mp4",
+			UploadedFilePath: filepath.Join(env.uploadsDir, "drive-job-clip.mov"),
+		})
+		env.store.UpdateStatusWithError("drive-job", models.ErrorCodeDriveError, "Failed to download file from Google Drive")
+
+		res := httptest.NewRecorder()
+		env.handler.RetryConversionHandler(res, httptest.NewRequest(http.MethodPost, RouteConversionRetry+"drive-job", nil))
+
+		newID := decodeQueuedID(t, res)
+		waitForDownloads(t, env, []string{newID})
+		status, _ := env.store.GetStatus(newID)
+		assert.Equal(t, "drive-job", status.PredecessorID)
+		assert.Equal(t, models.PhaseQueued, status.Phase)
+		job, ok := env.store.GetJob(newID)
+		require.True(t, ok)
+		assert.Equal(t, "drive-file", job.FileID)
+		data, err := os.ReadFile(job.UploadedFilePath)
+		require.NoError(t, err)
+		assert.Equal(t, "video drive-file", string(data))
+	})
+
+	t.Run("conversion not found", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		res := httptest.NewRecorder()
+		env.handler.RetryConversionHandler(res, httptest.NewRequest(http.MethodPost, RouteConversionRetry+"missing", nil))
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestRerunConversionHandler(t *testing.T) {
+	t.Run("re-runs a finished job with changed options", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		addFinishedJob(t, env, "done-job", false)
+
+		body := bytes.NewBufferString(`{"targetFormat":"mov","quality":"fast"}`)
+		res := httptest.NewRecorder()
+		env.handler.RerunConversionHandler(res, httptest.NewRequest(http.MethodPost, RouteConversionRerun+"done-job", body))
+
+		newID := decodeQueuedID(t, res)
+		job, ok := env.store.GetJob(newID)
+		require.True(t, ok)
+		assert.Equal(t, "mov", job.TargetFormat)
+		assert.Equal(t, "fast", job.Quality)
+		assert.True(t, job.RemoveSound, "options left out keep their previous values")
+		assert.Equal(t, ".mov", filepath.Ext(job.OutputFilePath))
+
+		status, _ := env.store.GetStatus(newID)
+		assert.Equal(t, "done-job", status.PredecessorID)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		sourcePath := addFinishedJob(t, env, "done-job", false)
+
+		body := bytes.NewBufferString(`{"targetFormat":"avi"}`)
+		res := httptest.NewRecorder()
+		env.handler.RerunConversionHandler(res, httptest.NewRequest(http.MethodPost, RouteConversionRerun+"done-job", body))
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+		assert.FileExists(t, sourcePath, "the source stays with the finished job")
+	})
+
+	t.Run("job still running", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		env.store.SetStatus("running", &models.ConversionStatus{Phase: models.PhaseEncoding})
+
+		res := httptest.NewRecorder()
+		env.handler.RerunConversionHandler(res, httptest.NewRequest(http.MethodPost, RouteConversionRerun+"running", nil))
+
+		assert.Equal(t, http.StatusConflict, res.Code)
+	})
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/filestore"
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/google/uuid"
+)
+
+// RetryConversionHandler queues a failed job again with the same options, reusing its
+// retained source file, or downloading it again for Google Drive jobs.
+func (h *Handler) RetryConversionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, RouteConversionRetry)
+	if id == "" {
+		h.sendErrorResponse(w, "Missing conversion ID", http.StatusBadRequest)
+		return
+	}
+
+	status, exists := h.Store.GetStatus(id)
+	if !exists {
+		h.sendErrorResponse(w, "Conversion not found", http.StatusNotFound)
+		return
+	}
+	if status.Phase != models.PhaseFailed {
+		h.sendErrorResponse(w, "Only failed conversions can be retried; use re-run to convert again with other options", http.StatusConflict)
+		return
+	}
+	h.queueSuccessorJob(w, r, id, nil)
+}
+
+// RerunConversionHandler queues a finished job again, reusing its retained source file.
+// The optional JSON body holds the options to change, in the format of a conversion
+// request; options it leaves out keep their previous values.
+func (h *Handler) RerunConversionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, RouteConversionRerun)
+	if id == "" {
+		h.sendErrorResponse(w, "Missing conversion ID", http.StatusBadRequest)
+		return
+	}
+
+	status, exists := h.Store.GetStatus(id)
+	if !exists {
+		h.sendErrorResponse(w, "Conversion not found", http.StatusNotFound)
+		return
+	}
+	if !status.Complete {
+		h.sendErrorResponse(w, "Conversion is still in progress", http.StatusConflict)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, constants.MaxJSONRequestSize)
+	overrides, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.sendErrorResponse(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	h.queueSuccessorJob(w, r, id, overrides)
+}
+
+// queueSuccessorJob queues a new job for the source of a finished job, with the finished
+// job's options changed by the given JSON overrides. The retained source file is moved to
+// the new job, which records the finished job as its predecessor.
+func (h *Handler) queueSuccessorJob(w http.ResponseWriter, r *http.Request, predecessorID string, overrides []byte) {
+	previous, exists := h.Store.GetJob(predecessorID)
+	if !exists {
+		h.sendErrorResponse(w, "The options of this conversion are no longer available", http.StatusConflict)
+		return
+	}
+
+	request, err := requestFromJob(previous)
+	if err != nil {
+		h.sendErrorResponse(w, "Failed to read the options of this conversion", http.StatusInternalServerError)
+		return
+	}
+	if len(bytes.TrimSpace(overrides)) > 0 {
+		if err := json.Unmarshal(overrides, &request); err != nil {
+			h.sendErrorResponse(w, fmt.Sprintf("Invalid request format: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if !validFormats[request.TargetFormat] {
+		h.sendErrorResponse(w, "Invalid target format specified", http.StatusBadRequest)
+		return
+	}
+
+	sanitizedBaseName := filestore.SanitizeFilename(request.FileName)
+	if sanitizedBaseName == "" {
+		sanitizedBaseName = fmt.Sprintf("video-%s", predecessorID)
+	}
+	conversionID := uuid.NewString()
+	uploadedFilePath, outputFilePath, err := h.resolveAndValidatePaths(sanitizedBaseName, request.TargetFormat, conversionID)
+	if err != nil {
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.newConversionJob(conversionID, request, uploadedFilePath, outputFilePath)
+	if err != nil {
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	job.ClientID = h.clientIdentity(r)
+	job.Status.PredecessorID = predecessorID
+
+	// Moving the source hands it to the new job; a concurrent retry of the same job finds it gone.
+	sourcePath := previous.UploadedFilePath
+	if err := os.Rename(sourcePath, uploadedFilePath); err != nil {
+		if os.IsNotExist(err) && previous.FileID != "" {
+			// Drive sources that were not retained, or never finished downloading, are
+			// downloaded again.
+			h.submitSuccessorDriveJob(w, job, predecessorID)
+			return
+		}
+		if os.IsNotExist(err) {
+			h.sendCodedErrorResponse(w, models.ErrorCodeSourceUnavailable,
+				"The source file of this conversion was not retained; upload or select it again", http.StatusGone)
+			return
+		}
+		log.Printf("ERROR [job %s]: Failed to reuse source of job %s: %v", conversionID, predecessorID, err)
+		h.sendErrorResponse(w, "Failed to reuse the source file", http.StatusInternalServerError)
+		return
+	}
+	// The moved source keeps its modification time; restart its age for the file cleanup.
+	now := time.Now()
+	if err := os.Chtimes(uploadedFilePath, now, now); err != nil {
+		log.Printf("WARN [job %s]: Failed to refresh the modification time of %s: %v", conversionID, uploadedFilePath, err)
+	}
+	h.Store.SetStatus(conversionID, job.Status)
+
+	if err := h.Converter.QueueJob(job); err != nil {
+		log.Printf("ERROR [job %s]: Failed to queue successor of job %s: %v", conversionID, predecessorID, err)
+		h.Store.DeleteStatus(conversionID)
+		// Give the source back so the job can be retried later.
+		if renameErr := os.Rename(uploadedFilePath, sourcePath); renameErr != nil {
+			log.Printf("WARN [job %s]: Failed to return source to job %s: %v", conversionID, predecessorID, renameErr)
+		}
+		h.sendCodedErrorResponse(w, models.ErrorCodeQueueFull, "Server busy, conversion queue is full", http.StatusServiceUnavailable)
+		return
+	}
+	log.Printf("Job %s queued as a successor of job %s", conversionID, predecessorID)
+
+	response := models.ConversionResponse{
+		Success:      true,
+		Message:      "Conversion job queued successfully",
+		ConversionID: conversionID,
+	}
+	h.sendJSONResponse(w, response, http.StatusAccepted)
+}
+
+// submitSuccessorDriveJob submits a successor job whose source is downloaded from Google
+// Drive again.
+func (h *Handler) submitSuccessorDriveJob(w http.ResponseWriter, job models.ConversionJob, predecessorID string) {
+	h.Store.SetStatus(job.ConversionID, job.Status)
+	if err := h.Converter.SubmitDriveJob(job); err != nil {
+		h.Store.DeleteStatus(job.ConversionID)
+		h.sendCodedErrorResponse(w, models.ErrorCodeQueueFull, "Server busy, conversion queue is full", http.StatusServiceUnavailable)
+		return
+	}
+	log.Printf("Job %s submitted as a successor of job %s, downloading its source from Google Drive again", job.ConversionID, predecessorID)
+
+	response := models.ConversionResponse{
+		Success:      true,
+		Message:      "Conversion job accepted; the file is being downloaded from Google Drive",
+		ConversionID: job.ConversionID,
+	}
+	h.sendJSONResponse(w, response, http.StatusAccepted)
+}
+
+// requestFromJob rebuilds the conversion request a job was created from. The result
+// shares no memory with the job, so it can be modified freely.
+func requestFromJob(job models.ConversionJob) (models.DriveConversionRequest, error) {
+	request := models.DriveConversionRequest{
+		FileID:        job.FileID,
+		FileName:      job.FileName,
+		TargetFormat:  job.TargetFormat,
+		Quality:       job.Quality,
+		ReverseVideo:  job.ReverseVideo,
+		RemoveSound:   job.RemoveSound,
+		Stabilization: job.Stabilization,
+		Filters:       job.Filters,
+		JobType:       job.JobType,
+		Scenes:        job.Scenes,
+		Silence:       job.Silence,
+		Metrics:       job.Metrics,
+		TargetVMAF:    job.TargetVMAF,
+		Priority:      job.Priority,
+		Destination:   job.Destination,
+	}
+
+	// Round-trip through JSON to copy the nested options.
+	data, err := json.Marshal(request)
+	if err != nil {
+		return models.DriveConversionRequest{}, err
+	}
+	var copied models.DriveConversionRequest
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return models.DriveConversionRequest{}, err
+	}
+	return copied, nil
+}
@@ -6,22 +6,44 @@ const (
 	// Config routes
 	RouteConfig = "/api/config"
 
+	// LUT library routes
+	RouteLUTs = "/api/luts"
+
 	// Video listing routes
 	RouteListDriveVideos = "/api/videos/drive"
+	RouteDriveThumbnail  = "/api/videos/drive/thumbnail/"
 
 	// Conversion routes
 	RouteConvertFromDrive = "/api/convert/drive"
 	RouteConvertUpload    = "/api/convert/upload"
+	RouteConvertDryRun    = "/api/convert/dry-run"
+	RouteConvertPreview   = "/api/convert/preview"
+	RouteConvertLibrary   = "/api/convert/library/"
+	RouteConvertBatch     = "/api/convert/batch"
+	RoutePreviewFile      = "/api/preview/"
 
 	// Conversion status and management routes
 	RouteActiveConversions       = "/api/conversions/active"
 	RouteActiveConversionsStream = "/api/conversions/stream"
+	RouteConversionsPauseAll     = "/api/conversions/pause"
+	RouteConversionsResumeAll    = "/api/conversions/resume"
 	RouteConversionStatus        = "/api/conversion/status/"
 	RouteConversionAbort         = "/api/conversion/abort/"
+	RouteConversionPause         = "/api/conversion/pause/"
+	RouteConversionResume        = "/api/conversion/resume/"
+	RouteConversionRetry         = "/api/conversion/retry/"
+	RouteConversionRerun         = "/api/conversion/rerun/"
+	RouteConversionPlan          = "/api/conversion/plan/"
+	RouteConversionThumbnail     = "/api/conversion/thumbnail/"
+
+	// Batch routes
+	RouteBatchStatus = "/api/batch/status/"
+	RouteBatchCancel = "/api/batch/cancel/"
 
 	// File management routes
-	RouteListFiles  = "/api/files"
-	RouteDeleteFile = "/api/file/delete/"
+	RouteListFiles       = "/api/files"
+	RouteDownloadArchive = "/api/files/archive"
+	RouteDeleteFile      = "/api/file/delete/"
 
 	// Download route
 	RouteDownload = "/download/"
@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gatanasi/video-converter/internal/models"
 	"github.com/stretchr/testify/assert"
@@ -42,6 +43,59 @@ func TestStatusHandler(t *testing.T) {
 		assert.Equal(t, "mp4", payload.Format)
 	})
 
+	t.Run("failed conversion reports error code", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		conversionID := "failed-conversion"
+		env.store.SetStatus(conversionID, &models.ConversionStatus{
+			OutputPath: filepath.Join(env.convertedDir, "test.mp4"),
+			Format:     "mp4",
+		})
+		env.store.UpdateStatusWithError(conversionID, models.ErrorCodeUnsupportedCodec, "FFmpeg execution failed: Unknown encoder 'libx265'")
+
+		req := httptest.NewRequest(http.MethodGet, RouteConversionStatus+conversionID, nil)
+		res := httptest.NewRecorder()
+
+		env.handler.StatusHandler(res, req)
+
+		var payload models.ConversionStatusResponse
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&payload))
+		assert.True(t, payload.Complete)
+		assert.Equal(t, models.ErrorCodeUnsupportedCodec, payload.ErrorCode)
+		assert.Contains(t, payload.Error, "Unknown encoder")
+		assert.Equal(t, models.PhaseFailed, payload.Phase)
+	})
+
+	t.Run("includes lifecycle phases", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		conversionID := "phased-conversion"
+		env.store.SetStatus(conversionID, &models.ConversionStatus{
+			OutputPath: filepath.Join(env.convertedDir, "test.mp4"),
+			Format:     "mp4",
+			Phase:      models.PhaseQueued,
+			Phases:     []models.PhaseRecord{{Phase: models.PhaseQueued, StartedAt: time.Now()}},
+		})
+		env.store.SetPhase(conversionID, models.PhaseEncoding, 100)
+		env.store.SetProgressPercentage(conversionID, 25)
+
+		req := httptest.NewRequest(http.MethodGet, RouteConversionStatus+conversionID, nil)
+		res := httptest.NewRecorder()
+
+		env.handler.StatusHandler(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		var payload models.ConversionStatusResponse
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&payload))
+		assert.Equal(t, models.PhaseEncoding, payload.Phase)
+		if assert.Len(t, payload.Phases, 2) {
+			assert.NotNil(t, payload.Phases[0].EndedAt)
+			assert.Equal(t, 25.0, payload.Phases[1].Progress)
+			assert.Nil(t, payload.Phases[1].EndedAt)
+		}
+	})
+
 	t.Run("conversion not found", func(t *testing.T) {
 		env := newHandlerTestEnv(t)
 
@@ -57,5 +111,6 @@ func TestStatusHandler(t *testing.T) {
 		assert.NoError(t, decodeErr)
 		assert.False(t, payload.Success)
 		assert.Contains(t, payload.Error, "not found")
+		assert.Equal(t, models.ErrorCodeNotFound, payload.ErrorCode)
 	})
 }
@@ -1,11 +1,16 @@
 package api
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gatanasi/video-converter/internal/conversion"
+	"github.com/gatanasi/video-converter/internal/drive"
 	"github.com/gatanasi/video-converter/internal/models"
 	"github.com/stretchr/testify/require"
 )
@@ -15,6 +20,7 @@ type handlerTestEnv struct {
 	store        *conversion.Store
 	uploadsDir   string
 	convertedDir string
+	dataDir      string
 }
 
 func newHandlerTestEnv(t *testing.T) *handlerTestEnv {
@@ -23,28 +29,82 @@ func newHandlerTestEnv(t *testing.T) *handlerTestEnv {
 	tempDir := t.TempDir()
 	uploadsDir := filepath.Join(tempDir, "uploads")
 	convertedDir := filepath.Join(tempDir, "converted")
+	dataDir := filepath.Join(tempDir, "data")
 
 	require.NoError(t, os.MkdirAll(uploadsDir, 0o755))
 	require.NoError(t, os.MkdirAll(convertedDir, 0o755))
 
 	config := models.Config{
-		Port:                 "3000",
-		MaxFileSize:          100 * 1024 * 1024,
-		UploadsDir:           uploadsDir,
-		ConvertedDir:         convertedDir,
-		GoogleDriveAPIKey:    "test-api-key",
-		WorkerCount:          2,
-		AllowedOrigins:       []string{"*"},
-		DefaultDriveFolderId: "test-folder-id",
+		Port:                  "3000",
+		MaxFileSize:           100 * 1024 * 1024,
+		MaxQueuedJobs:         10,
+		UploadsDir:            uploadsDir,
+		ConvertedDir:          convertedDir,
+		DataDir:               dataDir,
+		GoogleDriveAPIKey:     "test-api-key",
+		GoogleDriveAPIBaseURL: "http://127.0.0.1:0", // Tests needing Drive set up a fake server with useFakeDrive
+		WorkerCount:           2,
+		AllowedOrigins:        []string{"*"},
+		DefaultDriveFolderId:  "test-folder-id",
+		SourceRetention:       models.SourceRetentionFailed,
 	}
 
 	store := conversion.NewStore()
-	converter := conversion.NewVideoConverter(config.WorkerCount, store)
+	converter := conversion.NewVideoConverter(config.WorkerCount, config.MaxQueuedJobs, store)
+	converter.SetSourceRetention(config.SourceRetention)
 
 	return &handlerTestEnv{
-		handler:      NewHandler(config, converter, store),
+		handler:      NewHandler(config, converter, store, drive.NewClient(config.GoogleDriveAPIKey, config.GoogleDriveAPIBaseURL)),
 		store:        store,
 		uploadsDir:   uploadsDir,
 		convertedDir: convertedDir,
+		dataDir:      dataDir,
 	}
 }
+
+// useFakeDrive points the Drive client of env at a local server running handler.
+func useFakeDrive(t *testing.T, env *handlerTestEnv, handler http.Handler) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	env.handler.Drive = drive.NewClient(env.handler.Config.GoogleDriveAPIKey, server.URL)
+}
+
+// staticTokens is a drive.TokenSource returning a fixed token.
+type staticTokens string
+
+func (s staticTokens) Token(context.Context) (string, error) { return string(s), nil }
+
+// useDriveCredentials gives env a Drive client authorized with credentials, which can upload files.
+func useDriveCredentials(env *handlerTestEnv) {
+	env.handler.Drive = drive.NewClientWithTokens(staticTokens("test-token"), env.handler.Config.GoogleDriveAPIBaseURL)
+}
+
+// stubDriveDownloads makes the converter of env download Drive files locally instead of
+// from Google Drive. Files whose ID is listed in missing fail with drive.ErrNotFound.
+func stubDriveDownloads(env *handlerTestEnv, missing ...string) {
+	env.handler.Converter.SetDriveDownloader(func(ctx context.Context, fileID, destinationPath string, progress func(written, total int64)) error {
+		for _, id := range missing {
+			if id == fileID {
+				return drive.ErrNotFound
+			}
+		}
+		data := []byte("video " + fileID)
+		progress(int64(len(data)), int64(len(data)))
+		return os.WriteFile(destinationPath, data, 0o644)
+	})
+}
+
+// waitForDownloads waits until none of the given Drive jobs is waiting for or running its download.
+func waitForDownloads(t *testing.T, env *handlerTestEnv, ids []string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		for _, id := range ids {
+			status, _ := env.store.GetStatus(id)
+			if !status.Complete && status.QueuePosition == 0 {
+				return false
+			}
+		}
+		return true
+	}, 2*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/conversion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThumbnailHandler(t *testing.T) {
+	t.Run("route matches thumbnail URLs", func(t *testing.T) {
+		assert.Equal(t, RouteConversionThumbnail, conversion.ThumbnailURLPrefix)
+		assert.Equal(t, RouteDownload, conversion.DownloadURLPrefix)
+	})
+
+	t.Run("serves thumbnail", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		jobDir := filepath.Join(env.dataDir, constants.ThumbnailsSubdir, "job-1")
+		require.NoError(t, os.MkdirAll(jobDir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(jobDir, "scene-001.jpg"), []byte("jpeg"), 0o644))
+
+		req := httptest.NewRequest(http.MethodGet, RouteConversionThumbnail+"job-1/scene-001.jpg", nil)
+		res := httptest.NewRecorder()
+
+		env.handler.ThumbnailHandler(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "image/jpeg", res.Header().Get("Content-Type"))
+		assert.Equal(t, "jpeg", res.Body.String())
+	})
+
+	t.Run("missing thumbnail", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		req := httptest.NewRequest(http.MethodGet, RouteConversionThumbnail+"job-1/scene-009.jpg", nil)
+		res := httptest.NewRecorder()
+
+		env.handler.ThumbnailHandler(res, req)
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("rejects traversal", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+
+		req := httptest.NewRequest(http.MethodGet, RouteConversionThumbnail+"../secret.jpg", nil)
+		res := httptest.NewRecorder()
+
+		env.handler.ThumbnailHandler(res, req)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+}
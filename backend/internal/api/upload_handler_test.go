@@ -10,6 +10,7 @@ import (
 
 	"github.com/gatanasi/video-converter/internal/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUploadConvertHandler(t *testing.T) {
@@ -46,6 +47,32 @@ func TestUploadConvertHandler(t *testing.T) {
 		assert.NotEmpty(t, payload.ConversionID)
 	})
 
+	t.Run("Drive destination", func(t *testing.T) {
+		env := newHandlerTestEnv(t)
+		useDriveCredentials(env)
+
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		fileWriter, fileErr := writer.CreateFormFile("videoFile", "test-video.mov")
+		require.NoError(t, fileErr)
+		_, writeErr := fileWriter.Write([]byte("fake video content"))
+		require.NoError(t, writeErr)
+		require.NoError(t, writer.WriteField("targetFormat", "mp4"))
+		require.NoError(t, writer.WriteField("destinationFolderId", "exports"))
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest(http.MethodPost, RouteConvertUpload, &body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		res := httptest.NewRecorder()
+
+		env.handler.UploadConvertHandler(res, req)
+
+		id := decodeQueuedID(t, res)
+		job, ok := env.store.GetJob(id)
+		require.True(t, ok)
+		assert.Equal(t, &models.DriveDestination{FolderID: "exports"}, job.Destination)
+	})
+
 	t.Run("missing file", func(t *testing.T) {
 		env := newHandlerTestEnv(t)
 
@@ -102,3 +129,41 @@ func TestUploadConvertHandler(t *testing.T) {
 		assert.Contains(t, payload.Error, "exceeds maximum allowed size")
 	})
 }
+
+func TestClientIdentity(t *testing.T) {
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		trusted    bool
+		remoteAddr string
+		want       string
+	}{
+		{name: "remote address", remoteAddr: "203.0.113.7:51234", want: "ip:203.0.113.7"},
+		{name: "proxy user", headers: map[string]string{"X-Forwarded-User": "alice"}, trusted: true, remoteAddr: "10.0.0.1:80", want: "user:alice"},
+		{
+			name:       "api key wins and is hashed",
+			headers:    map[string]string{"X-API-Key": "secret", "X-Forwarded-User": "alice"},
+			trusted:    true,
+			remoteAddr: "10.0.0.1:80",
+			want:       "key:2bb80d537b1da3e3",
+		},
+		{
+			name:       "untrusted headers are ignored",
+			headers:    map[string]string{"X-API-Key": "secret", "X-Forwarded-User": "alice"},
+			remoteAddr: "10.0.0.1:80",
+			want:       "ip:10.0.0.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, RouteConvertUpload, nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			h := &Handler{Config: models.Config{TrustClientHeaders: tt.trusted}}
+			assert.Equal(t, tt.want, h.clientIdentity(req))
+		})
+	}
+}
@@ -23,6 +23,7 @@ func New() models.Config {
 
 	config.UploadsDir = getEnv("UPLOADS_DIR", constants.DefaultUploadsDir)
 	config.ConvertedDir = getEnv("CONVERTED_DIR", constants.DefaultConvertedDir)
+	config.DataDir = getEnv("DATA_DIR", constants.DefaultDataDir)
 
 	defaultWorkers := runtime.NumCPU()
 	workerCountStr := getEnv("WORKER_COUNT", strconv.Itoa(defaultWorkers)) // Get string for logging
@@ -32,14 +33,38 @@ func New() models.Config {
 		config.WorkerCount = defaultWorkers
 	}
 
+	config.MaxQueuedJobs = parseIntEnv("MAX_QUEUED_JOBS", constants.DefaultMaxQueuedJobs)
+	if config.MaxQueuedJobs < 1 {
+		log.Printf("Warning: Invalid MAX_QUEUED_JOBS '%d', using default %d", config.MaxQueuedJobs, constants.DefaultMaxQueuedJobs)
+		config.MaxQueuedJobs = constants.DefaultMaxQueuedJobs
+	}
+
+	config.SourceRetention = getEnv("SOURCE_RETENTION", constants.DefaultSourceRetention)
+	switch config.SourceRetention {
+	case models.SourceRetentionNone, models.SourceRetentionFailed, models.SourceRetentionAll:
+	default:
+		log.Printf("Warning: Invalid SOURCE_RETENTION '%s' (use none, failed or all), using default %s", config.SourceRetention, constants.DefaultSourceRetention)
+		config.SourceRetention = constants.DefaultSourceRetention
+	}
+
+	config.TrustClientHeaders = getEnv("TRUST_CLIENT_HEADERS", "false") == "true"
+
 	config.DefaultDriveFolderId = getEnv("DEFAULT_DRIVE_FOLDER_ID", "")
 	if config.DefaultDriveFolderId != "" {
 		log.Printf("Default Google Drive Folder ID configured: %s", config.DefaultDriveFolderId)
 	}
 
 	config.GoogleDriveAPIKey = os.Getenv("GOOGLE_DRIVE_API_KEY")
-	if config.GoogleDriveAPIKey == "" {
-		log.Fatal("FATAL: GOOGLE_DRIVE_API_KEY environment variable not set.")
+	config.GoogleDriveCredentialsFile = os.Getenv("GOOGLE_DRIVE_CREDENTIALS_FILE")
+	config.GoogleDriveImpersonateUser = os.Getenv("GOOGLE_DRIVE_IMPERSONATE_USER")
+	if config.GoogleDriveCredentialsFile != "" {
+		log.Printf("Using Google credentials from %s for Google Drive", config.GoogleDriveCredentialsFile)
+	} else if config.GoogleDriveAPIKey == "" {
+		log.Fatal("FATAL: Neither GOOGLE_DRIVE_API_KEY nor GOOGLE_DRIVE_CREDENTIALS_FILE environment variable set.")
+	}
+	config.GoogleDriveAPIBaseURL = getEnv("GOOGLE_DRIVE_API_BASE_URL", constants.DefaultDriveAPIBaseURL)
+	if config.GoogleDriveAPIBaseURL != constants.DefaultDriveAPIBaseURL {
+		log.Printf("Using Google Drive API base URL: %s", config.GoogleDriveAPIBaseURL)
 	}
 
 	allowedOriginsStr := getEnv("ALLOWED_ORIGINS", "")
@@ -57,8 +82,8 @@ func New() models.Config {
 		}
 	}
 
-	log.Printf("Configuration loaded: Port=%s, MaxFileSize=%dMB, Workers=%d, AllowedOrigins=%v",
-		config.Port, maxFileSizeMB, config.WorkerCount, config.AllowedOrigins)
+	log.Printf("Configuration loaded: Port=%s, MaxFileSize=%dMB, Workers=%d, MaxQueuedJobs=%d, SourceRetention=%s, AllowedOrigins=%v",
+		config.Port, maxFileSizeMB, config.WorkerCount, config.MaxQueuedJobs, config.SourceRetention, config.AllowedOrigins)
 
 	return config
 }
@@ -34,6 +34,9 @@ const (
 
 	// UploadSizeBuffer is extra buffer added to MaxFileSize for upload handling
 	UploadSizeBuffer = 1 * 1024 * 1024 // 1 MB
+
+	// MaxArchiveFiles is the maximum number of files in one ZIP download
+	MaxArchiveFiles = 500
 )
 
 // File Cleanup Configuration
@@ -78,13 +81,211 @@ const (
 	ProgressMaxBeforeCompletion = 99.0
 )
 
+// Video Stabilization Configuration
+const (
+	// StabilizationAnalysisProgressShare is the share of job progress (percent) assigned to the vidstabdetect pass
+	StabilizationAnalysisProgressShare = 30.0
+
+	// StabilizationShakiness is the vidstabdetect shakiness setting (1-10)
+	StabilizationShakiness = 5
+
+	// StabilizationAccuracy is the vidstabdetect accuracy setting (1-15)
+	StabilizationAccuracy = 15
+
+	// StabilizationDefaultSmoothing is the number of frames used for smoothing when none is requested
+	StabilizationDefaultSmoothing = 10
+
+	// StabilizationMaxSmoothing is the maximum accepted smoothing value
+	StabilizationMaxSmoothing = 100
+
+	// StabilizationMaxZoom is the maximum accepted absolute zoom percentage
+	StabilizationMaxZoom = 50.0
+)
+
+// Video Filter Configuration
+const (
+	// FilterMinHeight is the smallest accepted scaling target height in pixels
+	FilterMinHeight = 144
+
+	// FilterDefaultDenoiseStrength is the denoise strength used when none is requested
+	FilterDefaultDenoiseStrength = 4.0
+
+	// FilterMaxDenoiseStrength is the maximum accepted denoise strength
+	FilterMaxDenoiseStrength = 30.0
+
+	// FilterMaxSharpen is the maximum accepted unsharp luma amount
+	FilterMaxSharpen = 3.0
+
+	// MaxLUTFileSize is the maximum size of an uploaded .cube LUT file
+	MaxLUTFileSize = 64 * 1024 * 1024 // 64 MB
+)
+
+// Scene Detection Configuration
+const (
+	// SceneDefaultThreshold is the scene change score above which a new scene starts
+	SceneDefaultThreshold = 0.4
+
+	// SceneMinSeconds is the minimum scene length; shorter scenes are merged
+	SceneMinSeconds = 1.0
+
+	// SceneMinChunkSeconds is the minimum chunk length when splitting into fixed chunks
+	SceneMinChunkSeconds = 2.0
+
+	// SceneThumbnailWidth is the width in pixels of generated scene thumbnails
+	SceneThumbnailWidth = 320
+
+	// SceneDetectProgressShare is the share of job progress (percent) assigned to detection when splitting
+	SceneDetectProgressShare = 20.0
+
+	// SceneThumbnailProgressShare is the share of job progress (percent) assigned to thumbnail extraction
+	SceneThumbnailProgressShare = 10.0
+)
+
+// Silence Removal Configuration
+const (
+	// SilenceDefaultNoiseDB is the audio level (dBFS) below which audio is considered silent
+	SilenceDefaultNoiseDB = -30.0
+
+	// SilenceDefaultMinSeconds is the default minimum length of a silence to remove
+	SilenceDefaultMinSeconds = 0.5
+
+	// SilenceMinMinSeconds is the lowest accepted minimum silence length
+	SilenceMinMinSeconds = 0.1
+
+	// SilenceDefaultPaddingSeconds is the silence kept on each side of a cut so speech is not clipped
+	SilenceDefaultPaddingSeconds = 0.1
+
+	// SilenceDetectProgressShare is the share of job progress (percent) assigned to silence detection
+	SilenceDetectProgressShare = 20.0
+)
+
+// Quality Metrics Configuration
+const (
+	// MetricsSampleSeconds is the length of the window compared in sampled mode
+	MetricsSampleSeconds = 10.0
+
+	// MetricsProgressShare is the share of job progress (percent) assigned to quality measurement
+	MetricsProgressShare = 15.0
+)
+
+// CRF Search Configuration
+const (
+	// CRFSearchMinCRF is the lowest (highest quality) CRF considered by the search
+	CRFSearchMinCRF = 16
+
+	// CRFSearchMaxCRF is the highest (lowest bitrate) CRF considered by the search
+	CRFSearchMaxCRF = 40
+
+	// CRFSearchMaxTrials is the maximum number of sample encodes per search
+	CRFSearchMaxTrials = 6
+
+	// CRFSearchSampleCount is the number of representative windows taken from the video
+	CRFSearchSampleCount = 3
+
+	// CRFSearchSampleSeconds is the length of each representative window
+	CRFSearchSampleSeconds = 4.0
+
+	// CRFSearchProgressShare is the share of job progress (percent) assigned to the search
+	CRFSearchProgressShare = 30.0
+)
+
+// Preview Configuration
+const (
+	// PreviewDefaultSeconds is the default length of a preview sample
+	PreviewDefaultSeconds = 10.0
+
+	// PreviewMaxSeconds is the maximum length of a preview sample
+	PreviewMaxSeconds = 60.0
+
+	// PreviewTimeout is the maximum time a preview encode may take
+	PreviewTimeout = 5 * time.Minute
+
+	// MaxConcurrentPreviews is the number of preview encodes allowed to run at once
+	MaxConcurrentPreviews = 2
+)
+
+// Job Persistence Configuration
+const (
+	// JobJournalCompactMinEntries is the number of journal entries written before compaction is considered
+	JobJournalCompactMinEntries = 1000
+
+	// JobJournalCompactRatio is how many journal entries per stored job trigger a compaction
+	JobJournalCompactRatio = 4
+)
+
+// Job Scheduling Configuration
+const (
+	// SchedulerWeightInteractive is the relative share of workers for interactive jobs
+	SchedulerWeightInteractive = 8
+
+	// SchedulerWeightNormal is the relative share of workers for normal jobs
+	SchedulerWeightNormal = 4
+
+	// SchedulerWeightBulk is the relative share of workers for bulk jobs
+	SchedulerWeightBulk = 1
+)
+
+// Batch Configuration
+const (
+	// MaxBatchFiles is the maximum number of files accepted in one batch
+	MaxBatchFiles = 200
+)
+
 // Google Drive API Configuration
 const (
+	// DefaultDriveAPIBaseURL is the root URL of the Google APIs
+	DefaultDriveAPIBaseURL = "https://www.googleapis.com"
+
+	// DefaultGoogleTokenURL is the Google OAuth token endpoint, used when a credentials file names none
+	DefaultGoogleTokenURL = "https://oauth2.googleapis.com/token"
+
+	// DriveOAuthScope is the OAuth scope requested for Drive; full access allows uploading converted files
+	DriveOAuthScope = "https://www.googleapis.com/auth/drive"
+
+	// DriveJWTLifetime is the lifetime of the JWTs signed by service accounts, and of access tokens without a reported expiry
+	DriveJWTLifetime = 1 * time.Hour
+
+	// DriveTokenRefreshMargin is how long before it expires a cached access token is refreshed
+	DriveTokenRefreshMargin = 1 * time.Minute
+
 	// DriveAPIRequestTimeout is the timeout for standard Drive API requests
 	DriveAPIRequestTimeout = 30 * time.Second
 
-	// DriveAPIDownloadTimeout is the timeout for file download operations
+	// DriveAPIDownloadTimeout is the timeout for a single download attempt
 	DriveAPIDownloadTimeout = 20 * time.Minute
+
+	// DriveUploadChunkTimeout is the timeout for sending one chunk of an upload
+	DriveUploadChunkTimeout = 5 * time.Minute
+
+	// DriveUploadChunkSize is the size of the chunks of resumable uploads; Drive requires a multiple of 256 KiB
+	DriveUploadChunkSize = 8 * 1024 * 1024
+
+	// DriveTransferMaxAttempts is the number of consecutive attempts without progress before a download or upload fails
+	DriveTransferMaxAttempts = 5
+
+	// DriveTransferRetryBaseDelay is the delay before the first retry of an interrupted download or upload
+	DriveTransferRetryBaseDelay = 2 * time.Second
+
+	// DriveTransferRetryMaxDelay is the maximum delay between download or upload retries
+	DriveTransferRetryMaxDelay = 1 * time.Minute
+
+	// MaxConcurrentDownloads is the number of Drive downloads run at once
+	MaxConcurrentDownloads = 3
+
+	// DriveListPageSize is the number of files requested per page when listing a folder
+	DriveListPageSize = 1000
+
+	// DriveListMaxDepth is the number of folder levels listed by a recursive listing
+	DriveListMaxDepth = 10
+
+	// DriveListMaxFolders is the maximum number of folders listed by a recursive listing
+	DriveListMaxFolders = 500
+
+	// DriveThumbnailMaxSize is the maximum size in bytes of a proxied Drive thumbnail
+	DriveThumbnailMaxSize = 5 * 1024 * 1024
+
+	// DriveThumbnailCacheMaxAge is how long browsers may cache a proxied Drive thumbnail
+	DriveThumbnailCacheMaxAge = 1 * time.Hour
 )
 
 // File System Configuration
@@ -92,6 +293,9 @@ const (
 	// DirectoryPermissions is the default permission mode for created directories
 	DirectoryPermissions os.FileMode = 0755
 
+	// FilePermissions is the default permission mode for created files
+	FilePermissions os.FileMode = 0644
+
 	// MaxFilenameLength is the maximum length for sanitized filenames
 	MaxFilenameLength = 100
 )
@@ -101,9 +305,33 @@ const (
 	// DefaultMaxFileSizeMB is the default maximum file size in megabytes
 	DefaultMaxFileSizeMB = 2000
 
+	// DefaultMaxQueuedJobs is the default maximum number of jobs waiting for a worker
+	DefaultMaxQueuedJobs = 500
+
+	// DefaultSourceRetention is the default source retention policy (see models.SourceRetentionFailed)
+	DefaultSourceRetention = "failed"
+
 	// DefaultUploadsDir is the default directory for uploaded files
 	DefaultUploadsDir = "uploads"
 
 	// DefaultConvertedDir is the default directory for converted files
 	DefaultConvertedDir = "converted"
+
+	// DefaultDataDir is the default directory for application data such as LUTs
+	DefaultDataDir = "data"
+
+	// LUTsSubdir is the directory within the data directory holding .cube LUT files
+	LUTsSubdir = "luts"
+
+	// ThumbnailsSubdir is the directory within the data directory holding per-job thumbnails
+	ThumbnailsSubdir = "thumbnails"
+
+	// MetricsSubdir is the directory within the data directory holding quality metrics sidecar files
+	MetricsSubdir = "metrics"
+
+	// PreviewsSubdir is the directory within the data directory holding preview samples
+	PreviewsSubdir = "previews"
+
+	// JobJournalFile is the file within the data directory recording job history
+	JobJournalFile = "jobs.journal"
 )
@@ -0,0 +1,149 @@
+package conversion
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+// ErrBatchNotFound is returned when no batch exists with the requested ID.
+var ErrBatchNotFound = errors.New("batch not found")
+
+// GetBatchStatus returns the aggregate status of the jobs of a batch.
+func (s *Store) GetBatchStatus(batchID string) (models.BatchStatusResponse, bool) {
+	s.statusesMutex.RLock()
+	defer s.statusesMutex.RUnlock()
+	ids, exists := s.batches[batchID]
+	if !exists {
+		return models.BatchStatusResponse{}, false
+	}
+	return s.summarizeBatch(batchID, ids), true
+}
+
+// ActiveBatches returns the status of every batch that still has unfinished jobs,
+// ordered by batch ID.
+func (s *Store) ActiveBatches() []models.BatchStatusResponse {
+	s.statusesMutex.RLock()
+	defer s.statusesMutex.RUnlock()
+	batches := []models.BatchStatusResponse{}
+	for batchID, ids := range s.batches {
+		if summary := s.summarizeBatch(batchID, ids); !summary.Complete {
+			batches = append(batches, summary)
+		}
+	}
+	sort.Slice(batches, func(i, j int) bool { return batches[i].ID < batches[j].ID })
+	return batches
+}
+
+// batchJobIDs returns the IDs of the jobs of a batch in submission order.
+func (s *Store) batchJobIDs(batchID string) ([]string, bool) {
+	s.statusesMutex.RLock()
+	defer s.statusesMutex.RUnlock()
+	ids, exists := s.batches[batchID]
+	return append([]string(nil), ids...), exists
+}
+
+// summarizeBatch aggregates the statuses of the given jobs of a batch.
+// The caller must hold the statuses lock.
+func (s *Store) summarizeBatch(batchID string, ids []string) models.BatchStatusResponse {
+	summary := models.BatchStatusResponse{
+		ID:            batchID,
+		Total:         len(ids),
+		ConversionIDs: append([]string(nil), ids...),
+		Outputs:       []models.BatchOutput{},
+	}
+	var progress float64
+	for _, id := range ids {
+		status := s.statuses[id]
+		switch {
+		case !status.Complete:
+			summary.Pending++
+			progress += status.Progress
+			continue
+		case status.Phase == models.PhaseCanceled || status.ErrorCode == models.ErrorCodeAborted:
+			summary.Canceled++
+		case status.Error != "":
+			summary.Failed++
+		default:
+			summary.Succeeded++
+			summary.Outputs = append(summary.Outputs, batchOutputs(id, *status)...)
+		}
+		progress += 100
+	}
+	if summary.Total > 0 {
+		summary.Progress = progress / float64(summary.Total)
+	}
+	summary.Complete = summary.Pending == 0
+	return summary
+}
+
+// batchOutputs lists the downloadable files of a finished job.
+func batchOutputs(id string, status models.ConversionStatus) []models.BatchOutput {
+	var outputs []models.BatchOutput
+	if HasSingleDownload(status) {
+		name := filepath.Base(status.OutputPath)
+		outputs = append(outputs, models.BatchOutput{ConversionID: id, FileName: name, DownloadURL: DownloadURLPrefix + name})
+	}
+	for _, output := range status.Outputs {
+		outputs = append(outputs, models.BatchOutput{ConversionID: id, FileName: output.FileName, DownloadURL: output.DownloadURL})
+	}
+	return outputs
+}
+
+// removeFromBatch drops a deleted job from its batch, and the batch once it is empty.
+// The caller must hold the statuses lock for writing.
+func (s *Store) removeFromBatch(batchID, id string) {
+	if batchID == "" {
+		return
+	}
+	ids := s.batches[batchID]
+	for i, jobID := range ids {
+		if jobID == id {
+			ids = append(ids[:i:i], ids[i+1:]...)
+			break
+		}
+	}
+	if len(ids) == 0 {
+		delete(s.batches, batchID)
+		return
+	}
+	s.batches[batchID] = ids
+}
+
+// publishBatch sends the aggregate status of a batch to subscribers.
+func (s *Store) publishBatch(batchID string) {
+	summary, exists := s.GetBatchStatus(batchID)
+	if !exists {
+		return
+	}
+	s.publish(StoreEvent{
+		Type:    "batch",
+		BatchID: batchID,
+		Batch:   &summary,
+	})
+}
+
+// CancelBatch cancels every unfinished job of a batch and returns the IDs of the jobs
+// it canceled. Jobs that have already finished are left alone.
+func (c *VideoConverter) CancelBatch(batchID string) ([]string, error) {
+	ids, exists := c.store.batchJobIDs(batchID)
+	if !exists {
+		return nil, ErrBatchNotFound
+	}
+	canceled := []string{}
+	for _, id := range ids {
+		if err := c.CancelJob(id); err != nil {
+			if errors.Is(err, ErrJobComplete) || errors.Is(err, ErrJobNotFound) {
+				continue
+			}
+			return canceled, fmt.Errorf("failed to cancel job %s: %w", id, err)
+		}
+		canceled = append(canceled, id)
+	}
+	log.Printf("INFO: Canceled batch %s (%d of %d jobs)", batchID, len(canceled), len(ids))
+	return canceled, nil
+}
@@ -0,0 +1,119 @@
+package conversion
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBatchTestJob registers a queued job belonging to a batch.
+func newBatchTestJob(t *testing.T, store *Store, batchID, id string) models.ConversionJob {
+	t.Helper()
+	status := newQueuedStatus()
+	status.BatchID = batchID
+	status.OutputPath = id + ".mp4"
+	store.SetStatus(id, status)
+	return models.ConversionJob{ConversionID: id, UploadedFilePath: filepath.Join(t.TempDir(), id+".mov"), Status: status}
+}
+
+func TestBatchStatus(t *testing.T) {
+	store := NewStore()
+	for _, id := range []string{"done", "broken", "stopped", "running"} {
+		newBatchTestJob(t, store, "batch-1", id)
+	}
+	newBatchTestJob(t, store, "batch-2", "other")
+
+	store.UpdateStatusOnSuccess("done")
+	store.UpdateStatusWithError("broken", models.ErrorCodeConversionFailed, "FFmpeg execution failed")
+	store.UpdateStatusCanceled("stopped")
+	store.SetProgressPercentage("running", 40)
+
+	summary, ok := store.GetBatchStatus("batch-1")
+	require.True(t, ok)
+	assert.Equal(t, []string{"done", "broken", "stopped", "running"}, summary.ConversionIDs, "jobs are kept in submission order")
+	assert.Equal(t, 4, summary.Total)
+	assert.Equal(t, 1, summary.Succeeded)
+	assert.Equal(t, 1, summary.Failed)
+	assert.Equal(t, 1, summary.Canceled)
+	assert.Equal(t, 1, summary.Pending)
+	assert.False(t, summary.Complete)
+	assert.InDelta(t, 85.0, summary.Progress, 0.01, "finished jobs count as done")
+	require.Len(t, summary.Outputs, 1)
+	assert.Equal(t, "done", summary.Outputs[0].ConversionID)
+	assert.Equal(t, DownloadURLPrefix+"done.mp4", summary.Outputs[0].DownloadURL)
+
+	active := store.ActiveBatches()
+	require.Len(t, active, 2)
+	assert.Equal(t, "batch-1", active[0].ID)
+
+	store.UpdateStatusOnSuccess("running")
+	summary, _ = store.GetBatchStatus("batch-1")
+	assert.True(t, summary.Complete)
+	assert.Equal(t, 100.0, summary.Progress)
+	assert.Len(t, store.ActiveBatches(), 1)
+
+	_, ok = store.GetBatchStatus("missing")
+	assert.False(t, ok)
+}
+
+func TestBatchDeletion(t *testing.T) {
+	store := NewStore()
+	newBatchTestJob(t, store, "batch", "first")
+	newBatchTestJob(t, store, "batch", "second")
+
+	store.DeleteStatus("first")
+	summary, ok := store.GetBatchStatus("batch")
+	require.True(t, ok)
+	assert.Equal(t, []string{"second"}, summary.ConversionIDs)
+
+	store.DeleteStatus("second")
+	_, ok = store.GetBatchStatus("batch")
+	assert.False(t, ok, "a batch disappears with its last job")
+}
+
+func TestBatchEvents(t *testing.T) {
+	store := NewStore()
+	events := store.Subscribe()
+	defer store.Unsubscribe(events)
+
+	newBatchTestJob(t, store, "batch", "job")
+	store.SetProgressPercentage("job", 50)
+
+	var last *models.BatchStatusResponse
+	for len(events) > 0 {
+		if event := <-events; event.Type == "batch" {
+			assert.Equal(t, "batch", event.BatchID)
+			last = event.Batch
+		}
+	}
+	require.NotNil(t, last)
+	assert.Equal(t, 1, last.Pending)
+	assert.Greater(t, last.Progress, 0.0)
+}
+
+func TestCancelBatch(t *testing.T) {
+	store := NewStore()
+	converter := NewVideoConverter(1, 10, store) // Workers are not started, so jobs stay queued
+	queued := newBatchTestJob(t, store, "batch", "queued")
+	require.NoError(t, converter.QueueJob(queued))
+	newBatchTestJob(t, store, "batch", "downloading")
+	store.SetPhase("downloading", models.PhaseDownloading, 0)
+	newBatchTestJob(t, store, "batch", "finished")
+	store.UpdateStatusOnSuccess("finished")
+
+	canceled, err := converter.CancelBatch("batch")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"queued", "downloading"}, canceled)
+
+	summary, _ := store.GetBatchStatus("batch")
+	assert.Equal(t, 2, summary.Canceled)
+	assert.Equal(t, 1, summary.Succeeded)
+	assert.True(t, summary.Complete)
+	assert.Zero(t, converter.queue.len())
+
+	_, err = converter.CancelBatch("missing")
+	assert.ErrorIs(t, err, ErrBatchNotFound)
+}
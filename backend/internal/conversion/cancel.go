@@ -0,0 +1,73 @@
+package conversion
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+var (
+	// ErrJobNotFound is returned when no job exists with the requested ID.
+	ErrJobNotFound = errors.New("conversion not found")
+	// ErrJobComplete is returned when a job has already finished, failed or been canceled.
+	ErrJobComplete = errors.New("conversion already complete or aborted")
+)
+
+// CancelJob aborts a job in any non-terminal phase. A queued job is taken out of the
+// queue and its input files are removed right away. A running job is marked canceled
+// first and then interrupted: its Drive download is canceled or its FFmpeg process is
+// terminated, and the worker removes the input files and any partial output. Steps that
+// cannot be interrupted, such as probing, stop at the next step boundary.
+func (c *VideoConverter) CancelJob(id string) error {
+	status, exists := c.store.GetStatus(id)
+	if !exists {
+		return ErrJobNotFound
+	}
+	if status.Complete {
+		return ErrJobComplete
+	}
+
+	if job, queued := c.queue.remove(id); queued {
+		c.store.UpdateStatusCanceled(id)
+		c.updateQueuePositions()
+		c.removeInputFiles(job)
+		log.Printf("INFO [job %s]: Canceled while queued", id)
+		return nil
+	}
+
+	// Mark the job before interrupting it, so the worker sees the interruption as a cancellation.
+	if !c.store.UpdateStatusCanceled(id) {
+		return ErrJobComplete
+	}
+	c.store.cancelJobContext(id)
+	if cmd, active := c.store.GetActiveCmd(id); active {
+		if err := terminateProcess(cmd); err != nil {
+			log.Printf("ERROR [job %s]: Failed to stop FFmpeg process: %v", id, err)
+			return err
+		}
+		log.Printf("INFO [job %s]: Sent termination signal to FFmpeg process", id)
+	}
+	log.Printf("INFO [job %s]: Canceled in phase %s", id, status.Phase)
+	return nil
+}
+
+// abortIfCanceled removes the files of a job the user has canceled and reports whether
+// it was canceled. Workers call it between steps that cannot be interrupted.
+func (c *VideoConverter) abortIfCanceled(job models.ConversionJob) bool {
+	if !c.isCanceled(job.ConversionID) {
+		return false
+	}
+	log.Printf("INFO [job %s]: Stopping canceled job", job.ConversionID)
+	c.removeOutputFile(job)
+	c.removeInputFiles(job)
+	return true
+}
+
+// removeOutputFile removes the (possibly partial) output file of a job.
+func (c *VideoConverter) removeOutputFile(job models.ConversionJob) {
+	if err := os.Remove(job.OutputFilePath); err != nil && !os.IsNotExist(err) {
+		log.Printf("WARN [job %s]: Failed to remove incomplete output file %s: %v", job.ConversionID, job.OutputFilePath, err)
+	}
+}
@@ -0,0 +1,101 @@
+package conversion
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCancelTestJob registers a job whose input and partial output exist on disk.
+func newCancelTestJob(t *testing.T, store *Store, id string) models.ConversionJob {
+	t.Helper()
+	dir := t.TempDir()
+	job := models.ConversionJob{
+		ConversionID:     id,
+		UploadedFilePath: filepath.Join(dir, id+".mov"),
+		OutputFilePath:   filepath.Join(dir, id+".mp4"),
+		Status:           newQueuedStatus(),
+	}
+	require.NoError(t, os.WriteFile(job.UploadedFilePath, []byte("input"), 0o644))
+	require.NoError(t, os.WriteFile(job.OutputFilePath, []byte("partial"), 0o644))
+	store.SetStatus(id, job.Status)
+	return job
+}
+
+func TestCancelJob(t *testing.T) {
+	t.Run("queued job is removed from the queue", func(t *testing.T) {
+		store := NewStore()
+		converter := NewVideoConverter(1, 10, store) // Workers are not started, so jobs stay queued
+		first := newCancelTestJob(t, store, "first")
+		second := newCancelTestJob(t, store, "second")
+		require.NoError(t, converter.QueueJob(first))
+		require.NoError(t, converter.QueueJob(second))
+
+		require.NoError(t, converter.CancelJob("first"))
+
+		status, ok := store.GetStatus("first")
+		require.True(t, ok)
+		assert.Equal(t, models.PhaseCanceled, status.Phase)
+		assert.Equal(t, models.ErrorCodeAborted, status.ErrorCode)
+		assert.Zero(t, status.QueuePosition)
+		assert.NoFileExists(t, first.UploadedFilePath)
+		assert.Equal(t, []string{"second"}, converter.queue.ids())
+
+		status, _ = store.GetStatus("second")
+		assert.Equal(t, 1, status.QueuePosition, "later jobs move up")
+	})
+
+	t.Run("downloading job has its context canceled", func(t *testing.T) {
+		store := NewStore()
+		converter := NewVideoConverter(1, 10, store)
+		newCancelTestJob(t, store, "download")
+		store.SetPhase("download", models.PhaseDownloading, 0)
+
+		ctx, release := store.JobContext("download")
+		defer release()
+		require.NoError(t, converter.CancelJob("download"))
+
+		assert.Error(t, ctx.Err())
+		status, _ := store.GetStatus("download")
+		assert.Equal(t, models.PhaseCanceled, status.Phase)
+	})
+
+	t.Run("context of an already canceled job starts canceled", func(t *testing.T) {
+		store := NewStore()
+		converter := NewVideoConverter(1, 10, store)
+		newCancelTestJob(t, store, "early")
+		require.NoError(t, converter.CancelJob("early"))
+
+		ctx, release := store.JobContext("early")
+		defer release()
+		assert.Error(t, ctx.Err())
+	})
+
+	t.Run("worker stops a job canceled after it left the queue", func(t *testing.T) {
+		store := NewStore()
+		converter := NewVideoConverter(1, 10, store)
+		job := newCancelTestJob(t, store, "picked")
+		store.SetPhase("picked", models.PhaseProbing, 0)
+		require.NoError(t, converter.CancelJob("picked"))
+
+		assert.False(t, converter.prepareJob(job))
+		assert.NoFileExists(t, job.UploadedFilePath)
+		assert.NoFileExists(t, job.OutputFilePath)
+		status, _ := store.GetStatus("picked")
+		assert.Equal(t, models.PhaseCanceled, status.Phase)
+	})
+
+	t.Run("unknown and finished jobs", func(t *testing.T) {
+		store := NewStore()
+		converter := NewVideoConverter(1, 10, store)
+		assert.ErrorIs(t, converter.CancelJob("missing"), ErrJobNotFound)
+
+		newCancelTestJob(t, store, "done")
+		store.UpdateStatusOnSuccess("done")
+		assert.ErrorIs(t, converter.CancelJob("done"), ErrJobComplete)
+	})
+}
@@ -4,13 +4,14 @@ package conversion
 import (
 	"bufio"
 	"context" // Import context package
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -24,17 +25,54 @@ import (
 // VideoConverter manages the conversion worker pool and queue.
 type VideoConverter struct {
 	workersCount int
-	queue        chan models.ConversionJob
+	queue        *jobQueue
+	positionsMu  sync.Mutex // Serializes queue position updates so the latest order wins
+	pauseMu      sync.Mutex // Serializes pausing and resuming of FFmpeg processes
 	wg           sync.WaitGroup
 	store        *Store
+	previewSlots chan struct{} // Limits concurrently running preview encodes
+
+	downloader    DriveDownloader // Downloads the sources of Drive jobs
+	downloadSlots chan struct{}   // Limits concurrently running Drive downloads
+	uploader      DriveUploader   // Uploads the outputs of jobs with a Drive destination
+
+	sourceRetention string // One of the models.SourceRetention policies
 }
 
-// NewVideoConverter creates a new VideoConverter.
-func NewVideoConverter(workerCount int, store *Store) *VideoConverter {
+// NewVideoConverter creates a new VideoConverter whose queue accepts at most queueLimit pending jobs.
+func NewVideoConverter(workerCount, queueLimit int, store *Store) *VideoConverter {
 	return &VideoConverter{
 		workersCount: workerCount,
-		queue:        make(chan models.ConversionJob, workerCount*2),
+		queue:        newJobQueue(queueLimit),
 		store:        store,
+		previewSlots: make(chan struct{}, constants.MaxConcurrentPreviews),
+
+		downloadSlots: make(chan struct{}, constants.MaxConcurrentDownloads),
+
+		sourceRetention: models.SourceRetentionNone,
+	}
+}
+
+// SetSourceRetention sets the policy deciding which finished jobs keep their input file
+// for a retry or re-run. It must be called before the workers are started.
+func (c *VideoConverter) SetSourceRetention(policy string) {
+	c.sourceRetention = policy
+}
+
+// retainsSource reports whether the source retention policy keeps the input file of a
+// finished job. Canceled jobs never keep their source.
+func (c *VideoConverter) retainsSource(conversionID string) bool {
+	status, exists := c.store.GetStatus(conversionID)
+	if !exists || !status.Complete || status.ErrorCode == models.ErrorCodeAborted {
+		return false
+	}
+	switch c.sourceRetention {
+	case models.SourceRetentionAll:
+		return true
+	case models.SourceRetentionFailed:
+		return status.Error != ""
+	default:
+		return false
 	}
 }
 
@@ -47,10 +85,20 @@ func (c *VideoConverter) Start() {
 	log.Printf("Started %d conversion workers", c.workersCount)
 }
 
-// Stop signals workers to stop and waits for them to finish.
+// Stop signals workers to stop and waits for them to finish their current job.
+// Jobs still waiting in the queue are left in the store and restored on the next start.
 func (c *VideoConverter) Stop() {
-	close(c.queue) // Signal workers no more jobs are coming
-	c.wg.Wait()    // Wait for all worker goroutines to exit
+	if pending := c.queue.len(); pending > 0 {
+		log.Printf("Leaving %d queued jobs for the next start", pending)
+	}
+	// Paused jobs could never finish, so let them run to completion.
+	if resumed, err := c.ResumeAll(); err != nil {
+		log.Printf("WARN: Failed to resume paused jobs before stopping: %v", err)
+	} else if len(resumed) > 0 {
+		log.Printf("Resumed %d paused jobs before stopping", len(resumed))
+	}
+	c.queue.close() // Signal workers no more jobs are coming
+	c.wg.Wait()     // Wait for all worker goroutines to exit
 	log.Println("All conversion workers stopped")
 }
 
@@ -58,26 +106,45 @@ func (c *VideoConverter) Stop() {
 func (c *VideoConverter) worker(id int) {
 	defer c.wg.Done()
 	log.Printf("Worker %d started", id)
-	for job := range c.queue {
+	for {
+		job, ok := c.queue.pop()
+		if !ok {
+			break
+		}
+		c.updateQueuePositions()
 		log.Printf("Worker %d: Processing job %s (File: %s)", id, job.ConversionID, filepath.Base(job.UploadedFilePath))
-		c.convertVideo(job)
+		c.processJob(job)
 		log.Printf("Worker %d: Finished job %s", id, job.ConversionID)
 	}
 	log.Printf("Worker %d stopped", id)
 }
 
-// QueueJob adds a job to the conversion queue. Returns error if queue is full.
+// ErrQueueFull is returned by QueueJob when the conversion queue cannot accept more jobs.
+var ErrQueueFull = errors.New("conversion queue is full")
+
+// QueueJob adds a job to the conversion queue. Returns ErrQueueFull if the queue is full.
 func (c *VideoConverter) QueueJob(job models.ConversionJob) error {
-	select {
-	case c.queue <- job:
-		log.Printf("Job %s queued (File: %s)", job.ConversionID, filepath.Base(job.UploadedFilePath))
-		return nil
-	default:
-		// Non-blocking check if queue is full
-		err := fmt.Errorf("conversion queue is full, cannot accept job %s", job.ConversionID)
+	return c.queueJob(job, c.queue.push)
+}
+
+// queueJob tracks a job and adds it to the queue with push.
+func (c *VideoConverter) queueJob(job models.ConversionJob, push func(models.ConversionJob) error) error {
+	c.store.TrackJob(job)
+	if err := push(job); err != nil {
+		err = fmt.Errorf("%w, cannot accept job %s", err, job.ConversionID)
 		log.Printf("ERROR: Failed to queue job %s: %v", job.ConversionID, err)
 		return err
 	}
+	log.Printf("Job %s queued (File: %s, Priority: %s, Client: %s)", job.ConversionID, filepath.Base(job.UploadedFilePath), job.Priority, job.ClientID)
+	c.updateQueuePositions()
+	return nil
+}
+
+// updateQueuePositions records the current queue position and scheduling reason of every pending job.
+func (c *VideoConverter) updateQueuePositions() {
+	c.positionsMu.Lock()
+	defer c.positionsMu.Unlock()
+	c.store.SetQueuePositions(c.queue.slots())
 }
 
 // getVideoDuration uses ffprobe to get the duration of a video file in seconds.
@@ -86,12 +153,7 @@ func getVideoDuration(filePath string) (float64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), constants.FFprobeTimeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "ffprobe",
-		"-v", "error", // Only show errors
-		"-show_entries", "format=duration", // Get duration from format section
-		"-of", "default=noprint_wrappers=1:nokey=1", // Output only the value
-		filePath,
-	)
+	cmd := exec.CommandContext(ctx, "ffprobe", ffprobeDurationArgs(filePath)...)
 
 	outputBytes, err := cmd.Output()
 	if ctx.Err() == context.DeadlineExceeded {
@@ -119,126 +181,216 @@ func getVideoDuration(filePath string) (float64, error) {
 	return duration, nil
 }
 
-// convertVideo performs the actual video conversion using FFmpeg.
-func (c *VideoConverter) convertVideo(job models.ConversionJob) {
-	status := job.Status // Use the status pointer from the job
+// processJob runs a job according to its type.
+func (c *VideoConverter) processJob(job models.ConversionJob) {
+	if !c.prepareJob(job) {
+		return
+	}
+
+	switch job.JobType {
+	case models.JobTypeScenes:
+		c.detectScenes(job)
+	case models.JobTypeSilence:
+		c.removeSilence(job)
+	default:
+		c.convertVideo(job)
+	}
+}
+
+// prepareJob probes the input duration and ensures the output directory exists.
+// It returns false if the job cannot proceed, in which case the status has already been updated.
+func (c *VideoConverter) prepareJob(job models.ConversionJob) bool {
 	inputPath := job.UploadedFilePath
 	outputPath := job.OutputFilePath
 	conversionID := job.ConversionID
 
+	// The job may have been canceled after a worker took it from the queue.
+	if c.abortIfCanceled(job) {
+		return false
+	}
+	c.store.SetPhase(conversionID, models.PhaseProbing, 0)
+
 	// --- Get Video Duration ---
 	duration, durationErr := getVideoDuration(inputPath)
 	if durationErr != nil {
 		// Log warning but continue, progress will be less accurate
 		log.Printf("WARN [job %s]: Could not get video duration: %v. Progress estimation will be inaccurate.", conversionID, durationErr)
-		status.DurationSeconds = 0 // Ensure it's zero if error occurred
-	} else {
-		status.DurationSeconds = duration
+		duration = 0 // Ensure it's zero if error occurred
 	}
-	// Update status in store immediately with duration info
-	c.store.SetStatus(conversionID, status)
+	c.store.SetDuration(conversionID, duration)
 	// --- End Get Video Duration ---
+	if c.abortIfCanceled(job) {
+		return false
+	}
 
 	// Ensure output directory exists
 	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
 		errMsg := fmt.Sprintf("Failed to ensure output directory exists: %v", err)
 		log.Printf("ERROR [job %s]: %s", conversionID, errMsg)
-		c.store.UpdateStatusWithError(conversionID, errMsg)
-		return
+		c.store.UpdateStatusWithError(conversionID, storageErrorCode(err), errMsg)
+		c.removeInputFiles(job)
+		return false
 	}
+	return true
+}
 
-	// Determine optimal thread count (can be adjusted)
-	threadCount := runtime.NumCPU() - constants.ThreadCountReserve
-	if threadCount < constants.MinThreadCount {
-		threadCount = constants.MinThreadCount
-	}
+// convertVideo performs the actual video conversion using FFmpeg.
+func (c *VideoConverter) convertVideo(job models.ConversionJob) {
+	conversionID := job.ConversionID
 
-	// Build FFmpeg arguments
-	ffmpegArgs := []string{
-		"-i", inputPath,
-		"-threads", strconv.Itoa(threadCount),
-		"-progress", "pipe:1", // Send progress info to stdout
-		"-nostats",      // Suppress encoding stats on stderr
-		"-v", "warning", // Log level for FFmpeg messages on stderr
+	passes, err := buildFFmpegPasses(job, resolveJobQuality(job))
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid conversion options: %v", err)
+		log.Printf("ERROR [job %s]: %s", conversionID, errMsg)
+		c.store.UpdateStatusWithError(conversionID, models.ErrorCodeInvalidOptions, errMsg)
+		c.removeInputFiles(job)
+		return
 	}
 
-	selectedPreset := job.VideoPreset
-	selectedCRF := job.VideoCRF
-	if selectedPreset == "" || selectedCRF <= 0 {
-		defaultQuality := ResolveQualitySetting(models.DefaultQualityName)
-		if selectedPreset == "" {
-			selectedPreset = defaultQuality.Preset
+	// Leave room in the progress bar for the CRF search and quality measurement.
+	searchShare, metricsShare := 0.0, 0.0
+	if job.TargetVMAF > 0 {
+		searchShare = constants.CRFSearchProgressShare
+	}
+	if job.Metrics != nil {
+		metricsShare = constants.MetricsProgressShare
+	}
+	encodeScale := (100 - searchShare - metricsShare) / 100
+	c.store.SetPhase(conversionID, models.PhaseEncoding, 100-metricsShare)
+
+	// Run each FFmpeg pass in order, mapping its progress onto its share of the overall job.
+	progressStart := 0.0
+	for i, pass := range passes {
+		// The CRF search runs right before the final encode, after any analysis pass it depends on.
+		if i == len(passes)-1 && job.TargetVMAF > 0 {
+			crf, ok := c.runCRFSearch(job, progressStart, searchShare)
+			if !ok {
+				return
+			}
+			progressStart += searchShare
+			if crf > 0 {
+				job.VideoCRF = crf
+				if pass.args, err = buildFFmpegArgs(job, resolveJobQuality(job)); err != nil {
+					c.failJob(job, models.ErrorCodeInvalidOptions, fmt.Sprintf("Invalid conversion options: %v", err))
+					return
+				}
+			}
 		}
-		if selectedCRF <= 0 {
-			selectedCRF = defaultQuality.CRF
+
+		share := pass.progressShare * encodeScale
+		c.store.SetCurrentStep(conversionID, pass.description)
+		ffmpegErrOutput, err := c.runFFmpeg(conversionID, pass.args, progressRange{start: progressStart, share: share})
+		if err != nil {
+			c.handleFFmpegFailure(job, err, ffmpegErrOutput)
+			return
 		}
+		progressStart += share
 	}
 
-	// Add video filters if requested
-	if job.ReverseVideo {
-		ffmpegArgs = append(ffmpegArgs, "-vf", "reverse")
+	c.finalizeOutput(job)
+}
+
+// finalizeOutput verifies the encoded output of a single-output job, copies metadata,
+// marks the job complete and removes its input files.
+func (c *VideoConverter) finalizeOutput(job models.ConversionJob) {
+	inputPath := job.UploadedFilePath
+	outputPath := job.OutputFilePath
+	conversionID := job.ConversionID
+
+	c.store.SetPhase(conversionID, models.PhaseFinalizing, 100)
+
+	// Verify output file exists and is not empty
+	outputInfo, statErr := os.Stat(outputPath)
+	if statErr != nil {
+		errMsg := fmt.Sprintf("FFmpeg finished but output file error: %v", statErr)
+		log.Printf("ERROR [job %s]: %s", conversionID, errMsg)
+		c.store.UpdateStatusWithError(conversionID, models.ErrorCodeConversionFailed, errMsg)
+		c.removeInputFiles(job)
+		return
+	}
+	if outputInfo.Size() == 0 {
+		errMsg := "FFmpeg finished but output file is empty (0 bytes)"
+		log.Printf("ERROR [job %s]: %s", conversionID, errMsg)
+		c.store.UpdateStatusWithError(conversionID, models.ErrorCodeConversionFailed, errMsg)
+		if removeErr := os.Remove(outputPath); removeErr != nil && !os.IsNotExist(removeErr) { // Clean up empty output
+			log.Printf("WARN [job %s]: Failed to remove empty output file %s: %v", conversionID, outputPath, removeErr)
+		}
+		c.removeInputFiles(job)
+		return
 	}
 
-	// Handle audio options
-	if job.RemoveSound {
-		ffmpegArgs = append(ffmpegArgs, "-an") // No audio
+	// Attempt to copy metadata using exiftool (optional, log warning on failure)
+	c.store.SetCurrentStep(conversionID, "Copy metadata")
+	log.Printf("Attempting metadata copy for job %s using exiftool...", conversionID)
+	exifCmd := exec.Command("exiftool", exiftoolCopyArgs(inputPath, outputPath)...)
+	exifOutput, exifErr := exifCmd.CombinedOutput()
+	if exifErr != nil {
+		// Log warning, don't fail the conversion
+		log.Printf("Warning [job %s]: exiftool failed to copy metadata: %v. Output: %s", conversionID, exifErr, string(exifOutput))
 	} else {
-		if job.ReverseVideo {
-			ffmpegArgs = append(ffmpegArgs, "-af", "areverse") // Reverse audio to match video
-		} else {
-			// Default: copy audio stream without re-encoding if possible
-			ffmpegArgs = append(ffmpegArgs, "-c:a", "copy")
-		}
+		log.Printf("Successfully copied metadata for job %s", conversionID)
+	}
+	if c.abortIfCanceled(job) {
+		return
 	}
 
-	// Add format-specific arguments (consider making these configurable)
-	switch job.TargetFormat {
-	case "mov", "mp4":
-		ffmpegArgs = append(ffmpegArgs,
-			"-tag:v", "hvc1",
-			"-c:v", "libx265",
-			"-preset", selectedPreset,
-			"-crf", strconv.Itoa(selectedCRF),
-			"-movflags", "+faststart",
-		)
-	default:
-		errMsg := fmt.Sprintf("Unsupported target format '%s'", job.TargetFormat)
-		log.Printf("ERROR [job %s]: %s", conversionID, errMsg)
-		c.store.UpdateStatusWithError(conversionID, errMsg)
+	if job.Metrics != nil && !c.measureQuality(job) {
 		return
 	}
 
-	ffmpegArgs = append(ffmpegArgs, outputPath)
+	if job.Destination != nil && !c.uploadOutput(job) {
+		return
+	}
 
-	log.Printf("Executing FFmpeg for job %s: ffmpeg %s", conversionID, strings.Join(ffmpegArgs, " "))
-	cmd := exec.Command("ffmpeg", ffmpegArgs...)
+	// Mark as complete
+	c.store.UpdateStatusOnSuccess(conversionID)
+	log.Printf("Conversion successful for job %s: %s -> %s (%s)",
+		conversionID, filepath.Base(inputPath), filepath.Base(outputPath), utils.FormatBytesToMB(outputInfo.Size()))
 
-	// Register command for potential abort
-	c.store.RegisterActiveCmd(conversionID, cmd)
-	defer c.store.UnregisterActiveCmd(conversionID)
+	// Clean up the original downloaded file *after* successful conversion and metadata copy
+	c.removeInputFiles(job)
+}
+
+// progressRange maps the progress of a single FFmpeg pass onto the overall job progress.
+// The pass covers [start, start+share] percent of the job and processes duration seconds
+// of media; a zero duration means the full input duration recorded in the job status.
+type progressRange struct {
+	start    float64
+	share    float64
+	duration float64
+}
+
+// runFFmpeg executes a single FFmpeg pass for a job and waits for it to finish.
+// Progress reported by FFmpeg is scaled into the given progress range.
+// It returns the captured stderr output, which is useful for error reporting and analysis filters.
+func (c *VideoConverter) runFFmpeg(conversionID string, args []string, progress progressRange) (string, error) {
+	log.Printf("Executing FFmpeg for job %s: ffmpeg %s", conversionID, strings.Join(args, " "))
+	cmd := exec.Command("ffmpeg", args...)
 
 	stderrPipe, err := cmd.StderrPipe()
 	if err != nil {
-		errMsg := fmt.Sprintf("Failed to create stderr pipe: %v", err)
-		log.Printf("ERROR [job %s]: %s", conversionID, errMsg)
-		c.store.UpdateStatusWithError(conversionID, errMsg)
-		return
+		return "", &ffmpegStartError{fmt.Sprintf("Failed to create stderr pipe: %v", err)}
 	}
 	stdoutPipe, err := cmd.StdoutPipe() // For progress
 	if err != nil {
-		errMsg := fmt.Sprintf("Failed to create stdout pipe: %v", err)
-		log.Printf("ERROR [job %s]: %s", conversionID, errMsg)
-		c.store.UpdateStatusWithError(conversionID, errMsg)
-		return
+		return "", &ffmpegStartError{fmt.Sprintf("Failed to create stdout pipe: %v", err)}
 	}
 
 	if err := cmd.Start(); err != nil {
-		errMsg := fmt.Sprintf("Failed to start FFmpeg: %v", err)
-		log.Printf("ERROR [job %s]: %s", conversionID, errMsg)
-		c.store.UpdateStatusWithError(conversionID, errMsg)
-		return
+		return "", &ffmpegStartError{fmt.Sprintf("Failed to start FFmpeg: %v", err)}
 	}
 
+	// Register the started command for potential abort. A job canceled before registration
+	// had no process to stop, so stop it here.
+	c.store.RegisterActiveCmd(conversionID, cmd)
+	defer c.store.UnregisterActiveCmd(conversionID)
+	if c.isCanceled(conversionID) {
+		if err := terminateProcess(cmd); err != nil {
+			log.Printf("WARN [job %s]: Failed to stop FFmpeg process of canceled job: %v", conversionID, err)
+		}
+	}
+	c.pauseIfRequested(conversionID, cmd)
+
 	// Read stderr and stdout concurrently
 	var wg sync.WaitGroup
 	var ffmpegErrOutput strings.Builder
@@ -259,9 +411,7 @@ func (c *VideoConverter) convertVideo(job models.ConversionJob) {
 	// Goroutine to read stdout (FFmpeg progress)
 	go func() {
 		defer wg.Done()
-		// Pass the original status pointer, which includes the duration if found.
-		// processFFmpegProgress needs a pointer to potentially read DurationSeconds.
-		c.processFFmpegProgress(stdoutPipe, conversionID, status)
+		c.processFFmpegProgress(stdoutPipe, conversionID, progress)
 	}()
 
 	// Wait for FFmpeg command to complete
@@ -270,114 +420,162 @@ func (c *VideoConverter) convertVideo(job models.ConversionJob) {
 	// Wait for stderr/stdout reading goroutines to finish
 	wg.Wait()
 
-	// Check FFmpeg exit code *after* reading pipes
-	if err != nil {
-		// Fetch status *once* after command completion to check for abort/errors
-		currentStatus, exists := c.store.GetStatus(conversionID)
-		if !exists {
-			// This is unexpected if the command ran, log a warning.
-			// The job might have been deleted concurrently?
-			log.Printf("WARN [job %s]: Status not found after FFmpeg command finished.", conversionID)
-			// Attempt cleanup anyway, assuming an error occurred.
-			if removeErr := os.Remove(outputPath); removeErr != nil && !os.IsNotExist(removeErr) {
-				log.Printf("WARN [job %s]: Failed to remove potentially incomplete output file %s after status missing: %v", conversionID, outputPath, removeErr)
-			}
-			if removeErr := os.Remove(inputPath); removeErr != nil && !os.IsNotExist(removeErr) {
-				log.Printf("WARN [job %s]: Failed to remove input file %s after status missing: %v", conversionID, inputPath, removeErr)
-			}
-			return // Cannot proceed without status
-		}
+	return ffmpegErrOutput.String(), err
+}
 
-		// Check if the error is due to the process being killed (aborted)
-		isAbortError := currentStatus.Error == "Conversion aborted by user"
-		// Check if the FFmpeg error itself indicates a kill signal (less reliable)
-		isKilledError := strings.Contains(err.Error(), "signal: killed") || strings.Contains(err.Error(), "exit status -1") // OS-dependent
-
-		if !isAbortError && !isKilledError {
-			// Genuine FFmpeg execution error
-			errMsg := fmt.Sprintf("FFmpeg execution failed: %v", err)
-			log.Printf("ERROR [job %s]: %s\nFFmpeg Output:\n%s", conversionID, errMsg, ffmpegErrOutput.String())
-			// Update status only if it wasn't already marked by abort
-			if currentStatus.Error == "" { // Avoid overwriting specific abort message
-				c.store.UpdateStatusWithError(conversionID, errMsg+": "+ffmpegErrOutput.String())
-			}
-		} else if !isAbortError {
-			// If it was killed but not via our specific abort message, log it.
-			// The status might have already been set by the abort handler, or we set a generic one now.
-			log.Printf("WARN [job %s]: FFmpeg process killed unexpectedly: %v", conversionID, err)
-			if currentStatus.Error == "" { // Avoid overwriting specific abort message
-				c.store.UpdateStatusWithError(conversionID, "Conversion process terminated unexpectedly")
-			}
-		}
-		// Cleanup potentially incomplete output file if error occurred (and not aborted cleanly)
-		// We check isAbortError based on the status we fetched.
-		if !isAbortError {
-			if removeErr := os.Remove(outputPath); removeErr != nil && !os.IsNotExist(removeErr) {
-				log.Printf("WARN [job %s]: Failed to remove incomplete output file %s: %v", conversionID, outputPath, removeErr)
-			}
-		}
-		// Input file cleanup happens regardless of error type if conversion failed/aborted
-		if removeErr := os.Remove(inputPath); removeErr != nil && !os.IsNotExist(removeErr) {
-			log.Printf("WARN [job %s]: Failed to remove input file %s after error/abort: %v", conversionID, inputPath, removeErr)
+// buildEncodingStats computes live statistics from a block of FFmpeg -progress values.
+// The ETA and projected size cover the current pass of passDuration seconds of media.
+func buildEncodingStats(block map[string]string, passDuration float64) models.EncodingStats {
+	parseFloat := func(key, suffix string) float64 {
+		value, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(block[key], suffix)), 64)
+		if err != nil || value < 0 {
+			return 0 // Missing or "N/A"
 		}
+		return value
+	}
+
+	stats := models.EncodingStats{
+		FPS:         parseFloat("fps", ""),
+		Speed:       parseFloat("speed", "x"),
+		BitrateKbps: parseFloat("bitrate", "kbits/s"),
+		OutputSize:  int64(parseFloat("total_size", "")),
+	}
+
+	outTimeSec := parseFloat("out_time_us", "") / 1_000_000.0
+	if passDuration <= 0 || outTimeSec <= 0 {
+		return stats
+	}
+	if stats.Speed > 0 {
+		stats.ETASeconds = math.Max(passDuration-outTimeSec, 0) / stats.Speed
+	}
+	if stats.OutputSize > 0 {
+		stats.ProjectedSize = int64(float64(stats.OutputSize) * math.Max(passDuration/outTimeSec, 1))
+	}
+	return stats
+}
+
+// ffmpegStartError indicates FFmpeg could not be launched at all.
+type ffmpegStartError struct {
+	msg string
+}
+
+func (e *ffmpegStartError) Error() string {
+	return e.msg
+}
+
+// handleFFmpegFailure records the outcome of a failed or aborted FFmpeg pass and
+// removes any files the job left behind.
+func (c *VideoConverter) handleFFmpegFailure(job models.ConversionJob, err error, ffmpegErrOutput string) {
+	conversionID := job.ConversionID
+	outputPath := job.OutputFilePath
+
+	var startErr *ffmpegStartError
+	if errors.As(err, &startErr) {
+		log.Printf("ERROR [job %s]: %s", conversionID, startErr.msg)
+		c.store.UpdateStatusWithError(conversionID, models.ErrorCodeInternal, startErr.msg)
+		c.removeInputFiles(job)
 		return
 	}
 
-	// Verify output file exists and is not empty
-	outputInfo, statErr := os.Stat(outputPath)
-	if statErr != nil {
-		errMsg := fmt.Sprintf("FFmpeg finished but output file error: %v", statErr)
-		log.Printf("ERROR [job %s]: %s", conversionID, errMsg)
-		c.store.UpdateStatusWithError(conversionID, errMsg)
-		if removeErr := os.Remove(inputPath); removeErr != nil && !os.IsNotExist(removeErr) {
-			log.Printf("WARN [job %s]: Failed to remove input file %s after output file error: %v", conversionID, inputPath, removeErr)
+	// Fetch status *once* after command completion to check for abort/errors
+	currentStatus, exists := c.store.GetStatus(conversionID)
+	if !exists {
+		// This is unexpected if the command ran, log a warning.
+		// The job might have been deleted concurrently?
+		log.Printf("WARN [job %s]: Status not found after FFmpeg command finished.", conversionID)
+		// Attempt cleanup anyway, assuming an error occurred.
+		if removeErr := os.Remove(outputPath); removeErr != nil && !os.IsNotExist(removeErr) {
+			log.Printf("WARN [job %s]: Failed to remove potentially incomplete output file %s after status missing: %v", conversionID, outputPath, removeErr)
 		}
-		return
+		c.removeInputFiles(job)
+		return // Cannot proceed without status
 	}
-	if outputInfo.Size() == 0 {
-		errMsg := "FFmpeg finished but output file is empty (0 bytes)"
-		log.Printf("ERROR [job %s]: %s", conversionID, errMsg)
-		c.store.UpdateStatusWithError(conversionID, errMsg)
-		if removeErr := os.Remove(outputPath); removeErr != nil && !os.IsNotExist(removeErr) { // Clean up empty output
-			log.Printf("WARN [job %s]: Failed to remove empty output file %s: %v", conversionID, outputPath, removeErr)
+
+	// Check if the error is due to the process being killed (aborted)
+	isAbortError := currentStatus.ErrorCode == models.ErrorCodeAborted
+	// Check if the FFmpeg error itself indicates a kill signal (less reliable)
+	isKilledError := strings.Contains(err.Error(), "signal: killed") || strings.Contains(err.Error(), "exit status -1") // OS-dependent
+
+	if !isAbortError && !isKilledError {
+		// Genuine FFmpeg execution error
+		errMsg := fmt.Sprintf("FFmpeg execution failed: %v", err)
+		log.Printf("ERROR [job %s]: %s\nFFmpeg Output:\n%s", conversionID, errMsg, ffmpegErrOutput)
+		// Update status only if it wasn't already marked by abort
+		if currentStatus.Error == "" { // Avoid overwriting specific abort message
+			c.store.UpdateStatusWithError(conversionID, classifyFFmpegError(ffmpegErrOutput), errMsg+": "+ffmpegErrOutput)
 		}
-		if removeErr := os.Remove(inputPath); removeErr != nil && !os.IsNotExist(removeErr) { // Clean up input
-			log.Printf("WARN [job %s]: Failed to remove input file %s after empty output: %v", conversionID, inputPath, removeErr)
+	} else if !isAbortError {
+		// If it was killed but not via our specific abort message, log it.
+		// The status might have already been set by the abort handler, or we set a generic one now.
+		log.Printf("WARN [job %s]: FFmpeg process killed unexpectedly: %v", conversionID, err)
+		if currentStatus.Error == "" { // Avoid overwriting specific abort message
+			c.store.UpdateStatusWithError(conversionID, models.ErrorCodeConversionFailed, "Conversion process terminated unexpectedly")
 		}
-		return
 	}
+	// Clean up the incomplete output and the input files, whether the job failed or was aborted
+	c.removeOutputFile(job)
+	c.removeInputFiles(job)
+}
 
-	// Attempt to copy metadata using exiftool (optional, log warning on failure)
-	log.Printf("Attempting metadata copy for job %s using exiftool...", conversionID)
-	// -overwrite_original modifies the output file directly
-	// -preserve keeps original file modification time if possible
-	// -P preserves filesystem timestamp
-	exifCmd := exec.Command("exiftool", "-tagsFromFile", inputPath, "-preserve", "-overwrite_original", outputPath)
-	exifOutput, exifErr := exifCmd.CombinedOutput()
-	if exifErr != nil {
-		// Log warning, don't fail the conversion
-		log.Printf("Warning [job %s]: exiftool failed to copy metadata: %v. Output: %s", conversionID, exifErr, string(exifOutput))
-	} else {
-		log.Printf("Successfully copied metadata for job %s", conversionID)
+// ffmpegErrorPatterns maps FFmpeg error output to error codes, checked in order.
+var ffmpegErrorPatterns = []struct {
+	pattern string
+	code    string
+}{
+	{"No space left on device", models.ErrorCodeDiskFull},
+	{"Unknown encoder", models.ErrorCodeUnsupportedCodec},
+	{"Unknown decoder", models.ErrorCodeUnsupportedCodec},
+	{"Decoder (codec", models.ErrorCodeUnsupportedCodec}, // "Decoder (codec x) not found for input stream"
+	{"Encoder (codec", models.ErrorCodeUnsupportedCodec}, // "Encoder (codec x) not found for output stream"
+	{"codec not currently supported", models.ErrorCodeUnsupportedCodec},
+	{"Could not find codec parameters", models.ErrorCodeUnsupportedCodec},
+	{"Invalid data found when processing input", models.ErrorCodeInvalidInput},
+	{"moov atom not found", models.ErrorCodeInvalidInput},
+}
+
+// classifyFFmpegError returns the error code matching a failed FFmpeg run's error output.
+func classifyFFmpegError(ffmpegErrOutput string) string {
+	for _, p := range ffmpegErrorPatterns {
+		if strings.Contains(ffmpegErrOutput, p.pattern) {
+			return p.code
+		}
 	}
+	return models.ErrorCodeConversionFailed
+}
 
-	// Mark as complete
-	c.store.UpdateStatusOnSuccess(conversionID)
-	log.Printf("Conversion successful for job %s: %s -> %s (%s)",
-		conversionID, filepath.Base(inputPath), filepath.Base(outputPath), utils.FormatBytesToMB(outputInfo.Size()))
+// storageErrorCode returns the error code for a failed file system operation.
+func storageErrorCode(err error) string {
+	if utils.IsDiskFull(err) {
+		return models.ErrorCodeDiskFull
+	}
+	return models.ErrorCodeInternal
+}
 
-	// Clean up the original downloaded file *after* successful conversion and metadata copy
-	err = os.Remove(inputPath)
-	if err != nil {
-		log.Printf("Warning [job %s]: Failed to remove original downloaded file %s: %v", conversionID, inputPath, err)
-	} else {
-		log.Printf("Removed original downloaded file for job %s: %s", conversionID, inputPath)
+// removeInputFiles deletes the job's input file along with any intermediate files
+// derived from it (such as stabilization transforms). The input file itself is kept
+// when the source retention policy retains it for a retry or re-run.
+func (c *VideoConverter) removeInputFiles(job models.ConversionJob) {
+	inputPath := job.UploadedFilePath
+	paths := []string{inputPath, TransformsFilePath(inputPath)}
+	if c.retainsSource(job.ConversionID) {
+		log.Printf("Retaining input file for job %s (source retention: %s): %s", job.ConversionID, c.sourceRetention, inputPath)
+		paths = paths[1:]
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil {
+			if !os.IsNotExist(err) {
+				log.Printf("WARN [job %s]: Failed to remove input file %s: %v", job.ConversionID, path, err)
+			}
+			continue
+		}
+		log.Printf("Removed input file for job %s: %s", job.ConversionID, path)
 	}
 }
 
 // processFFmpegProgress parses FFmpeg progress output from stdout.
-// It now uses the duration stored in the status for more accurate calculation.
-func (c *VideoConverter) processFFmpegProgress(stdout io.ReadCloser, conversionID string, status *models.ConversionStatus) {
+// It uses the pass duration (or the duration stored in the job status) for accurate
+// calculation and maps the pass progress onto the given progress range.
+func (c *VideoConverter) processFFmpegProgress(stdout io.ReadCloser, conversionID string, progress progressRange) {
 	defer func() {
 		if err := stdout.Close(); err != nil {
 			log.Printf("WARN [job %s]: Error closing FFmpeg stdout pipe: %v", conversionID, err)
@@ -385,8 +583,16 @@ func (c *VideoConverter) processFFmpegProgress(stdout io.ReadCloser, conversionI
 	}()
 	scanner := bufio.NewScanner(stdout)
 	var lastProgressUpdate time.Time
-	// Check duration directly from the passed status pointer
-	hasDuration := status != nil && status.DurationSeconds > 0
+	passStarted := time.Now()
+	pausedBefore := c.store.pausedTime(conversionID) // Pauses before this pass do not affect its statistics
+	passDuration := progress.duration
+	if passDuration <= 0 {
+		status, _ := c.store.GetStatus(conversionID)
+		passDuration = status.DurationSeconds
+	}
+	hasDuration := passDuration > 0
+	// FFmpeg reports progress in blocks of key=value lines terminated by a "progress" line.
+	block := make(map[string]string)
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -396,15 +602,25 @@ func (c *VideoConverter) processFFmpegProgress(stdout io.ReadCloser, conversionI
 		}
 		key := strings.TrimSpace(parts[0])
 		value := strings.TrimSpace(parts[1])
+		block[key] = value
+
+		if key == "progress" {
+			stats := buildEncodingStats(block, passDuration)
+			excludePausedTime(&stats, time.Since(passStarted), c.store.pausedTime(conversionID)-pausedBefore)
+			c.store.SetEncodingStats(conversionID, stats)
+			block = make(map[string]string)
+		}
 
 		if hasDuration && key == "out_time_us" {
 			outTimeUs, err := strconv.ParseFloat(value, 64)
-			// Ensure status pointer is not nil before accessing DurationSeconds
-			if err == nil && outTimeUs >= 0 && status != nil {
+			if err == nil && outTimeUs >= 0 {
 				outTimeSec := outTimeUs / 1_000_000.0
-				progress := (outTimeSec / status.DurationSeconds) * 100.0
+				passFraction := outTimeSec / passDuration
+				if passFraction > 1 {
+					passFraction = 1
+				}
 				// Update progress using the calculated percentage
-				c.store.SetProgressPercentage(conversionID, progress)
+				c.store.SetProgressPercentage(conversionID, progress.start+passFraction*progress.share)
 				lastProgressUpdate = time.Now() // Update timestamp even for accurate progress
 			}
 		} else if !hasDuration && (key == "out_time_us" || key == "frame") {
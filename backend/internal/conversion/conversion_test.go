@@ -0,0 +1,115 @@
+package conversion
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildEncodingStats(t *testing.T) {
+	t.Run("encode pass", func(t *testing.T) {
+		block := map[string]string{
+			"frame":       "750",
+			"fps":         "48.50",
+			"bitrate":     "2400.5kbits/s",
+			"total_size":  "7500000",
+			"out_time_us": "25000000",
+			"speed":       "2.00x",
+			"progress":    "continue",
+		}
+
+		stats := buildEncodingStats(block, 100)
+
+		assert.Equal(t, 48.5, stats.FPS)
+		assert.Equal(t, 2.0, stats.Speed)
+		assert.Equal(t, 2400.5, stats.BitrateKbps)
+		assert.Equal(t, int64(7500000), stats.OutputSize)
+		assert.Equal(t, 37.5, stats.ETASeconds)
+		assert.Equal(t, int64(30000000), stats.ProjectedSize)
+	})
+
+	t.Run("analysis pass without size", func(t *testing.T) {
+		block := map[string]string{
+			"fps":         "120.0",
+			"bitrate":     "N/A",
+			"total_size":  "N/A",
+			"out_time_us": "10000000",
+			"speed":       "N/A",
+		}
+
+		stats := buildEncodingStats(block, 100)
+
+		assert.Equal(t, 120.0, stats.FPS)
+		assert.Zero(t, stats.Speed)
+		assert.Zero(t, stats.BitrateKbps)
+		assert.Zero(t, stats.ETASeconds)
+		assert.Zero(t, stats.ProjectedSize)
+	})
+}
+
+func TestProcessFFmpegProgress(t *testing.T) {
+	store := NewStore()
+	store.SetStatus("job-1", &models.ConversionStatus{OutputPath: "/converted/out.mp4", DurationSeconds: 100})
+	converter := NewVideoConverter(1, 1, store)
+	events := store.Subscribe()
+	defer store.Unsubscribe(events)
+
+	output := strings.Join([]string{
+		"frame=300",
+		"fps=30.00",
+		"bitrate=1000.0kbits/s",
+		"total_size=5000000",
+		"out_time_us=50000000",
+		"speed=1.25x",
+		"progress=continue",
+	}, "\n")
+	converter.processFFmpegProgress(io.NopCloser(strings.NewReader(output)), "job-1", progressRange{start: 0, share: 100})
+
+	status, exists := store.GetStatus("job-1")
+	require.True(t, exists)
+	require.NotNil(t, status.Stats)
+	assert.Equal(t, 1.25, status.Stats.Speed)
+	assert.Equal(t, 40.0, status.Stats.ETASeconds)
+	assert.Equal(t, int64(10000000), status.Stats.ProjectedSize)
+	assert.InDelta(t, 50.0, status.Progress, 1e-9)
+
+	// The stats are pushed to stream subscribers with the status update.
+	var last StoreEvent
+	for len(events) > 0 {
+		last = <-events
+	}
+	require.NotNil(t, last.Status)
+	require.NotNil(t, last.Status.Stats)
+	assert.Equal(t, 30.0, last.Status.Stats.FPS)
+
+	// Completed conversions no longer report live statistics.
+	store.UpdateStatusOnSuccess("job-1")
+	status, _ = store.GetStatus("job-1")
+	assert.Nil(t, store.BuildStatusResponse("job-1", status).Stats)
+}
+
+func TestClassifyFFmpegError(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"disk full", "av_interleaved_write_frame(): No space left on device", models.ErrorCodeDiskFull},
+		{"missing encoder", "Unknown encoder 'libx265'", models.ErrorCodeUnsupportedCodec},
+		{"missing decoder", "Decoder (codec prores_raw) not found for input stream #0:0", models.ErrorCodeUnsupportedCodec},
+		{"unsupported stream", "Could not find codec parameters for stream 0 (Video: none)", models.ErrorCodeUnsupportedCodec},
+		{"corrupt input", "input.mov: Invalid data found when processing input", models.ErrorCodeInvalidInput},
+		{"truncated mp4", "moov atom not found", models.ErrorCodeInvalidInput},
+		{"other failure", "Error while filtering: Cannot allocate memory", models.ErrorCodeConversionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, classifyFFmpegError(tt.output))
+		})
+	}
+}
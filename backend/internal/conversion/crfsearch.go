@@ -0,0 +1,290 @@
+package conversion
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+// errJobAborted is returned from within multi-step operations when the user aborted the job
+// and the failure has already been recorded.
+var errJobAborted = errors.New("job aborted")
+
+// ValidateCRFSearch checks the target VMAF of a job.
+func ValidateCRFSearch(job models.ConversionJob) error {
+	if job.TargetVMAF == 0 {
+		return nil
+	}
+	if math.IsNaN(job.TargetVMAF) || math.IsInf(job.TargetVMAF, 0) || job.TargetVMAF < 0 || job.TargetVMAF > 100 {
+		return fmt.Errorf("targetVmaf must be between 0 and 100")
+	}
+	if job.JobType != "" && job.JobType != models.JobTypeConvert {
+		return fmt.Errorf("a target VMAF is only supported for convert jobs")
+	}
+	// The search encodes its samples from re-timed frames, which no longer line up with the
+	// frame-indexed transforms of the stabilization analysis.
+	if job.Stabilization != nil {
+		return fmt.Errorf("a target VMAF cannot be combined with stabilization")
+	}
+	return nil
+}
+
+// crfSampleRanges returns the representative windows encoded during the search, spread
+// evenly across the video. Short videos are sampled in full.
+func crfSampleRanges(duration float64) []models.TimeRange {
+	total := constants.CRFSearchSampleCount * constants.CRFSearchSampleSeconds
+	if duration <= total {
+		return []models.TimeRange{{Start: 0, End: duration}}
+	}
+	ranges := make([]models.TimeRange, 0, constants.CRFSearchSampleCount)
+	for i := 1; i <= constants.CRFSearchSampleCount; i++ {
+		center := duration * float64(i) / float64(constants.CRFSearchSampleCount+1)
+		start := center - constants.CRFSearchSampleSeconds/2
+		ranges = append(ranges, models.TimeRange{Start: start, End: start + constants.CRFSearchSampleSeconds})
+	}
+	return ranges
+}
+
+// crfReferencePath returns the lossless reference sample the trials are compared against.
+func crfReferencePath(job models.ConversionJob) string {
+	return job.UploadedFilePath + ".crf-ref.mkv"
+}
+
+// crfTrialPath returns the sample encode of a single CRF trial.
+func crfTrialPath(job models.ConversionJob, crf int) string {
+	return fmt.Sprintf("%s.crf-%d.%s", job.UploadedFilePath, crf, job.TargetFormat)
+}
+
+// buildCRFReferenceArgs builds the lossless encode of the representative samples. The job's
+// filters are applied here, so trials measure compression loss only.
+func buildCRFReferenceArgs(job models.ConversionJob, samples []models.TimeRange) []string {
+	sampleJob := job
+	sampleJob.KeepRanges = samples
+
+	args := []string{
+		"-i", job.UploadedFilePath,
+		"-threads", strconv.Itoa(ffmpegThreadCount()),
+		"-progress", "pipe:1",
+		"-nostats",
+		"-v", "warning",
+	}
+	if filterChain := buildVideoFilterChain(sampleJob); filterChain != "" {
+		args = append(args, "-vf", filterChain)
+	}
+	return append(args,
+		"-an",
+		"-c:v", "libx264", "-preset", "ultrafast", "-qp", "0",
+		"-y", crfReferencePath(job),
+	)
+}
+
+// buildCRFTrialArgs builds the encode of the reference sample at the given CRF.
+func buildCRFTrialArgs(job models.ConversionJob, crf int) ([]string, error) {
+	quality := resolveJobQuality(job)
+	quality.CRF = crf
+	encoderArgs, err := videoEncoderArgs(job.TargetFormat, quality)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{
+		"-i", crfReferencePath(job),
+		"-threads", strconv.Itoa(ffmpegThreadCount()),
+		"-progress", "pipe:1",
+		"-nostats",
+		"-v", "warning",
+		"-an",
+	}
+	args = append(args, encoderArgs...)
+	return append(args, "-y", crfTrialPath(job, crf)), nil
+}
+
+// buildCRFScoreArgs builds the VMAF comparison of a trial against the reference sample.
+func buildCRFScoreArgs(job models.ConversionJob, crf int) []string {
+	scoreJob := models.ConversionJob{
+		UploadedFilePath: crfReferencePath(job),
+		OutputFilePath:   crfTrialPath(job, crf),
+	}
+	return buildMetricsArgs(scoreJob, metricsWindow{}, true)
+}
+
+// searchCRF binary-searches the CRF range for the highest CRF whose VMAF meets the target,
+// assuming quality decreases as CRF increases. Among the trials meeting the target, the one
+// with the lowest bitrate is selected; if none does, the highest-quality trial is used.
+func searchCRF(target float64, evaluate func(crf int) (models.CRFTrial, error)) (models.CRFSearchResult, error) {
+	result := models.CRFSearchResult{TargetVMAF: target}
+	low, high := constants.CRFSearchMinCRF, constants.CRFSearchMaxCRF
+	for low <= high && len(result.Trials) < constants.CRFSearchMaxTrials {
+		crf := (low + high) / 2
+		trial, err := evaluate(crf)
+		if err != nil {
+			return result, err
+		}
+		result.Trials = append(result.Trials, trial)
+		if trial.VMAF >= target {
+			low = crf + 1
+		} else {
+			high = crf - 1
+		}
+	}
+
+	var best *models.CRFTrial
+	for i := range result.Trials {
+		trial := &result.Trials[i]
+		if trial.VMAF < target {
+			continue
+		}
+		if best == nil || trial.BitrateKbps < best.BitrateKbps {
+			best = trial
+		}
+	}
+	if best != nil {
+		result.SelectedCRF = best.CRF
+		result.TargetMet = true
+		return result, nil
+	}
+
+	for i := range result.Trials {
+		if best == nil || result.Trials[i].CRF < best.CRF {
+			best = &result.Trials[i]
+		}
+	}
+	if best != nil {
+		result.SelectedCRF = best.CRF
+	}
+	return result, nil
+}
+
+// crfSearchPlanCommands returns the commands of the CRF search for the execution plan.
+// Trial CRFs depend on earlier scores, so the trial and scoring commands are templates.
+func crfSearchPlanCommands(job models.ConversionJob) ([]models.CommandInvocation, error) {
+	firstCRF := (constants.CRFSearchMinCRF + constants.CRFSearchMaxCRF) / 2
+	trialArgs, err := buildCRFTrialArgs(job, firstCRF)
+	if err != nil {
+		return nil, err
+	}
+	return []models.CommandInvocation{
+		{
+			Program:     "ffmpeg",
+			Args:        buildCRFReferenceArgs(job, crfSampleRanges(constants.CRFSearchSampleCount*constants.CRFSearchSampleSeconds)),
+			Description: "Encode lossless reference samples for the CRF search (windows spread across the video once its duration is known)",
+		},
+		{
+			Program:     "ffmpeg",
+			Args:        trialArgs,
+			Description: fmt.Sprintf("Encode samples at a trial CRF (repeated up to %d times)", constants.CRFSearchMaxTrials),
+		},
+		{
+			Program:     "ffmpeg",
+			Args:        buildCRFScoreArgs(job, firstCRF),
+			Description: fmt.Sprintf("Score the trial against the target VMAF %g", job.TargetVMAF),
+		},
+	}, nil
+}
+
+// runCRFSearch runs the per-title CRF search for a job within the given progress range and
+// returns the selected CRF. A failed search is recorded in the job status and returns 0 so the
+// preset CRF is used. It returns false only when the job was aborted during the search.
+func (c *VideoConverter) runCRFSearch(job models.ConversionJob, progressStart, progressShare float64) (int, bool) {
+	conversionID := job.ConversionID
+	status, _ := c.store.GetStatus(conversionID)
+	if status.DurationSeconds <= 0 {
+		log.Printf("WARN [job %s]: Skipping CRF search, video duration unknown; using preset CRF %d", conversionID, job.VideoCRF)
+		c.store.SetCRFSearch(conversionID, models.CRFSearchResult{TargetVMAF: job.TargetVMAF, Error: "video duration unknown, no samples to search"})
+		return 0, true
+	}
+	samples := crfSampleRanges(status.DurationSeconds)
+	sampleSeconds := 0.0
+	for _, sample := range samples {
+		sampleSeconds += sample.End - sample.Start
+	}
+
+	triedCRFs := []int{}
+	defer func() {
+		paths := []string{crfReferencePath(job)}
+		for _, crf := range triedCRFs {
+			paths = append(paths, crfTrialPath(job, crf))
+		}
+		for _, path := range paths {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				log.Printf("WARN [job %s]: Failed to remove CRF search sample %s: %v", conversionID, path, err)
+			}
+		}
+	}()
+
+	// The reference and each trial's encode and scoring get an equal slice of the range.
+	stepShare := progressShare / float64(1+2*constants.CRFSearchMaxTrials)
+	step := 0
+	run := func(description string, args []string) (string, error) {
+		c.store.SetCurrentStep(conversionID, description)
+		output, err := c.runFFmpeg(conversionID, args, progressRange{start: progressStart + stepShare*float64(step), share: stepShare, duration: sampleSeconds})
+		step++
+		if err != nil {
+			if c.isCanceled(conversionID) {
+				c.handleFFmpegFailure(job, err, output)
+				return output, errJobAborted
+			}
+			return output, fmt.Errorf("%s failed: %v", description, err)
+		}
+		return output, nil
+	}
+
+	partial := models.CRFSearchResult{TargetVMAF: job.TargetVMAF, SampleSeconds: sampleSeconds}
+	evaluate := func(crf int) (models.CRFTrial, error) {
+		trialArgs, err := buildCRFTrialArgs(job, crf)
+		if err != nil {
+			return models.CRFTrial{}, err
+		}
+		triedCRFs = append(triedCRFs, crf)
+		if _, err := run(fmt.Sprintf("Encode CRF %d sample", crf), trialArgs); err != nil {
+			return models.CRFTrial{}, err
+		}
+		output, err := run(fmt.Sprintf("Score CRF %d sample", crf), buildCRFScoreArgs(job, crf))
+		if err != nil {
+			return models.CRFTrial{}, err
+		}
+		vmaf, _, _ := parseQualityScores(output)
+		if vmaf == nil {
+			return models.CRFTrial{}, fmt.Errorf("VMAF score unavailable (FFmpeg may be built without libvmaf)")
+		}
+
+		trial := models.CRFTrial{CRF: crf, VMAF: *vmaf}
+		if info, err := os.Stat(crfTrialPath(job, crf)); err == nil {
+			trial.SizeBytes = info.Size()
+			if sampleSeconds > 0 {
+				trial.BitrateKbps = float64(info.Size()) * 8 / 1000 / sampleSeconds
+			}
+		}
+		log.Printf("Job %s: CRF %d sample scored VMAF %.2f at %.0f kbps", conversionID, crf, trial.VMAF, trial.BitrateKbps)
+
+		partial.Trials = append(partial.Trials, trial)
+		c.store.SetCRFSearch(conversionID, partial)
+		return trial, nil
+	}
+
+	var result models.CRFSearchResult
+	_, err := run("Encode CRF search reference samples", buildCRFReferenceArgs(job, samples))
+	if err == nil {
+		result, err = searchCRF(job.TargetVMAF, evaluate)
+	}
+	if errors.Is(err, errJobAborted) {
+		return 0, false
+	}
+	if err != nil {
+		log.Printf("WARN [job %s]: CRF search failed, using preset CRF %d: %v", conversionID, job.VideoCRF, err)
+		partial.Error = err.Error()
+		c.store.SetCRFSearch(conversionID, partial)
+		return 0, true
+	}
+
+	result.SampleSeconds = sampleSeconds
+	c.store.SetCRFSearch(conversionID, result)
+	log.Printf("Job %s: CRF search selected CRF %d (target VMAF %g met: %t)", conversionID, result.SelectedCRF, job.TargetVMAF, result.TargetMet)
+	return result.SelectedCRF, true
+}
@@ -0,0 +1,99 @@
+package conversion
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTrial models VMAF and bitrate both falling linearly as CRF increases.
+func fakeTrial(crf int) models.CRFTrial {
+	return models.CRFTrial{CRF: crf, VMAF: 120 - float64(crf)*1.5, BitrateKbps: 10000 / float64(crf)}
+}
+
+func TestSearchCRF(t *testing.T) {
+	t.Run("selects highest CRF meeting target", func(t *testing.T) {
+		result, err := searchCRF(93, func(crf int) (models.CRFTrial, error) { return fakeTrial(crf), nil })
+		require.NoError(t, err)
+
+		// VMAF >= 93 holds up to CRF 18.
+		assert.True(t, result.TargetMet)
+		assert.Equal(t, 18, result.SelectedCRF)
+		assert.LessOrEqual(t, len(result.Trials), constants.CRFSearchMaxTrials)
+	})
+
+	t.Run("falls back to best quality trial", func(t *testing.T) {
+		result, err := searchCRF(99.5, func(crf int) (models.CRFTrial, error) { return fakeTrial(crf), nil })
+		require.NoError(t, err)
+
+		assert.False(t, result.TargetMet)
+		assert.Equal(t, constants.CRFSearchMinCRF, result.SelectedCRF)
+	})
+
+	t.Run("propagates evaluation errors", func(t *testing.T) {
+		_, err := searchCRF(93, func(crf int) (models.CRFTrial, error) { return models.CRFTrial{}, errors.New("no libvmaf") })
+		assert.Error(t, err)
+	})
+}
+
+func TestCRFSampleRanges(t *testing.T) {
+	assert.Equal(t, []models.TimeRange{{Start: 0, End: 8}}, crfSampleRanges(8))
+
+	ranges := crfSampleRanges(100)
+	require.Len(t, ranges, constants.CRFSearchSampleCount)
+	assert.Equal(t, models.TimeRange{Start: 23, End: 27}, ranges[0])
+	assert.Equal(t, models.TimeRange{Start: 73, End: 77}, ranges[2])
+}
+
+func TestValidateCRFSearch(t *testing.T) {
+	assert.NoError(t, ValidateCRFSearch(models.ConversionJob{}))
+	assert.NoError(t, ValidateCRFSearch(models.ConversionJob{TargetVMAF: 93}))
+	assert.Error(t, ValidateCRFSearch(models.ConversionJob{TargetVMAF: 120}))
+	assert.Error(t, ValidateCRFSearch(models.ConversionJob{TargetVMAF: math.NaN()}))
+	assert.Error(t, ValidateCRFSearch(models.ConversionJob{TargetVMAF: math.Inf(1)}))
+	assert.Error(t, ValidateCRFSearch(models.ConversionJob{TargetVMAF: 93, JobType: models.JobTypeSilence}))
+	assert.Error(t, ValidateCRFSearch(models.ConversionJob{TargetVMAF: 93, Stabilization: &models.StabilizationOptions{}}))
+	assert.NoError(t, ValidateCRFSearch(models.ConversionJob{Stabilization: &models.StabilizationOptions{}}))
+}
+
+func TestRunCRFSearch_UnknownDuration(t *testing.T) {
+	store := NewStore()
+	converter := NewVideoConverter(1, 10, store)
+	job := newDriveTestJob(t, store, "job")
+	job.TargetVMAF = 93
+
+	crf, ok := converter.runCRFSearch(job, 0, 30)
+
+	assert.True(t, ok)
+	assert.Zero(t, crf, "the preset CRF is kept")
+	status, _ := store.GetStatus("job")
+	require.NotNil(t, status.CRFSearch)
+	assert.Contains(t, status.CRFSearch.Error, "duration unknown")
+	assert.Empty(t, status.CRFSearch.Trials)
+}
+
+func TestBuildPlan_TargetVMAF(t *testing.T) {
+	job := models.ConversionJob{
+		TargetFormat:     "mp4",
+		UploadedFilePath: "/uploads/in.mov",
+		OutputFilePath:   "/converted/out.mp4",
+		TargetVMAF:       93,
+		Filters:          &models.FilterOptions{MaxHeight: 720},
+	}
+
+	plan, err := BuildPlan(job)
+	require.NoError(t, err)
+	require.Len(t, plan.Commands, 6)
+
+	reference := plan.Commands[1]
+	assert.Contains(t, reference.Args, "/uploads/in.mov.crf-ref.mkv")
+	assert.Contains(t, reference.Args, "select='between(t,0.000,12.000)',setpts=N/FRAME_RATE/TB,scale=-2:'min(720,ih)'")
+	assert.Contains(t, plan.Commands[2].Args, "/uploads/in.mov.crf-28.mp4")
+	assert.Contains(t, plan.Commands[3].Description, "93")
+	assert.Equal(t, "Encode video", plan.Commands[4].Description)
+}
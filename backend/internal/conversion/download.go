@@ -0,0 +1,141 @@
+package conversion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/gatanasi/video-converter/internal/drive"
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/gatanasi/video-converter/internal/utils"
+)
+
+// DriveDownloader downloads a Google Drive file to destinationPath, resuming a partial
+// file already there, and reports progress as it goes.
+type DriveDownloader func(ctx context.Context, fileID, destinationPath string, progress func(written, total int64)) error
+
+// errDownloadsDisabled is the download error of Drive jobs when no downloader is set.
+var errDownloadsDisabled = errors.New("google drive downloads are not configured")
+
+// downloadFailedMessage is the error recorded on jobs whose Drive download failed.
+const downloadFailedMessage = "Failed to download file from Google Drive"
+
+// SetDriveDownloader sets the function used to download the sources of Drive jobs.
+// It must be called before Drive jobs are submitted or restored.
+func (c *VideoConverter) SetDriveDownloader(downloader DriveDownloader) {
+	c.downloader = downloader
+}
+
+// SubmitDriveJob accepts a job whose source must first be downloaded from Google Drive.
+// The download runs in the background as the job's downloading phase, at most
+// MaxConcurrentDownloads at a time, and the job is queued for conversion once its
+// source has arrived. A failed download fails the job; a canceled one stops it.
+// The job holds a queue slot while downloading, so it returns ErrQueueFull up front
+// when the queue is full.
+func (c *VideoConverter) SubmitDriveJob(job models.ConversionJob) error {
+	return c.SubmitDriveJobs([]models.ConversionJob{job})
+}
+
+// SubmitDriveJobs submits several Drive jobs as SubmitDriveJob does. It returns
+// ErrQueueFull, submitting none of them, when the queue cannot hold them all.
+func (c *VideoConverter) SubmitDriveJobs(jobs []models.ConversionJob) error {
+	if err := c.queue.reserve(len(jobs)); err != nil {
+		err = fmt.Errorf("%w, cannot accept %d Drive jobs", err, len(jobs))
+		log.Printf("ERROR: %v", err)
+		return err
+	}
+	for _, job := range jobs {
+		c.startDownload(job)
+	}
+	return nil
+}
+
+// startDownload starts downloading the source of a Drive job holding a reserved queue slot.
+func (c *VideoConverter) startDownload(job models.ConversionJob) {
+	job.DownloadPending = true
+	c.store.TrackJob(job) // Persist the options so a restart can resume the download
+	c.store.SetPhase(job.ConversionID, models.PhaseDownloading, 0)
+	c.store.SetCurrentStep(job.ConversionID, "Waiting to download from Google Drive")
+	go c.downloadSource(job)
+}
+
+// downloadSource downloads the source of a Drive job and queues the job in its reserved
+// slot, or gives the slot back if the job does not get that far.
+func (c *VideoConverter) downloadSource(job models.ConversionJob) {
+	id := job.ConversionID
+	queued := false
+	defer func() {
+		if !queued {
+			c.queue.release()
+		}
+	}()
+	c.downloadSlots <- struct{}{}
+	defer func() { <-c.downloadSlots }()
+
+	if status, exists := c.store.GetStatus(id); !exists || status.Complete {
+		c.removePartialDownload(job) // Canceled while waiting for a download slot
+		return
+	}
+	c.store.SetCurrentStep(id, "Downloading from Google Drive")
+
+	downloader := c.downloader
+	if downloader == nil {
+		downloader = func(context.Context, string, string, func(int64, int64)) error { return errDownloadsDisabled }
+	}
+	ctx, release := c.store.JobContext(id)
+	err := downloader(ctx, job.FileID, job.UploadedFilePath, func(written, total int64) {
+		c.store.SetDownloadProgress(id, written, total)
+	})
+	canceled := ctx.Err() != nil
+	release()
+	if canceled {
+		log.Printf("INFO [job %s]: Download canceled by user", id)
+		c.removePartialDownload(job)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR [job %s]: Failed to download file from Google Drive: %v", id, err)
+		c.store.UpdateStatusWithError(id, DriveErrorCode(err), downloadFailedMessage)
+		c.removePartialDownload(job)
+		return
+	}
+	log.Printf("Download complete for job %s", id)
+
+	job.DownloadPending = false
+	c.store.SetPhase(id, models.PhaseQueued, 0)
+	queued = true // pushReserved gives the slot back even when it fails
+	if err := c.queueJob(job, c.queue.pushReserved); err != nil {
+		c.store.UpdateStatusWithError(id, models.ErrorCodeQueueFull, "Server busy, conversion queue is full")
+		c.removeInputFiles(job)
+	}
+}
+
+// removePartialDownload removes what was downloaded of a job's source. Unlike
+// removeInputFiles it ignores the source retention policy, since an incomplete
+// source cannot be retried.
+func (c *VideoConverter) removePartialDownload(job models.ConversionJob) {
+	if err := os.Remove(job.UploadedFilePath); err != nil && !os.IsNotExist(err) {
+		log.Printf("WARN [job %s]: Failed to remove partial download %s: %v", job.ConversionID, job.UploadedFilePath, err)
+	}
+}
+
+// DriveErrorCode returns the error code describing a failed Google Drive request. It is the
+// only mapping of drive package errors, shared by failed downloads and API responses.
+func DriveErrorCode(err error) string {
+	switch {
+	case errors.Is(err, drive.ErrNotFound):
+		return models.ErrorCodeDriveNotFound
+	case errors.Is(err, drive.ErrQuotaExceeded):
+		return models.ErrorCodeDriveQuota
+	case errors.Is(err, drive.ErrUnauthorized):
+		return models.ErrorCodeDriveAuth
+	case errors.Is(err, drive.ErrFileTooLarge):
+		return models.ErrorCodeFileTooLarge
+	case utils.IsDiskFull(err):
+		return models.ErrorCodeDiskFull
+	default:
+		return models.ErrorCodeDriveError
+	}
+}
@@ -0,0 +1,176 @@
+package conversion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gatanasi/video-converter/internal/drive"
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDriveTestJob registers a Drive job whose source is downloaded to a temporary directory.
+func newDriveTestJob(t *testing.T, store *Store, id string) models.ConversionJob {
+	t.Helper()
+	status := newQueuedStatus()
+	store.SetStatus(id, status)
+	return models.ConversionJob{ConversionID: id, FileID: "drive-" + id, UploadedFilePath: filepath.Join(t.TempDir(), id+".mov"), Status: status}
+}
+
+// waitForPhase waits until a job reaches a phase and returns its status.
+func waitForPhase(t *testing.T, store *Store, id, phase string) models.ConversionStatus {
+	t.Helper()
+	var status models.ConversionStatus
+	require.Eventually(t, func() bool {
+		status, _ = store.GetStatus(id)
+		return status.Phase == phase
+	}, 5*time.Second, 5*time.Millisecond)
+	return status
+}
+
+func TestSubmitDriveJob(t *testing.T) {
+	t.Run("queues the job once its source is downloaded", func(t *testing.T) {
+		store := NewStore()
+		converter := NewVideoConverter(1, 10, store)
+		converter.SetDriveDownloader(func(ctx context.Context, fileID, dest string, progress func(int64, int64)) error {
+			progress(5, 10)
+			return os.WriteFile(dest, []byte("video"), 0o644)
+		})
+		job := newDriveTestJob(t, store, "job")
+
+		require.NoError(t, converter.SubmitDriveJob(job))
+
+		status := waitForPhase(t, store, "job", models.PhaseQueued)
+		require.NotNil(t, status.Download)
+		assert.Equal(t, int64(5), status.Download.BytesDownloaded)
+		assert.Equal(t, []string{"job"}, converter.queue.ids())
+		queued, _ := store.GetJob("job")
+		assert.False(t, queued.DownloadPending)
+	})
+
+	t.Run("failed download fails the job", func(t *testing.T) {
+		store := NewStore()
+		converter := NewVideoConverter(1, 10, store)
+		converter.SetDriveDownloader(func(ctx context.Context, fileID, dest string, progress func(int64, int64)) error {
+			require.NoError(t, os.WriteFile(dest, []byte("part"), 0o644))
+			return drive.ErrQuotaExceeded
+		})
+		job := newDriveTestJob(t, store, "job")
+
+		require.NoError(t, converter.SubmitDriveJob(job))
+
+		status := waitForPhase(t, store, "job", models.PhaseFailed)
+		assert.Equal(t, models.ErrorCodeDriveQuota, status.ErrorCode)
+		assert.NoFileExists(t, job.UploadedFilePath, "the partial download is removed")
+		assert.Empty(t, converter.queue.ids())
+	})
+
+	t.Run("canceling stops the download", func(t *testing.T) {
+		store := NewStore()
+		converter := NewVideoConverter(1, 10, store)
+		started := make(chan struct{})
+		converter.SetDriveDownloader(func(ctx context.Context, fileID, dest string, progress func(int64, int64)) error {
+			require.NoError(t, os.WriteFile(dest, []byte("part"), 0o644))
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		job := newDriveTestJob(t, store, "job")
+
+		require.NoError(t, converter.SubmitDriveJob(job))
+		<-started
+		require.NoError(t, converter.CancelJob("job"))
+
+		status := waitForPhase(t, store, "job", models.PhaseCanceled)
+		assert.True(t, status.Complete)
+		assert.Eventually(t, func() bool {
+			_, err := os.Stat(job.UploadedFilePath)
+			return os.IsNotExist(err)
+		}, 5*time.Second, 5*time.Millisecond, "the partial download is removed")
+		assert.Empty(t, converter.queue.ids())
+	})
+}
+
+func TestSubmitDriveJobs_QueueLimit(t *testing.T) {
+	store := NewStore()
+	converter := NewVideoConverter(1, 2, store)
+	release := make(chan struct{})
+	converter.SetDriveDownloader(func(ctx context.Context, fileID, dest string, progress func(int64, int64)) error {
+		<-release
+		return drive.ErrNotFound
+	})
+	jobs := []models.ConversionJob{newDriveTestJob(t, store, "a"), newDriveTestJob(t, store, "b")}
+
+	require.NoError(t, converter.SubmitDriveJobs(jobs))
+	assert.ErrorIs(t, converter.SubmitDriveJob(newDriveTestJob(t, store, "c")), ErrQueueFull, "downloading jobs hold their queue slot")
+	assert.ErrorIs(t, converter.QueueJob(newDriveTestJob(t, store, "d")), ErrQueueFull)
+
+	close(release)
+	waitForPhase(t, store, "a", models.PhaseFailed)
+	waitForPhase(t, store, "b", models.PhaseFailed)
+	assert.NoError(t, converter.SubmitDriveJobs(jobs[:1]), "failed downloads give their slot back")
+}
+
+func TestRestoreJobs_ResumesDownloads(t *testing.T) {
+	record := testRecord("downloading", time.Now())
+	record.Job.UploadedFilePath = filepath.Join(t.TempDir(), "downloading.mov")
+	record.Job.FileID = "drive-file"
+	record.Job.DownloadPending = true
+	record.Status.Phase = models.PhaseDownloading
+	require.NoError(t, os.WriteFile(record.Job.UploadedFilePath, []byte("part"), 0o644))
+
+	repo := NewMemoryRepository()
+	require.NoError(t, repo.Save(record))
+	store := NewStoreWithRepository(repo)
+	converter := NewVideoConverter(1, 10, store)
+	resumedFrom := make(chan string, 1)
+	converter.SetDriveDownloader(func(ctx context.Context, fileID, dest string, progress func(int64, int64)) error {
+		data, err := os.ReadFile(dest)
+		resumedFrom <- string(data)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(dest, []byte("partial video"), 0o644)
+	})
+
+	converter.Start()
+	defer converter.Stop()
+	require.NoError(t, converter.RestoreJobs())
+
+	assert.Equal(t, "part", <-resumedFrom, "the partial download is kept")
+	// A worker picks the job up after the download; the source is not a real video, so it fails.
+	status := waitForPhase(t, store, "downloading", models.PhaseFailed)
+	phases := make([]string, len(status.Phases))
+	for i, phase := range status.Phases {
+		phases[i] = phase.Phase
+	}
+	assert.Contains(t, phases, models.PhaseProbing, "the job ran on a worker")
+}
+
+func TestDriveErrorCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode string
+	}{
+		{"not found", fmt.Errorf("listing: %w", drive.ErrNotFound), models.ErrorCodeDriveNotFound},
+		{"quota", drive.ErrQuotaExceeded, models.ErrorCodeDriveQuota},
+		{"unauthorized", fmt.Errorf("token: %w", drive.ErrUnauthorized), models.ErrorCodeDriveAuth},
+		{"too large", fmt.Errorf("%w: 3 GB", drive.ErrFileTooLarge), models.ErrorCodeFileTooLarge},
+		{"disk full", &os.PathError{Op: "write", Path: "upload.mov", Err: syscall.ENOSPC}, models.ErrorCodeDiskFull},
+		{"other", errors.New("connection reset"), models.ErrorCodeDriveError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantCode, DriveErrorCode(tt.err))
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package conversion
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+// Supported denoise filters.
+const (
+	DenoiseHQDN3D  = "hqdn3d"
+	DenoiseNLMeans = "nlmeans"
+)
+
+// ValidateFilters checks that image-processing filter options are within supported ranges.
+// A nil value means no filters were requested and is always valid.
+func ValidateFilters(opts *models.FilterOptions) error {
+	if opts == nil {
+		return nil
+	}
+	if opts.MaxHeight < 0 || (opts.MaxHeight > 0 && opts.MaxHeight < constants.FilterMinHeight) {
+		return fmt.Errorf("maxHeight must be at least %d pixels", constants.FilterMinHeight)
+	}
+	switch opts.Rotate {
+	case 0, 90, 180, 270:
+	default:
+		return fmt.Errorf("rotate must be one of 0, 90, 180 or 270 degrees")
+	}
+	switch opts.Denoise {
+	case "", DenoiseHQDN3D, DenoiseNLMeans:
+	default:
+		return fmt.Errorf("unsupported denoise filter '%s'", opts.Denoise)
+	}
+	if opts.DenoiseStrength < 0 || opts.DenoiseStrength > constants.FilterMaxDenoiseStrength {
+		return fmt.Errorf("denoiseStrength must be between 0 and %g", constants.FilterMaxDenoiseStrength)
+	}
+	if opts.Sharpen < 0 || opts.Sharpen > constants.FilterMaxSharpen {
+		return fmt.Errorf("sharpen must be between 0 and %g", constants.FilterMaxSharpen)
+	}
+	if err := validateRange("brightness", opts.Brightness, -1, 1); err != nil {
+		return err
+	}
+	if err := validateRange("contrast", opts.Contrast, 0, 3); err != nil {
+		return err
+	}
+	if err := validateRange("saturation", opts.Saturation, 0, 3); err != nil {
+		return err
+	}
+	if err := validateRange("gamma", opts.Gamma, 0.1, 10); err != nil {
+		return err
+	}
+	if opts.LUT != "" && !IsLUTFileName(opts.LUT) {
+		return fmt.Errorf("lut must be the name of a .cube file")
+	}
+	return nil
+}
+
+// IsLUTFileName reports whether name is a plain .cube filename without any directory components.
+func IsLUTFileName(name string) bool {
+	return name != "" &&
+		filepath.Base(name) == name &&
+		!strings.ContainsAny(name, `/\`) &&
+		strings.EqualFold(filepath.Ext(name), ".cube")
+}
+
+func validateRange(name string, value *float64, minValue, maxValue float64) error {
+	if value != nil && (*value < minValue || *value > maxValue) {
+		return fmt.Errorf("%s must be between %g and %g", name, minValue, maxValue)
+	}
+	return nil
+}
+
+// buildVideoFilterChain assembles every video filter requested by a job into a single
+// comma-separated filtergraph suitable for -vf, in a fixed, well-defined order:
+// cuts of the kept ranges, stabilization, denoise, LUT, color adjustment, rotation, scaling,
+// sharpening and finally reverse.
+// It returns an empty string when no filters are needed.
+func buildVideoFilterChain(job models.ConversionJob) string {
+	var filters []string
+
+	// Cuts come first so the remaining filters only process kept frames.
+	if sel := keepRangesExpression(job.KeepRanges); sel != "" {
+		filters = append(filters, fmt.Sprintf("select='%s'", sel), "setpts=N/FRAME_RATE/TB")
+	}
+
+	if job.Stabilization != nil {
+		filters = append(filters, stabilizationTransformFilters(job)...)
+	}
+
+	if opts := job.Filters; opts != nil {
+		if denoise := denoiseFilter(opts); denoise != "" {
+			filters = append(filters, denoise)
+		}
+		if job.LUTPath != "" {
+			filters = append(filters, "lut3d=file="+escapeFilterValue(job.LUTPath))
+		}
+		if eq := eqFilter(opts); eq != "" {
+			filters = append(filters, eq)
+		}
+		filters = append(filters, rotationFilters(opts.Rotate)...)
+		if opts.MaxHeight > 0 {
+			// -2 keeps the aspect ratio with an even width; never upscale.
+			filters = append(filters, fmt.Sprintf("scale=-2:'min(%d,ih)'", opts.MaxHeight))
+		}
+		if opts.Sharpen > 0 {
+			filters = append(filters, "unsharp=5:5:"+formatFilterFloat(opts.Sharpen))
+		}
+	}
+
+	if job.ReverseVideo {
+		filters = append(filters, "reverse")
+	}
+
+	return strings.Join(filters, ",")
+}
+
+// buildAudioFilterChain returns the audio filtergraph for a job, mirroring the cuts
+// and reversal applied to the video so both streams stay in sync.
+func buildAudioFilterChain(job models.ConversionJob) string {
+	var filters []string
+	if sel := keepRangesExpression(job.KeepRanges); sel != "" {
+		filters = append(filters, fmt.Sprintf("aselect='%s'", sel), "asetpts=N/SR/TB")
+	}
+	if job.ReverseVideo {
+		filters = append(filters, "areverse")
+	}
+	return strings.Join(filters, ",")
+}
+
+// keepRangesExpression returns a select expression matching frames within the given ranges.
+func keepRangesExpression(ranges []models.TimeRange) string {
+	terms := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		terms = append(terms, fmt.Sprintf("between(t,%s,%s)", formatSeconds(r.Start), formatSeconds(r.End)))
+	}
+	return strings.Join(terms, "+")
+}
+
+// denoiseFilter returns the configured denoise filter, scaling the generic strength
+// into the parameters of the selected implementation.
+func denoiseFilter(opts *models.FilterOptions) string {
+	strength := opts.DenoiseStrength
+	if strength == 0 {
+		strength = constants.FilterDefaultDenoiseStrength
+	}
+	switch opts.Denoise {
+	case DenoiseHQDN3D:
+		// luma_spatial:chroma_spatial:luma_tmp:chroma_tmp
+		return fmt.Sprintf("hqdn3d=%s:%s:%s:%s",
+			formatFilterFloat(strength),
+			formatFilterFloat(strength*0.75),
+			formatFilterFloat(strength*1.5),
+			formatFilterFloat(strength*1.125),
+		)
+	case DenoiseNLMeans:
+		return "nlmeans=s=" + formatFilterFloat(strength)
+	default:
+		return ""
+	}
+}
+
+// eqFilter returns an eq filter for the requested color adjustments, or an empty string.
+func eqFilter(opts *models.FilterOptions) string {
+	var params []string
+	for _, param := range []struct {
+		name  string
+		value *float64
+	}{
+		{"brightness", opts.Brightness},
+		{"contrast", opts.Contrast},
+		{"saturation", opts.Saturation},
+		{"gamma", opts.Gamma},
+	} {
+		if param.value != nil {
+			params = append(params, param.name+"="+formatFilterFloat(*param.value))
+		}
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return "eq=" + strings.Join(params, ":")
+}
+
+// rotationFilters returns the transpose filters for a clockwise rotation in degrees.
+func rotationFilters(degrees int) []string {
+	switch degrees {
+	case 90:
+		return []string{"transpose=clock"}
+	case 180:
+		return []string{"hflip", "vflip"}
+	case 270:
+		return []string{"transpose=cclock"}
+	default:
+		return nil
+	}
+}
+
+func formatFilterFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,90 @@
+package conversion
+
+import (
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestBuildVideoFilterChain(t *testing.T) {
+	tests := []struct {
+		name     string
+		job      models.ConversionJob
+		expected string
+	}{
+		{"No Filters", models.ConversionJob{}, ""},
+		{"Reverse Only", models.ConversionJob{ReverseVideo: true}, "reverse"},
+		{
+			"Scale And Rotate",
+			models.ConversionJob{Filters: &models.FilterOptions{MaxHeight: 720, Rotate: 90}},
+			"transpose=clock,scale=-2:'min(720,ih)'",
+		},
+		{
+			"Denoise Default Strength",
+			models.ConversionJob{Filters: &models.FilterOptions{Denoise: DenoiseHQDN3D}},
+			"hqdn3d=4:3:6:4.5",
+		},
+		{
+			"Full Chain Order",
+			models.ConversionJob{
+				UploadedFilePath: "/uploads/in.mov",
+				ReverseVideo:     true,
+				Stabilization:    &models.StabilizationOptions{},
+				LUTPath:          "/data/luts/slog3.cube",
+				Filters: &models.FilterOptions{
+					Denoise:         DenoiseNLMeans,
+					DenoiseStrength: 2,
+					Contrast:        floatPtr(1.1),
+					Brightness:      floatPtr(0),
+					Rotate:          180,
+					MaxHeight:       1080,
+					Sharpen:         0.5,
+					LUT:             "slog3.cube",
+				},
+			},
+			"vidstabtransform=input=/uploads/in.mov.trf:smoothing=10:zoom=0,unsharp=5:5:0.8:3:3:0.4," +
+				"nlmeans=s=2,lut3d=file=/data/luts/slog3.cube,eq=brightness=0:contrast=1.1," +
+				"hflip,vflip,scale=-2:'min(1080,ih)',unsharp=5:5:0.5,reverse",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, buildVideoFilterChain(tt.job))
+		})
+	}
+}
+
+func TestValidateFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *models.FilterOptions
+		wantErr bool
+	}{
+		{"Nil", nil, false},
+		{"Valid", &models.FilterOptions{MaxHeight: 720, Rotate: 270, Denoise: DenoiseHQDN3D, Gamma: floatPtr(1.2), LUT: "look.CUBE"}, false},
+		{"Tiny Height", &models.FilterOptions{MaxHeight: 10}, true},
+		{"Odd Rotation", &models.FilterOptions{Rotate: 45}, true},
+		{"Unknown Denoise", &models.FilterOptions{Denoise: "median"}, true},
+		{"Saturation Out Of Range", &models.FilterOptions{Saturation: floatPtr(5)}, true},
+		{"Gamma Zero", &models.FilterOptions{Gamma: floatPtr(0)}, true},
+		{"LUT Wrong Extension", &models.FilterOptions{LUT: "look.png"}, true},
+		{"LUT With Path", &models.FilterOptions{LUT: "../look.cube"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFilters(tt.opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
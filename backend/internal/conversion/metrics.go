@@ -0,0 +1,223 @@
+package conversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+var (
+	vmafScoreRegex = regexp.MustCompile(`VMAF score[:=]\s*([0-9]+(?:\.[0-9]+)?)`)
+	ssimAllRegex   = regexp.MustCompile(`SSIM .*All:([0-9]+(?:\.[0-9]+)?)`)
+	psnrAvgRegex   = regexp.MustCompile(`PSNR .*average:([0-9]+(?:\.[0-9]+)?)`)
+)
+
+// metricsWindow is the part of the video compared when measuring quality.
+// A zero length compares the whole video.
+type metricsWindow struct {
+	start  float64
+	length float64
+}
+
+// ValidateMetricsOptions checks that quality metrics can be measured for a job.
+// Metrics compare frames at the same timestamps, so edits that change the timeline
+// or orientation cannot be measured.
+func ValidateMetricsOptions(job models.ConversionJob) error {
+	opts := job.Metrics
+	if opts == nil {
+		return nil
+	}
+	switch opts.Mode {
+	case "", models.MetricsModeSampled, models.MetricsModeFull:
+	default:
+		return fmt.Errorf("unsupported metrics mode '%s'", opts.Mode)
+	}
+	if job.JobType != "" && job.JobType != models.JobTypeConvert {
+		return fmt.Errorf("quality metrics are only supported for convert jobs")
+	}
+	if job.ReverseVideo {
+		return fmt.Errorf("quality metrics cannot be measured for reversed videos")
+	}
+	if job.Filters != nil && job.Filters.Rotate != 0 {
+		return fmt.Errorf("quality metrics cannot be measured for rotated videos")
+	}
+	if job.Stabilization != nil {
+		return fmt.Errorf("quality metrics cannot be measured for stabilized videos")
+	}
+	if len(job.KeepRanges) > 0 {
+		return fmt.Errorf("quality metrics cannot be measured for trimmed videos")
+	}
+	return nil
+}
+
+// metricsMode returns the metrics mode of a job with the default applied.
+func metricsMode(job models.ConversionJob) string {
+	if job.Metrics == nil || job.Metrics.Mode == "" {
+		return models.MetricsModeSampled
+	}
+	return job.Metrics.Mode
+}
+
+// selectMetricsWindow returns the window to compare for a video of the given duration.
+// Sampled mode compares a window from the middle of the video; short or unknown-length
+// videos are compared in full.
+func selectMetricsWindow(mode string, duration float64) metricsWindow {
+	if mode == models.MetricsModeFull || duration <= constants.MetricsSampleSeconds {
+		return metricsWindow{}
+	}
+	return metricsWindow{
+		start:  (duration - constants.MetricsSampleSeconds) / 2,
+		length: constants.MetricsSampleSeconds,
+	}
+}
+
+// buildMetricsArgs builds the FFmpeg pass comparing the output (distorted) against the source
+// (reference). The reference is scaled to the output size so downscaled outputs can be compared.
+// The scores are printed to stderr at info level.
+func buildMetricsArgs(job models.ConversionJob, window metricsWindow, includeVMAF bool) []string {
+	var seek []string
+	if window.length > 0 {
+		seek = []string{"-ss", formatSeconds(window.start), "-t", formatSeconds(window.length)}
+	}
+
+	args := append([]string{}, seek...)
+	args = append(args, "-i", job.OutputFilePath)
+	args = append(args, seek...)
+	args = append(args, "-i", job.UploadedFilePath)
+
+	graph := []string{
+		"[0:v]setpts=PTS-STARTPTS[main]",
+		"[1:v]setpts=PTS-STARTPTS[source]",
+		"[source][main]scale2ref=flags=bicubic[ref][dist]",
+	}
+	if includeVMAF {
+		graph = append(graph,
+			"[dist]split=3[d0][d1][d2]",
+			"[ref]split=3[r0][r1][r2]",
+			fmt.Sprintf("[d0][r0]libvmaf=n_threads=%d", ffmpegThreadCount()),
+			"[d1][r1]ssim",
+			"[d2][r2]psnr",
+		)
+	} else {
+		graph = append(graph,
+			"[dist]split=2[d0][d1]",
+			"[ref]split=2[r0][r1]",
+			"[d0][r0]ssim",
+			"[d1][r1]psnr",
+		)
+	}
+
+	return append(args,
+		"-threads", strconv.Itoa(ffmpegThreadCount()),
+		"-progress", "pipe:1",
+		"-nostats",
+		"-v", "info",
+		"-lavfi", strings.Join(graph, ";"),
+		"-f", "null", "-",
+	)
+}
+
+// parseQualityScores extracts the VMAF, SSIM and PSNR scores printed by FFmpeg.
+// Scores that were not reported (or are infinite, for identical inputs) are left nil.
+func parseQualityScores(ffmpegOutput string) (vmaf, ssim, psnr *float64) {
+	parse := func(re *regexp.Regexp) *float64 {
+		var result *float64
+		for _, line := range strings.Split(ffmpegOutput, "\n") {
+			if match := re.FindStringSubmatch(line); match != nil {
+				if value, err := strconv.ParseFloat(match[1], 64); err == nil {
+					result = &value
+				}
+			}
+		}
+		return result
+	}
+	return parse(vmafScoreRegex), parse(ssimAllRegex), parse(psnrAvgRegex)
+}
+
+// MetricsSidecarPath returns the sidecar file holding the quality metrics of a converted file.
+func MetricsSidecarPath(metricsDir, outputFileName string) string {
+	return filepath.Join(metricsDir, outputFileName+".json")
+}
+
+// ReadMetricsSidecar loads the quality metrics stored for a converted file.
+func ReadMetricsSidecar(path string) (*models.QualityMetrics, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var metrics models.QualityMetrics
+	if err := json.Unmarshal(data, &metrics); err != nil {
+		return nil, fmt.Errorf("failed to parse metrics file %s: %w", filepath.Base(path), err)
+	}
+	return &metrics, nil
+}
+
+// writeMetricsSidecar stores quality metrics next to the library entry they describe.
+func writeMetricsSidecar(path string, metrics models.QualityMetrics) error {
+	if err := os.MkdirAll(filepath.Dir(path), constants.DirectoryPermissions); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(metrics, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, constants.FilePermissions)
+}
+
+// measureQuality compares the encoded output against the source and records the scores.
+// Measurement failures are logged but do not fail the conversion. It returns false only
+// when the job was aborted during measurement, in which case the failure is already handled.
+func (c *VideoConverter) measureQuality(job models.ConversionJob) bool {
+	conversionID := job.ConversionID
+	status, _ := c.store.GetStatus(conversionID)
+	mode := metricsMode(job)
+	window := selectMetricsWindow(mode, status.DurationSeconds)
+	progress := progressRange{start: 100 - constants.MetricsProgressShare, share: constants.MetricsProgressShare, duration: window.length}
+
+	c.store.SetCurrentStep(conversionID, "Measure quality metrics")
+	output, err := c.runFFmpeg(conversionID, buildMetricsArgs(job, window, true), progress)
+	if err != nil && strings.Contains(output, "libvmaf") && !c.isCanceled(conversionID) {
+		log.Printf("WARN [job %s]: VMAF unavailable, measuring SSIM and PSNR only", conversionID)
+		output, err = c.runFFmpeg(conversionID, buildMetricsArgs(job, window, false), progress)
+	}
+	if err != nil {
+		if c.isCanceled(conversionID) {
+			c.handleFFmpegFailure(job, err, output)
+			return false
+		}
+		log.Printf("WARN [job %s]: Failed to measure quality metrics: %v", conversionID, err)
+		return true
+	}
+
+	vmaf, ssim, psnr := parseQualityScores(output)
+	compared := window.length
+	if compared == 0 {
+		compared = status.DurationSeconds
+	}
+	metrics := models.QualityMetrics{
+		VMAF:            vmaf,
+		SSIM:            ssim,
+		PSNR:            psnr,
+		Mode:            mode,
+		ComparedSeconds: compared,
+		Quality:         resolveJobQuality(job),
+		ComputedAt:      time.Now(),
+	}
+	c.store.SetQualityMetrics(conversionID, metrics)
+
+	if job.MetricsPath != "" {
+		if err := writeMetricsSidecar(job.MetricsPath, metrics); err != nil {
+			log.Printf("WARN [job %s]: Failed to store quality metrics: %v", conversionID, err)
+		}
+	}
+	return true
+}
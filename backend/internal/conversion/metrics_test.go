@@ -0,0 +1,114 @@
+package conversion
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateMetricsOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		job     models.ConversionJob
+		wantErr bool
+	}{
+		{"Disabled", models.ConversionJob{ReverseVideo: true}, false},
+		{"Sampled", models.ConversionJob{Metrics: &models.MetricsOptions{}}, false},
+		{"Full", models.ConversionJob{Metrics: &models.MetricsOptions{Mode: models.MetricsModeFull}}, false},
+		{"Unknown Mode", models.ConversionJob{Metrics: &models.MetricsOptions{Mode: "fast"}}, true},
+		{"Reversed", models.ConversionJob{Metrics: &models.MetricsOptions{}, ReverseVideo: true}, true},
+		{"Rotated", models.ConversionJob{Metrics: &models.MetricsOptions{}, Filters: &models.FilterOptions{Rotate: 90}}, true},
+		{"Stabilized", models.ConversionJob{Metrics: &models.MetricsOptions{}, Stabilization: &models.StabilizationOptions{}}, true},
+		{"Trimmed", models.ConversionJob{Metrics: &models.MetricsOptions{}, KeepRanges: []models.TimeRange{{Start: 0, End: 5}}}, true},
+		{"Scene Job", models.ConversionJob{Metrics: &models.MetricsOptions{}, JobType: models.JobTypeScenes}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMetricsOptions(tt.job)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSelectMetricsWindow(t *testing.T) {
+	assert.Equal(t, metricsWindow{start: 45, length: constants.MetricsSampleSeconds}, selectMetricsWindow(models.MetricsModeSampled, 100))
+	assert.Equal(t, metricsWindow{}, selectMetricsWindow(models.MetricsModeSampled, 5))
+	assert.Equal(t, metricsWindow{}, selectMetricsWindow(models.MetricsModeFull, 100))
+}
+
+func TestBuildMetricsArgs(t *testing.T) {
+	job := models.ConversionJob{UploadedFilePath: "/uploads/in.mov", OutputFilePath: "/converted/out.mp4"}
+
+	args := buildMetricsArgs(job, metricsWindow{start: 45, length: 10}, true)
+	joined := strings.Join(args, " ")
+	assert.True(t, strings.HasPrefix(joined, "-ss 45.000 -t 10.000 -i /converted/out.mp4 -ss 45.000 -t 10.000 -i /uploads/in.mov"))
+	assert.Contains(t, joined, "libvmaf")
+	assert.Contains(t, joined, "[d1][r1]ssim")
+
+	args = buildMetricsArgs(job, metricsWindow{}, false)
+	joined = strings.Join(args, " ")
+	assert.True(t, strings.HasPrefix(joined, "-i /converted/out.mp4 -i /uploads/in.mov"))
+	assert.NotContains(t, joined, "libvmaf")
+	assert.Contains(t, joined, "[d1][r1]psnr")
+}
+
+func TestParseQualityScores(t *testing.T) {
+	output := `[libvmaf @ 0x55] VMAF score: 94.218731
+[Parsed_ssim_7 @ 0x56] SSIM Y:0.981234 (17.263) U:0.990001 (20.000) V:0.989000 (19.586) All:0.984321 (18.046)
+[Parsed_psnr_8 @ 0x57] PSNR y:40.12 u:44.80 v:45.01 average:41.337 min:38.20 max:46.10`
+
+	vmaf, ssim, psnr := parseQualityScores(output)
+	require.NotNil(t, vmaf)
+	require.NotNil(t, ssim)
+	require.NotNil(t, psnr)
+	assert.InDelta(t, 94.218731, *vmaf, 1e-9)
+	assert.InDelta(t, 0.984321, *ssim, 1e-9)
+	assert.InDelta(t, 41.337, *psnr, 1e-9)
+
+	vmaf, _, psnr = parseQualityScores("[Parsed_psnr_1 @ 0x57] PSNR y:inf u:inf v:inf average:inf min:inf max:inf")
+	assert.Nil(t, vmaf)
+	assert.Nil(t, psnr)
+}
+
+func TestMetricsSidecar(t *testing.T) {
+	vmaf := 93.1
+	metrics := models.QualityMetrics{VMAF: &vmaf, Mode: models.MetricsModeFull, ComparedSeconds: 12}
+	path := MetricsSidecarPath(filepath.Join(t.TempDir(), "metrics"), "clip.mp4")
+
+	require.NoError(t, writeMetricsSidecar(path, metrics))
+
+	loaded, err := ReadMetricsSidecar(path)
+	require.NoError(t, err)
+	require.NotNil(t, loaded.VMAF)
+	assert.Equal(t, vmaf, *loaded.VMAF)
+	assert.Equal(t, "clip.mp4.json", filepath.Base(path))
+}
+
+func TestBuildPlan_Metrics(t *testing.T) {
+	job := models.ConversionJob{
+		TargetFormat:     "mp4",
+		UploadedFilePath: "/uploads/in.mov",
+		OutputFilePath:   "/converted/out.mp4",
+		Metrics:          &models.MetricsOptions{},
+	}
+
+	plan, err := BuildPlan(job)
+	require.NoError(t, err)
+	last := plan.Commands[len(plan.Commands)-1]
+	assert.Equal(t, "ffmpeg", last.Program)
+	assert.Contains(t, last.Description, "VMAF")
+
+	job.ReverseVideo = true
+	_, err = BuildPlan(job)
+	assert.Error(t, err)
+}
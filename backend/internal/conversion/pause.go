@@ -0,0 +1,174 @@
+package conversion
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+var (
+	// ErrPauseUnsupported is returned when processes cannot be suspended on this platform.
+	ErrPauseUnsupported = errors.New("pausing conversions is not supported on this platform")
+	// ErrJobNotRunning is returned when pausing a job that has no running FFmpeg process.
+	ErrJobNotRunning = errors.New("conversion has no running FFmpeg process to pause")
+	// ErrJobNotPaused is returned when resuming a job that is not paused.
+	ErrJobNotPaused = errors.New("conversion is not paused")
+)
+
+// PauseJob suspends the running FFmpeg process of a job. Only jobs with a running
+// process can be paused; pausing a job that is already paused does nothing.
+func (c *VideoConverter) PauseJob(id string) error {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+
+	status, exists := c.store.GetStatus(id)
+	if !exists {
+		return ErrJobNotFound
+	}
+	if status.Complete {
+		return ErrJobComplete
+	}
+	if status.Phase == models.PhasePaused {
+		return nil
+	}
+	return c.pauseRunningJob(id)
+}
+
+// pauseRunningJob suspends the FFmpeg process of a job and records the pause.
+// The caller must hold pauseMu.
+func (c *VideoConverter) pauseRunningJob(id string) error {
+	cmd, active := c.store.GetActiveCmd(id)
+	if !active {
+		return ErrJobNotRunning
+	}
+	if err := suspendProcess(cmd); err != nil {
+		if errors.Is(err, os.ErrProcessDone) {
+			return ErrJobNotRunning
+		}
+		return err
+	}
+	c.store.SetPaused(id, true)
+	log.Printf("INFO [job %s]: Paused FFmpeg process %d", id, cmd.Process.Pid)
+	return nil
+}
+
+// ResumeJob continues a paused job.
+func (c *VideoConverter) ResumeJob(id string) error {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+
+	status, exists := c.store.GetStatus(id)
+	if !exists {
+		return ErrJobNotFound
+	}
+	if status.Complete {
+		return ErrJobComplete
+	}
+	if status.Phase != models.PhasePaused {
+		return ErrJobNotPaused
+	}
+	return c.resumePausedJob(id)
+}
+
+// resumePausedJob continues the FFmpeg process of a paused job and records the resume.
+// The caller must hold pauseMu.
+func (c *VideoConverter) resumePausedJob(id string) error {
+	if cmd, active := c.store.GetActiveCmd(id); active {
+		if err := resumeProcess(cmd); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			return err
+		}
+	}
+	c.store.SetPaused(id, false)
+	log.Printf("INFO [job %s]: Resumed", id)
+	return nil
+}
+
+// PauseAll pauses every running conversion and holds queued jobs back from the workers
+// until ResumeAll is called. It returns the IDs of the conversions it paused.
+func (c *VideoConverter) PauseAll() ([]string, error) {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+
+	c.queue.hold(true)
+	paused := []string{}
+	for _, id := range c.store.activeCmdIDs() {
+		status, exists := c.store.GetStatus(id)
+		if !exists || status.Complete || status.Phase == models.PhasePaused {
+			continue
+		}
+		if err := c.pauseRunningJob(id); err != nil {
+			if errors.Is(err, ErrJobNotRunning) {
+				continue // Finished in the meantime
+			}
+			c.queue.hold(false) // Let queued jobs start rather than stay held with no pause to resume
+			return paused, fmt.Errorf("failed to pause job %s: %w", id, err)
+		}
+		paused = append(paused, id)
+	}
+	log.Printf("INFO: Paused all conversions (%d running)", len(paused))
+	return paused, nil
+}
+
+// ResumeAll releases the queue and resumes every paused conversion, including those
+// paused individually. It returns the IDs of the conversions it resumed.
+func (c *VideoConverter) ResumeAll() ([]string, error) {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+
+	c.queue.hold(false)
+	resumed := []string{}
+	for _, id := range c.store.activeCmdIDs() {
+		status, exists := c.store.GetStatus(id)
+		if !exists || status.Phase != models.PhasePaused {
+			continue
+		}
+		if err := c.resumePausedJob(id); err != nil {
+			return resumed, fmt.Errorf("failed to resume job %s: %w", id, err)
+		}
+		resumed = append(resumed, id)
+	}
+	log.Printf("INFO: Resumed all conversions (%d paused)", len(resumed))
+	return resumed, nil
+}
+
+// AllPaused reports whether PauseAll is in effect.
+func (c *VideoConverter) AllPaused() bool {
+	return c.queue.held()
+}
+
+// pauseIfRequested suspends a newly started FFmpeg pass if its job is paused or all
+// conversions are paused, so that a pause requested between passes still applies.
+func (c *VideoConverter) pauseIfRequested(conversionID string, cmd *exec.Cmd) {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+
+	status, exists := c.store.GetStatus(conversionID)
+	if !exists || status.Complete || (status.Phase != models.PhasePaused && !c.queue.held()) {
+		return
+	}
+	if err := suspendProcess(cmd); err != nil {
+		log.Printf("WARN [job %s]: Failed to pause new FFmpeg pass: %v", conversionID, err)
+		return
+	}
+	c.store.SetPaused(conversionID, true)
+	log.Printf("INFO [job %s]: Started FFmpeg pass paused", conversionID)
+}
+
+// excludePausedTime corrects FFmpeg statistics for time the process spent suspended.
+// FFmpeg averages speed and frame rate over wall-clock time, so a pause would otherwise
+// lower them and inflate the ETA. elapsed is the wall-clock time since the pass started
+// and paused the part of it spent paused.
+func excludePausedTime(stats *models.EncodingStats, elapsed, paused time.Duration) {
+	if paused <= 0 || elapsed <= paused {
+		return
+	}
+	factor := elapsed.Seconds() / (elapsed - paused).Seconds()
+	stats.Speed *= factor
+	stats.FPS *= factor
+	stats.ETASeconds /= factor
+}
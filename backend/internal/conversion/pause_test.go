@@ -0,0 +1,186 @@
+//go:build !windows
+
+package conversion
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startEncodingJob registers a running job whose FFmpeg process is a long sleep.
+func startEncodingJob(t *testing.T, store *Store, id string) *exec.Cmd {
+	t.Helper()
+	cmd := exec.Command("sleep", "1000")
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	store.SetStatus(id, newQueuedStatus())
+	store.SetPhase(id, models.PhaseEncoding, 100)
+	store.SetProgressPercentage(id, 40)
+	store.RegisterActiveCmd(id, cmd)
+	return cmd
+}
+
+func TestPauseJob(t *testing.T) {
+	t.Run("pause and resume", func(t *testing.T) {
+		store := NewStore()
+		converter := NewVideoConverter(1, 10, store)
+		startEncodingJob(t, store, "job")
+
+		require.NoError(t, converter.PauseJob("job"))
+		require.NoError(t, converter.PauseJob("job"), "pausing twice is not an error")
+		status, _ := store.GetStatus("job")
+		assert.Equal(t, models.PhasePaused, status.Phase)
+		assert.Equal(t, 40.0, status.Progress)
+
+		require.NoError(t, converter.ResumeJob("job"))
+		status, _ = store.GetStatus("job")
+		assert.Equal(t, models.PhaseEncoding, status.Phase)
+		phases := make([]string, len(status.Phases))
+		for i, record := range status.Phases {
+			phases[i] = record.Phase
+		}
+		assert.Equal(t, []string{models.PhaseQueued, models.PhaseEncoding, models.PhasePaused, models.PhaseEncoding}, phases)
+		assert.Equal(t, 40.0, status.Phases[3].Progress, "progress continues where the phase stopped")
+
+		assert.ErrorIs(t, converter.ResumeJob("job"), ErrJobNotPaused)
+	})
+
+	t.Run("job without a running process", func(t *testing.T) {
+		store := NewStore()
+		converter := NewVideoConverter(1, 10, store)
+		store.SetStatus("queued", newQueuedStatus())
+
+		assert.ErrorIs(t, converter.PauseJob("queued"), ErrJobNotRunning)
+		assert.ErrorIs(t, converter.PauseJob("missing"), ErrJobNotFound)
+	})
+
+	t.Run("cancel a paused job", func(t *testing.T) {
+		store := NewStore()
+		converter := NewVideoConverter(1, 10, store)
+		cmd := startEncodingJob(t, store, "job")
+		require.NoError(t, converter.PauseJob("job"))
+
+		require.NoError(t, converter.CancelJob("job"))
+
+		exited := make(chan struct{})
+		go func() {
+			_ = cmd.Wait()
+			close(exited)
+		}()
+		select {
+		case <-exited:
+		case <-time.After(2 * time.Second):
+			t.Fatal("paused process was not terminated")
+		}
+		status, _ := store.GetStatus("job")
+		assert.Equal(t, models.PhaseCanceled, status.Phase)
+	})
+}
+
+func TestPauseAll(t *testing.T) {
+	store := NewStore()
+	converter := NewVideoConverter(1, 10, store)
+	startEncodingJob(t, store, "running")
+	store.SetStatus("queued", newQueuedStatus())
+	require.NoError(t, converter.QueueJob(models.ConversionJob{ConversionID: "queued"}))
+
+	paused, err := converter.PauseAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"running"}, paused)
+	assert.True(t, converter.AllPaused())
+
+	popped := make(chan string, 1)
+	go func() {
+		job, _ := converter.queue.pop()
+		popped <- job.ConversionID
+	}()
+	select {
+	case <-popped:
+		t.Fatal("a worker received a job while all conversions were paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	resumed, err := converter.ResumeAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"running"}, resumed)
+	assert.False(t, converter.AllPaused())
+	select {
+	case id := <-popped:
+		assert.Equal(t, "queued", id)
+	case <-time.After(2 * time.Second):
+		t.Fatal("the queue was not released")
+	}
+}
+
+func TestPauseAll_Failure(t *testing.T) {
+	store := NewStore()
+	converter := NewVideoConverter(1, 10, store)
+	store.SetStatus("unsignalable", newQueuedStatus())
+	store.SetPhase("unsignalable", models.PhaseEncoding, 100)
+	store.RegisterActiveCmd("unsignalable", &exec.Cmd{Process: &os.Process{}})
+	store.SetStatus("queued", newQueuedStatus())
+	require.NoError(t, converter.QueueJob(models.ConversionJob{ConversionID: "queued"}))
+
+	_, err := converter.PauseAll()
+	require.Error(t, err)
+	assert.False(t, converter.AllPaused(), "the queue is not left held")
+
+	popped := make(chan string, 1)
+	go func() {
+		job, _ := converter.queue.pop()
+		popped <- job.ConversionID
+	}()
+	select {
+	case id := <-popped:
+		assert.Equal(t, "queued", id)
+	case <-time.After(2 * time.Second):
+		t.Fatal("queued jobs stayed held after a failed pause")
+	}
+}
+
+func TestExcludePausedTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		paused  time.Duration
+		want    models.EncodingStats
+	}{
+		{
+			name:    "no pause",
+			elapsed: 10 * time.Second,
+			want:    models.EncodingStats{FPS: 30, Speed: 1, ETASeconds: 60},
+		},
+		{
+			name:    "paused half the time",
+			elapsed: 10 * time.Second,
+			paused:  5 * time.Second,
+			want:    models.EncodingStats{FPS: 60, Speed: 2, ETASeconds: 30},
+		},
+		{
+			name:    "paused the whole time",
+			elapsed: 10 * time.Second,
+			paused:  10 * time.Second,
+			want:    models.EncodingStats{FPS: 30, Speed: 1, ETASeconds: 60},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := models.EncodingStats{FPS: 30, Speed: 1, ETASeconds: 60}
+			excludePausedTime(&stats, tt.elapsed, tt.paused)
+			assert.InDelta(t, tt.want.FPS, stats.FPS, 1e-9)
+			assert.InDelta(t, tt.want.Speed, stats.Speed, 1e-9)
+			assert.InDelta(t, tt.want.ETASeconds, stats.ETASeconds, 1e-9)
+		})
+	}
+}
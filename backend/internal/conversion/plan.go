@@ -0,0 +1,242 @@
+package conversion
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+// BuildPlan returns the ordered list of external tool invocations that convertVideo
+// will run for the given job, without executing anything.
+func BuildPlan(job models.ConversionJob) (models.ConversionPlan, error) {
+	quality := resolveJobQuality(job)
+
+	commands := []models.CommandInvocation{{
+		Program:     "ffprobe",
+		Args:        ffprobeDurationArgs(job.UploadedFilePath),
+		Description: "Probe input duration",
+	}}
+
+	if err := ValidateMetricsOptions(job); err != nil {
+		return models.ConversionPlan{}, err
+	}
+	if err := ValidateCRFSearch(job); err != nil {
+		return models.ConversionPlan{}, err
+	}
+
+	switch job.JobType {
+	case "", models.JobTypeConvert:
+	case models.JobTypeScenes:
+		sceneCommands, err := scenePlanCommands(job)
+		if err != nil {
+			return models.ConversionPlan{}, err
+		}
+		return models.ConversionPlan{
+			OutputFileName: filepath.Base(job.OutputFilePath),
+			Quality:        quality,
+			Commands:       append(commands, sceneCommands...),
+		}, nil
+	case models.JobTypeSilence:
+		silenceCommands, err := silencePlanCommands(job, quality)
+		if err != nil {
+			return models.ConversionPlan{}, err
+		}
+		return models.ConversionPlan{
+			OutputFileName: filepath.Base(job.OutputFilePath),
+			Quality:        quality,
+			Commands:       append(commands, silenceCommands...),
+		}, nil
+	default:
+		return models.ConversionPlan{}, fmt.Errorf("unsupported job type '%s'", job.JobType)
+	}
+
+	passes, err := buildFFmpegPasses(job, quality)
+	if err != nil {
+		return models.ConversionPlan{}, err
+	}
+	for i, pass := range passes {
+		if i == len(passes)-1 && job.TargetVMAF > 0 {
+			searchCommands, err := crfSearchPlanCommands(job)
+			if err != nil {
+				return models.ConversionPlan{}, err
+			}
+			commands = append(commands, searchCommands...)
+		}
+		commands = append(commands, models.CommandInvocation{
+			Program:     "ffmpeg",
+			Args:        pass.args,
+			Description: pass.description,
+		})
+	}
+	commands = append(commands, models.CommandInvocation{
+		Program:     "exiftool",
+		Args:        exiftoolCopyArgs(job.UploadedFilePath, job.OutputFilePath),
+		Description: "Copy metadata",
+	})
+	if job.Metrics != nil {
+		// The sampled window is centred in the video once its duration is known.
+		window := metricsWindow{}
+		description := "Measure VMAF, SSIM and PSNR against the source"
+		if metricsMode(job) == models.MetricsModeSampled {
+			window.length = constants.MetricsSampleSeconds
+			description += fmt.Sprintf(" (%gs window from the middle of the video)", constants.MetricsSampleSeconds)
+		}
+		commands = append(commands, models.CommandInvocation{
+			Program:     "ffmpeg",
+			Args:        buildMetricsArgs(job, window, true),
+			Description: description,
+		})
+	}
+
+	return models.ConversionPlan{
+		OutputFileName: filepath.Base(job.OutputFilePath),
+		Quality:        quality,
+		Commands:       commands,
+	}, nil
+}
+
+// resolveJobQuality returns the quality setting for a job, filling in defaults
+// for any missing preset or CRF value.
+func resolveJobQuality(job models.ConversionJob) models.QualitySetting {
+	quality := models.QualitySetting{
+		Name:   job.Quality,
+		Preset: job.VideoPreset,
+		CRF:    job.VideoCRF,
+	}
+	if quality.Preset == "" || quality.CRF <= 0 {
+		defaultQuality := ResolveQualitySetting(models.DefaultQualityName)
+		if quality.Preset == "" {
+			quality.Preset = defaultQuality.Preset
+		}
+		if quality.CRF <= 0 {
+			quality.CRF = defaultQuality.CRF
+		}
+		if quality.Name == "" {
+			quality.Name = defaultQuality.Name
+		}
+	}
+	return quality
+}
+
+// ffmpegThreadCount determines the number of threads FFmpeg may use.
+func ffmpegThreadCount() int {
+	threadCount := runtime.NumCPU() - constants.ThreadCountReserve
+	if threadCount < constants.MinThreadCount {
+		threadCount = constants.MinThreadCount
+	}
+	return threadCount
+}
+
+// ffprobeDurationArgs returns the ffprobe arguments used to read the container duration.
+func ffprobeDurationArgs(filePath string) []string {
+	return []string{
+		"-v", "error", // Only show errors
+		"-show_entries", "format=duration", // Get duration from format section
+		"-of", "default=noprint_wrappers=1:nokey=1", // Output only the value
+		filePath,
+	}
+}
+
+// exiftoolCopyArgs returns the exiftool arguments used to copy metadata from input to output.
+// -overwrite_original modifies the output file directly and -preserve keeps the file modification time.
+func exiftoolCopyArgs(inputPath, outputPath string) []string {
+	return []string{"-tagsFromFile", inputPath, "-preserve", "-overwrite_original", outputPath}
+}
+
+// ffmpegPass is a single FFmpeg invocation within a job, along with the share
+// of overall job progress (in percent) it accounts for.
+type ffmpegPass struct {
+	description   string
+	args          []string
+	progressShare float64
+}
+
+// buildFFmpegPasses returns the FFmpeg invocations for a job in execution order.
+// Most jobs need a single encode pass; stabilization adds a motion analysis pass first.
+func buildFFmpegPasses(job models.ConversionJob, quality models.QualitySetting) ([]ffmpegPass, error) {
+	if err := ValidateStabilization(job.Stabilization); err != nil {
+		return nil, err
+	}
+	if err := ValidateFilters(job.Filters); err != nil {
+		return nil, err
+	}
+
+	encodeArgs, err := buildFFmpegArgs(job, quality)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Stabilization == nil {
+		return []ffmpegPass{{description: "Encode video", args: encodeArgs, progressShare: 100}}, nil
+	}
+
+	return []ffmpegPass{
+		{
+			description:   "Analyze camera motion",
+			args:          buildStabilizationAnalysisArgs(job),
+			progressShare: constants.StabilizationAnalysisProgressShare,
+		},
+		{
+			description:   "Encode stabilized video",
+			args:          encodeArgs,
+			progressShare: 100 - constants.StabilizationAnalysisProgressShare,
+		},
+	}, nil
+}
+
+// buildFFmpegArgs builds the FFmpeg argument list for the encode step of a job.
+func buildFFmpegArgs(job models.ConversionJob, quality models.QualitySetting) ([]string, error) {
+	ffmpegArgs := []string{
+		"-i", job.UploadedFilePath,
+		"-threads", strconv.Itoa(ffmpegThreadCount()),
+		"-progress", "pipe:1", // Send progress info to stdout
+		"-nostats",      // Suppress encoding stats on stderr
+		"-v", "warning", // Log level for FFmpeg messages on stderr
+	}
+
+	// Add video filters if requested, combined into a single filtergraph
+	if filterChain := buildVideoFilterChain(job); filterChain != "" {
+		ffmpegArgs = append(ffmpegArgs, "-vf", filterChain)
+	}
+
+	// Handle audio options
+	if job.RemoveSound {
+		ffmpegArgs = append(ffmpegArgs, "-an") // No audio
+	} else {
+		if audioChain := buildAudioFilterChain(job); audioChain != "" {
+			ffmpegArgs = append(ffmpegArgs, "-af", audioChain) // Cut/reverse audio to match video
+		} else {
+			// Default: copy audio stream without re-encoding if possible
+			ffmpegArgs = append(ffmpegArgs, "-c:a", "copy")
+		}
+	}
+
+	encoderArgs, err := videoEncoderArgs(job.TargetFormat, quality)
+	if err != nil {
+		return nil, err
+	}
+	ffmpegArgs = append(ffmpegArgs, encoderArgs...)
+
+	return append(ffmpegArgs, job.OutputFilePath), nil
+}
+
+// videoEncoderArgs returns the format-specific video encoder arguments for a quality setting.
+func videoEncoderArgs(targetFormat string, quality models.QualitySetting) ([]string, error) {
+	// Add format-specific arguments (consider making these configurable)
+	switch targetFormat {
+	case "mov", "mp4":
+		return []string{
+			"-tag:v", "hvc1",
+			"-c:v", "libx265",
+			"-preset", quality.Preset,
+			"-crf", strconv.Itoa(quality.CRF),
+			"-movflags", "+faststart",
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported target format '%s'", targetFormat)
+	}
+}
@@ -0,0 +1,116 @@
+package conversion
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildPlan(t *testing.T) {
+	job := models.ConversionJob{
+		ConversionID:     "abc-123",
+		TargetFormat:     "mp4",
+		Quality:          "high",
+		VideoPreset:      "slower",
+		VideoCRF:         20,
+		UploadedFilePath: "/uploads/abc-123-input.mov",
+		OutputFilePath:   "/converted/input-abc.mp4",
+	}
+
+	plan, err := BuildPlan(job)
+	require.NoError(t, err)
+
+	assert.Equal(t, "input-abc.mp4", plan.OutputFileName)
+	assert.Equal(t, "high", plan.Quality.Name)
+	assert.Equal(t, 20, plan.Quality.CRF)
+	require.Len(t, plan.Commands, 3)
+	assert.Equal(t, "ffprobe", plan.Commands[0].Program)
+	assert.Equal(t, "ffmpeg", plan.Commands[1].Program)
+	assert.Equal(t, "exiftool", plan.Commands[2].Program)
+
+	ffmpegArgs := plan.Commands[1].Args
+	assert.Equal(t, job.OutputFilePath, ffmpegArgs[len(ffmpegArgs)-1])
+	assert.Contains(t, ffmpegArgs, "slower")
+	assert.Contains(t, ffmpegArgs, "copy")
+}
+
+func TestBuildPlan_ReverseWithoutSound(t *testing.T) {
+	job := models.ConversionJob{
+		TargetFormat:     "mov",
+		UploadedFilePath: "/uploads/in.mov",
+		OutputFilePath:   "/converted/out.mov",
+		ReverseVideo:     true,
+		RemoveSound:      true,
+	}
+
+	plan, err := BuildPlan(job)
+	require.NoError(t, err)
+
+	ffmpegArgs := plan.Commands[1].Args
+	assert.Contains(t, ffmpegArgs, "reverse")
+	assert.Contains(t, ffmpegArgs, "-an")
+	assert.NotContains(t, ffmpegArgs, "areverse")
+	// Missing quality values fall back to the default preset
+	assert.Equal(t, "default", plan.Quality.Name)
+	assert.Equal(t, 22, plan.Quality.CRF)
+}
+
+func TestBuildPlan_UnsupportedFormat(t *testing.T) {
+	_, err := BuildPlan(models.ConversionJob{TargetFormat: "avi"})
+	assert.Error(t, err)
+}
+
+func TestBuildPlan_Stabilization(t *testing.T) {
+	job := models.ConversionJob{
+		TargetFormat:     "mp4",
+		UploadedFilePath: "/uploads/shaky.mov",
+		OutputFilePath:   "/converted/shaky.mp4",
+		ReverseVideo:     true,
+		Stabilization:    &models.StabilizationOptions{Smoothing: 30, Zoom: 5},
+	}
+
+	plan, err := BuildPlan(job)
+	require.NoError(t, err)
+	require.Len(t, plan.Commands, 4)
+
+	analysisArgs := plan.Commands[1].Args
+	assert.Contains(t, analysisArgs, "vidstabdetect=shakiness=5:accuracy=15:result=/uploads/shaky.mov.trf")
+	assert.Equal(t, "-", analysisArgs[len(analysisArgs)-1])
+
+	encodeArgs := plan.Commands[2].Args
+	assert.Contains(t, encodeArgs, "vidstabtransform=input=/uploads/shaky.mov.trf:smoothing=30:zoom=5,unsharp=5:5:0.8:3:3:0.4,reverse")
+}
+
+func TestValidateStabilization(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *models.StabilizationOptions
+		wantErr bool
+	}{
+		{"Disabled", nil, false},
+		{"Defaults", &models.StabilizationOptions{}, false},
+		{"Max Values", &models.StabilizationOptions{Smoothing: 100, Zoom: -50}, false},
+		{"Negative Smoothing", &models.StabilizationOptions{Smoothing: -1}, true},
+		{"Excessive Zoom", &models.StabilizationOptions{Zoom: 80}, true},
+		{"NaN Zoom", &models.StabilizationOptions{Zoom: math.NaN()}, true},
+		{"Infinite Zoom", &models.StabilizationOptions{Zoom: math.Inf(-1)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStabilization(tt.opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEscapeFilterValue(t *testing.T) {
+	assert.Equal(t, `C\:\\temp\\a\,b.trf`, escapeFilterValue(`C:\temp\a,b.trf`))
+}
@@ -0,0 +1,158 @@
+package conversion
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+var (
+	// ErrPreviewBusy is returned when the maximum number of preview encodes is already running.
+	ErrPreviewBusy = errors.New("too many previews running, try again shortly")
+	// ErrInvalidPreview is returned when the preview options cannot be used with the input.
+	ErrInvalidPreview = errors.New("invalid preview request")
+)
+
+// ValidatePreview checks that a job's options can be previewed from a single sample encode.
+func ValidatePreview(job models.ConversionJob) error {
+	if job.JobType != "" && job.JobType != models.JobTypeConvert {
+		return fmt.Errorf("%w: previews are only supported for convert jobs", ErrInvalidPreview)
+	}
+	if job.Stabilization != nil {
+		return fmt.Errorf("%w: stabilization needs a full analysis pass and cannot be previewed", ErrInvalidPreview)
+	}
+	if job.TargetVMAF > 0 {
+		return fmt.Errorf("%w: a target VMAF cannot be previewed", ErrInvalidPreview)
+	}
+	return nil
+}
+
+// previewWindow returns the part of the input to encode for a preview. A zero length uses the
+// default; the window is clipped to the input duration when it is known.
+func previewWindow(offset, length, duration float64) (models.TimeRange, error) {
+	if math.IsNaN(offset) || math.IsInf(offset, 0) || math.IsNaN(length) || math.IsInf(length, 0) {
+		return models.TimeRange{}, fmt.Errorf("%w: offset and sample length must be finite numbers", ErrInvalidPreview)
+	}
+	if length == 0 {
+		length = constants.PreviewDefaultSeconds
+	}
+	if offset < 0 {
+		return models.TimeRange{}, fmt.Errorf("%w: offset cannot be negative", ErrInvalidPreview)
+	}
+	if length < 0 || length > constants.PreviewMaxSeconds {
+		return models.TimeRange{}, fmt.Errorf("%w: sample length must be between 0 and %g seconds", ErrInvalidPreview, constants.PreviewMaxSeconds)
+	}
+	window := models.TimeRange{Start: offset, End: offset + length}
+	if duration > 0 {
+		if offset >= duration {
+			return models.TimeRange{}, fmt.Errorf("%w: offset %gs is beyond the end of the video (%.2fs)", ErrInvalidPreview, offset, duration)
+		}
+		if window.End > duration {
+			window.End = duration
+		}
+	}
+	return window, nil
+}
+
+// buildPreviewArgs builds the sample encode, reusing the regular encode settings with an input seek.
+func buildPreviewArgs(job models.ConversionJob, window models.TimeRange) ([]string, error) {
+	encodeArgs, err := buildFFmpegArgs(job, resolveJobQuality(job))
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{"-ss", formatSeconds(window.Start), "-t", formatSeconds(window.End - window.Start)}, encodeArgs...), nil
+}
+
+// projectPreview extrapolates the full output size and encode time from the sample's
+// bitrate and encoding speed.
+func projectPreview(result *models.PreviewResult, fullSeconds float64) {
+	result.ProjectedDurationSeconds = fullSeconds
+	if result.EncodeSeconds > 0 {
+		result.Speed = result.SampleSeconds / result.EncodeSeconds
+	}
+	if result.SampleSeconds <= 0 || fullSeconds <= 0 {
+		return
+	}
+	scale := fullSeconds / result.SampleSeconds
+	result.ProjectedSize = int64(float64(result.SampleSize) * scale)
+	result.ProjectedEncodeSeconds = result.EncodeSeconds * scale
+}
+
+// Preview encodes a short sample of the job's input with its options and projects the full
+// job from it. Previews run immediately on the caller's goroutine instead of waiting in the
+// job queue, limited to a few at a time.
+func (c *VideoConverter) Preview(job models.ConversionJob, offset, length float64) (models.PreviewResult, error) {
+	select {
+	case c.previewSlots <- struct{}{}:
+		defer func() { <-c.previewSlots }()
+	default:
+		return models.PreviewResult{}, ErrPreviewBusy
+	}
+
+	if err := ValidatePreview(job); err != nil {
+		return models.PreviewResult{}, err
+	}
+
+	duration, err := getVideoDuration(job.UploadedFilePath)
+	if err != nil {
+		log.Printf("WARN [preview %s]: Could not get video duration: %v. Projection will be unavailable.", job.ConversionID, err)
+		duration = 0
+	}
+	window, err := previewWindow(offset, length, duration)
+	if err != nil {
+		return models.PreviewResult{}, err
+	}
+	args, err := buildPreviewArgs(job, window)
+	if err != nil {
+		return models.PreviewResult{}, fmt.Errorf("%w: %v", ErrInvalidPreview, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.PreviewTimeout)
+	defer cancel()
+
+	log.Printf("Executing FFmpeg for preview %s: ffmpeg %s", job.ConversionID, strings.Join(args, " "))
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	started := time.Now()
+	err = cmd.Run()
+	elapsed := time.Since(started)
+	if err != nil {
+		if removeErr := os.Remove(job.OutputFilePath); removeErr != nil && !os.IsNotExist(removeErr) {
+			log.Printf("WARN [preview %s]: Failed to remove incomplete sample %s: %v", job.ConversionID, job.OutputFilePath, removeErr)
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return models.PreviewResult{}, fmt.Errorf("preview encode timed out after %s", constants.PreviewTimeout)
+		}
+		return models.PreviewResult{}, fmt.Errorf("preview encode failed: %v: %s", err, stderr.String())
+	}
+
+	info, err := os.Stat(job.OutputFilePath)
+	if err != nil || info.Size() == 0 {
+		return models.PreviewResult{}, fmt.Errorf("preview encode finished but the sample is missing or empty")
+	}
+
+	result := models.PreviewResult{
+		FileName:      filepath.Base(job.OutputFilePath),
+		Quality:       resolveJobQuality(job),
+		Offset:        window.Start,
+		SampleSeconds: window.End - window.Start,
+		SampleSize:    info.Size(),
+		EncodeSeconds: elapsed.Seconds(),
+	}
+	projectPreview(&result, duration)
+	log.Printf("Preview %s: %.1fs sample encoded in %.1fs (%.2fx), projected %d bytes",
+		job.ConversionID, result.SampleSeconds, result.EncodeSeconds, result.Speed, result.ProjectedSize)
+	return result, nil
+}
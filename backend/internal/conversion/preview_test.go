@@ -0,0 +1,81 @@
+package conversion
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		offset   float64
+		length   float64
+		duration float64
+		want     models.TimeRange
+		wantErr  bool
+	}{
+		{"Default Length", 30, 0, 120, models.TimeRange{Start: 30, End: 40}, false},
+		{"Clipped To End", 115, 10, 120, models.TimeRange{Start: 115, End: 120}, false},
+		{"Unknown Duration", 30, 5, 0, models.TimeRange{Start: 30, End: 35}, false},
+		{"Beyond End", 130, 10, 120, models.TimeRange{}, true},
+		{"Negative Offset", -1, 10, 120, models.TimeRange{}, true},
+		{"Too Long", 0, 600, 1200, models.TimeRange{}, true},
+		{"NaN Offset", math.NaN(), 10, 120, models.TimeRange{}, true},
+		{"Infinite Offset", math.Inf(1), 10, 0, models.TimeRange{}, true},
+		{"NaN Length", 30, math.NaN(), 120, models.TimeRange{}, true},
+		{"Infinite Length", 30, math.Inf(-1), 120, models.TimeRange{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, err := previewWindow(tt.offset, tt.length, tt.duration)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ErrInvalidPreview))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, window)
+		})
+	}
+}
+
+func TestProjectPreview(t *testing.T) {
+	result := models.PreviewResult{SampleSeconds: 10, SampleSize: 5_000_000, EncodeSeconds: 20}
+
+	projectPreview(&result, 3600)
+
+	assert.Equal(t, 3600.0, result.ProjectedDurationSeconds)
+	assert.Equal(t, int64(1_800_000_000), result.ProjectedSize)
+	assert.Equal(t, 7200.0, result.ProjectedEncodeSeconds)
+	assert.Equal(t, 0.5, result.Speed)
+}
+
+func TestBuildPreviewArgs(t *testing.T) {
+	job := models.ConversionJob{
+		TargetFormat:     "mp4",
+		Quality:          "high",
+		VideoPreset:      "slower",
+		VideoCRF:         18,
+		UploadedFilePath: "/uploads/talk.mov",
+		OutputFilePath:   "/data/previews/preview-abc.mp4",
+	}
+
+	args, err := buildPreviewArgs(job, models.TimeRange{Start: 60, End: 70})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"-ss", "60.000", "-t", "10.000", "-i", "/uploads/talk.mov"}, args[:6])
+	assert.Contains(t, args, "slower")
+	assert.Equal(t, "/data/previews/preview-abc.mp4", args[len(args)-1])
+}
+
+func TestValidatePreview(t *testing.T) {
+	assert.NoError(t, ValidatePreview(models.ConversionJob{Filters: &models.FilterOptions{MaxHeight: 720}}))
+	assert.Error(t, ValidatePreview(models.ConversionJob{Stabilization: &models.StabilizationOptions{}}))
+	assert.Error(t, ValidatePreview(models.ConversionJob{JobType: models.JobTypeScenes}))
+	assert.Error(t, ValidatePreview(models.ConversionJob{TargetVMAF: 93}))
+}
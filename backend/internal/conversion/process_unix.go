@@ -0,0 +1,37 @@
+//go:build !windows
+
+package conversion
+
+import (
+	"errors"
+	"log"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// terminateProcess asks a running command to stop, falling back to killing it.
+// A suspended process is continued so that it receives the signal.
+// A process that has already exited is not an error.
+func terminateProcess(cmd *exec.Cmd) error {
+	err := cmd.Process.Signal(syscall.SIGTERM)
+	if err != nil && !errors.Is(err, os.ErrProcessDone) {
+		log.Printf("WARN: SIGTERM failed for process %d, trying SIGKILL: %v", cmd.Process.Pid, err)
+		err = cmd.Process.Signal(syscall.SIGKILL)
+	}
+	if err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	_ = cmd.Process.Signal(syscall.SIGCONT)
+	return nil
+}
+
+// suspendProcess stops a running command until resumeProcess is called.
+func suspendProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Signal(syscall.SIGSTOP)
+}
+
+// resumeProcess continues a command stopped by suspendProcess.
+func resumeProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Signal(syscall.SIGCONT)
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package conversion
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// terminateProcess kills a running command. A process that has already exited is not an error.
+func terminateProcess(cmd *exec.Cmd) error {
+	if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	return nil
+}
+
+// suspendProcess is not supported on Windows.
+func suspendProcess(*exec.Cmd) error {
+	return ErrPauseUnsupported
+}
+
+// resumeProcess is not supported on Windows.
+func resumeProcess(*exec.Cmd) error {
+	return ErrPauseUnsupported
+}
@@ -0,0 +1,302 @@
+package conversion
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+// ResolvePriority returns the scheduling priority of a job, defaulting to interactive
+// for silence previews (which only detect and report) and to normal otherwise.
+// Interactive jobs run before everyone else's jobs, so only previews may use it.
+func ResolvePriority(job models.ConversionJob) (string, error) {
+	preview := job.JobType == models.JobTypeSilence && job.Silence != nil && job.Silence.PreviewOnly
+	switch job.Priority {
+	case "":
+		if preview {
+			return models.PriorityInteractive, nil
+		}
+		return models.PriorityNormal, nil
+	case models.PriorityInteractive:
+		if !preview {
+			return "", errors.New("interactive priority is reserved for silence previews (use normal or bulk)")
+		}
+		return job.Priority, nil
+	case models.PriorityNormal, models.PriorityBulk:
+		return job.Priority, nil
+	default:
+		return "", fmt.Errorf("unknown priority '%s' (use interactive, normal or bulk)", job.Priority)
+	}
+}
+
+// priorityWeight returns the share of workers a client's jobs of a priority receive
+// relative to other clients' jobs.
+func priorityWeight(priority string) float64 {
+	switch priority {
+	case models.PriorityInteractive:
+		return constants.SchedulerWeightInteractive
+	case models.PriorityBulk:
+		return constants.SchedulerWeightBulk
+	default:
+		return constants.SchedulerWeightNormal
+	}
+}
+
+// queuedJob is a pending job together with its scheduling tags.
+type queuedJob struct {
+	job         models.ConversionJob
+	interactive bool    // Interactive jobs run before all other jobs
+	finish      float64 // Virtual finish tag; lower runs first
+	start       float64 // Virtual start tag, which becomes the queue's virtual time when dispatched
+	seq         uint64  // Arrival order, breaks ties
+}
+
+// before reports whether a should be dispatched before b.
+func (a *queuedJob) before(b *queuedJob) bool {
+	if a.interactive != b.interactive {
+		return a.interactive
+	}
+	if a.finish != b.finish {
+		return a.finish < b.finish
+	}
+	return a.seq < b.seq
+}
+
+// QueueSlot describes where a pending job stands in the queue and why.
+type QueueSlot struct {
+	ID       string
+	Position int // 1-based
+	Reason   string
+}
+
+// jobQueue holds jobs waiting for a worker, up to its limit. Jobs are dispatched with
+// start-time fair queuing across clients: every client gets a share of the workers in
+// proportion to the weight of its jobs' priorities, so one client's large batch does not
+// starve other clients. Interactive jobs jump ahead of all normal and bulk jobs.
+// Pending jobs are persisted by the store and requeued by RestoreJobs after a restart,
+// so the queue itself only lives in memory.
+type jobQueue struct {
+	mu          sync.Mutex
+	cond        *sync.Cond
+	pending     []*queuedJob
+	limit       int
+	reserved    int // Slots held for Drive jobs whose source is still downloading
+	closed      bool
+	holding     bool               // Set while all conversions are paused; pop waits until released
+	virtualTime float64            // Start tag of the most recently dispatched job
+	lastFinish  map[string]float64 // Finish tag of each client's latest job
+	seq         uint64
+}
+
+// newJobQueue creates a queue accepting at most limit pending jobs.
+func newJobQueue(limit int) *jobQueue {
+	q := &jobQueue{limit: limit, lastFinish: make(map[string]float64)}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push adds a job to the queue. It returns ErrQueueFull if the pending and reserved
+// jobs reach the limit of the queue or it has been closed.
+func (q *jobQueue) push(job models.ConversionJob) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed || len(q.pending)+q.reserved >= q.limit {
+		return ErrQueueFull
+	}
+	q.add(job)
+	return nil
+}
+
+// reserve holds n slots for jobs pushed later with pushReserved. It returns ErrQueueFull,
+// reserving nothing, if the queue cannot hold n more jobs.
+func (q *jobQueue) reserve(n int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed || len(q.pending)+q.reserved+n > q.limit {
+		return ErrQueueFull
+	}
+	q.reserved += n
+	return nil
+}
+
+// release gives back a reserved slot whose job will not be pushed.
+func (q *jobQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.reserved--
+}
+
+// pushReserved adds a job to the queue in a slot reserved for it. It only fails once
+// the queue has been closed; the slot is given back either way.
+func (q *jobQueue) pushReserved(job models.ConversionJob) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.reserved--
+	if q.closed {
+		return ErrQueueFull
+	}
+	q.add(job)
+	return nil
+}
+
+// add inserts a job in dispatch order. Callers must hold the queue lock.
+func (q *jobQueue) add(job models.ConversionJob) {
+	// A client's next job starts when its previous job finishes, or now if the client
+	// has been idle, and costs one job divided by the weight of its priority.
+	start := q.virtualTime
+	if last, ok := q.lastFinish[job.ClientID]; ok && last > start {
+		start = last
+	}
+	finish := start + 1/priorityWeight(job.Priority)
+	q.lastFinish[job.ClientID] = finish
+	q.seq++
+
+	q.pending = append(q.pending, &queuedJob{
+		job:         job,
+		interactive: job.Priority == models.PriorityInteractive,
+		start:       start,
+		finish:      finish,
+		seq:         q.seq,
+	})
+	sort.SliceStable(q.pending, func(i, j int) bool { return q.pending[i].before(q.pending[j]) })
+	q.cond.Signal()
+}
+
+// pop removes and returns the next job to run, waiting until one is available and the
+// queue is not held. It returns false once the queue has been closed.
+func (q *jobQueue) pop() (models.ConversionJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for (len(q.pending) == 0 || q.holding) && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return models.ConversionJob{}, false
+	}
+
+	next := q.pending[0]
+	q.pending[0] = nil // Release the reference held by the backing array
+	q.pending = q.pending[1:]
+	if next.start > q.virtualTime {
+		q.virtualTime = next.start
+	}
+	// Clients whose jobs have all been dispatched no longer need a finish tag.
+	for client, finish := range q.lastFinish {
+		if finish <= q.virtualTime {
+			delete(q.lastFinish, client)
+		}
+	}
+	return next.job, true
+}
+
+// remove takes a pending job out of the queue. It returns false if the job is not
+// waiting in the queue, for example because a worker has already picked it up.
+func (q *jobQueue) remove(id string) (models.ConversionJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, entry := range q.pending {
+		if entry.job.ConversionID == id {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			return entry.job, true
+		}
+	}
+	return models.ConversionJob{}, false
+}
+
+// ids returns the IDs of the pending jobs in dispatch order.
+func (q *jobQueue) ids() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	ids := make([]string, len(q.pending))
+	for i, entry := range q.pending {
+		ids[i] = entry.job.ConversionID
+	}
+	return ids
+}
+
+// slots returns the position of every pending job in dispatch order, with a
+// description of the scheduling decision.
+func (q *jobQueue) slots() []QueueSlot {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	clients := make(map[string]struct{})
+	for _, entry := range q.pending {
+		clients[entry.job.ClientID] = struct{}{}
+	}
+
+	slots := make([]QueueSlot, len(q.pending))
+	aheadByClient := make(map[string]int)
+	for i, entry := range q.pending {
+		client := entry.job.ClientID
+		slots[i] = QueueSlot{
+			ID:       entry.job.ConversionID,
+			Position: i + 1,
+			Reason:   queueReason(entry, i, aheadByClient[client], len(clients)),
+		}
+		aheadByClient[client]++
+	}
+	return slots
+}
+
+// queueReason explains a pending job's position.
+func queueReason(entry *queuedJob, ahead, aheadFromClient, clients int) string {
+	priority := entry.job.Priority
+	if priority == "" {
+		priority = models.PriorityNormal
+	}
+
+	var reason string
+	switch {
+	case ahead == 0:
+		reason = fmt.Sprintf("%s priority, next to run", priority)
+	case entry.interactive:
+		reason = fmt.Sprintf("%s priority, ahead of all normal and bulk jobs; %d interactive jobs ahead", priority, ahead)
+	default:
+		reason = fmt.Sprintf("%s priority, %d jobs ahead", priority, ahead)
+	}
+	if aheadFromClient > 0 {
+		reason += fmt.Sprintf(" (%d from the same client)", aheadFromClient)
+	}
+	if clients > 1 {
+		reason += fmt.Sprintf("; workers are shared fairly between %d clients", clients)
+	}
+	return reason
+}
+
+// hold stops (or, with false, resumes) handing jobs to workers. Jobs can still be queued while held.
+func (q *jobQueue) hold(holding bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.holding = holding
+	if !holding {
+		q.cond.Broadcast()
+	}
+}
+
+// held reports whether the queue is holding jobs back from workers.
+func (q *jobQueue) held() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.holding
+}
+
+// len returns the number of pending jobs.
+func (q *jobQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// close wakes all waiting workers and makes pop return false. Jobs still pending are
+// dropped from memory; they remain in the store and are requeued on the next start.
+func (q *jobQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
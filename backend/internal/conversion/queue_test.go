@@ -0,0 +1,256 @@
+package conversion
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobQueue(t *testing.T) {
+	t.Run("first in first out up to the limit", func(t *testing.T) {
+		q := newJobQueue(3)
+		for i := 1; i <= 3; i++ {
+			require.NoError(t, q.push(models.ConversionJob{ConversionID: fmt.Sprintf("job-%d", i)}))
+		}
+		assert.ErrorIs(t, q.push(models.ConversionJob{ConversionID: "job-4"}), ErrQueueFull)
+		assert.Equal(t, []string{"job-1", "job-2", "job-3"}, q.ids())
+
+		job, ok := q.pop()
+		require.True(t, ok)
+		assert.Equal(t, "job-1", job.ConversionID)
+		assert.Equal(t, 2, q.len())
+		assert.NoError(t, q.push(models.ConversionJob{ConversionID: "job-4"}), "popping frees a slot")
+	})
+
+	t.Run("reserved slots count against the limit", func(t *testing.T) {
+		q := newJobQueue(3)
+		require.NoError(t, q.push(models.ConversionJob{ConversionID: "queued"}))
+		require.NoError(t, q.reserve(2))
+		assert.ErrorIs(t, q.reserve(1), ErrQueueFull)
+		assert.ErrorIs(t, q.push(models.ConversionJob{ConversionID: "other"}), ErrQueueFull)
+
+		require.NoError(t, q.pushReserved(models.ConversionJob{ConversionID: "downloaded"}))
+		assert.Equal(t, []string{"queued", "downloaded"}, q.ids())
+		assert.ErrorIs(t, q.push(models.ConversionJob{ConversionID: "other"}), ErrQueueFull, "one slot is still reserved")
+		q.release()
+		assert.NoError(t, q.push(models.ConversionJob{ConversionID: "other"}), "releasing frees the slot")
+	})
+
+	t.Run("pop waits for a job", func(t *testing.T) {
+		q := newJobQueue(1)
+		popped := make(chan string, 1)
+		go func() {
+			job, _ := q.pop()
+			popped <- job.ConversionID
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		require.NoError(t, q.push(models.ConversionJob{ConversionID: "late"}))
+
+		select {
+		case id := <-popped:
+			assert.Equal(t, "late", id)
+		case <-time.After(2 * time.Second):
+			t.Fatal("pop did not return after a job was pushed")
+		}
+	})
+
+	t.Run("close releases waiting workers and rejects jobs", func(t *testing.T) {
+		q := newJobQueue(1)
+		done := make(chan bool, 1)
+		go func() {
+			_, ok := q.pop()
+			done <- ok
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		q.close()
+
+		select {
+		case ok := <-done:
+			assert.False(t, ok)
+		case <-time.After(2 * time.Second):
+			t.Fatal("pop did not return after close")
+		}
+		assert.True(t, errors.Is(q.push(models.ConversionJob{ConversionID: "after"}), ErrQueueFull))
+	})
+}
+
+func TestQueueJobPositions(t *testing.T) {
+	store := NewStore()
+	converter := NewVideoConverter(1, 30, store) // Workers are not started, so jobs stay queued
+
+	for i := 1; i <= 30; i++ {
+		id := fmt.Sprintf("job-%d", i)
+		status := newQueuedStatus()
+		store.SetStatus(id, status)
+		require.NoError(t, converter.QueueJob(models.ConversionJob{ConversionID: id, Status: status}))
+	}
+	err := converter.QueueJob(models.ConversionJob{ConversionID: "job-31", Status: newQueuedStatus()})
+	assert.ErrorIs(t, err, ErrQueueFull)
+
+	status, _ := store.GetStatus("job-30")
+	assert.Equal(t, 30, status.QueuePosition)
+
+	// A worker taking the first job moves everyone else up.
+	job, ok := converter.queue.pop()
+	require.True(t, ok)
+	converter.updateQueuePositions()
+
+	status, _ = store.GetStatus(job.ConversionID)
+	assert.Zero(t, status.QueuePosition)
+	status, _ = store.GetStatus("job-2")
+	assert.Equal(t, 1, status.QueuePosition)
+	status, _ = store.GetStatus("job-30")
+	assert.Equal(t, 29, status.QueuePosition)
+}
+
+func TestResolvePriority(t *testing.T) {
+	tests := []struct {
+		name    string
+		job     models.ConversionJob
+		want    string
+		wantErr bool
+	}{
+		{name: "defaults to normal", job: models.ConversionJob{}, want: models.PriorityNormal},
+		{name: "explicit bulk", job: models.ConversionJob{Priority: models.PriorityBulk}, want: models.PriorityBulk},
+		{
+			name: "silence preview defaults to interactive",
+			job:  models.ConversionJob{JobType: models.JobTypeSilence, Silence: &models.SilenceOptions{PreviewOnly: true}},
+			want: models.PriorityInteractive,
+		},
+		{
+			name: "explicit priority overrides the default",
+			job:  models.ConversionJob{JobType: models.JobTypeSilence, Silence: &models.SilenceOptions{PreviewOnly: true}, Priority: models.PriorityBulk},
+			want: models.PriorityBulk,
+		},
+		{name: "unknown priority", job: models.ConversionJob{Priority: "urgent"}, wantErr: true},
+		{name: "interactive conversion", job: models.ConversionJob{Priority: models.PriorityInteractive}, wantErr: true},
+		{
+			name:    "interactive silence removal",
+			job:     models.ConversionJob{JobType: models.JobTypeSilence, Silence: &models.SilenceOptions{}, Priority: models.PriorityInteractive},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolvePriority(tt.job)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// popAll drains the queue and returns the IDs in dispatch order.
+func popAll(t *testing.T, q *jobQueue) []string {
+	t.Helper()
+	var ids []string
+	for q.len() > 0 {
+		job, ok := q.pop()
+		require.True(t, ok)
+		ids = append(ids, job.ConversionID)
+	}
+	return ids
+}
+
+func TestJobQueueFairScheduling(t *testing.T) {
+	t.Run("a batch does not starve another client", func(t *testing.T) {
+		q := newJobQueue(20)
+		for i := 1; i <= 5; i++ {
+			require.NoError(t, q.push(models.ConversionJob{ConversionID: fmt.Sprintf("batch-%d", i), ClientID: "ip:a", Priority: models.PriorityNormal}))
+		}
+		require.NoError(t, q.push(models.ConversionJob{ConversionID: "single", ClientID: "ip:b", Priority: models.PriorityNormal}))
+
+		// The single job is scheduled alongside the first job of the batch instead of after all of it.
+		assert.Equal(t, []string{"batch-1", "single", "batch-2", "batch-3", "batch-4", "batch-5"}, popAll(t, q))
+	})
+
+	t.Run("interactive jobs jump the queue", func(t *testing.T) {
+		q := newJobQueue(20)
+		require.NoError(t, q.push(models.ConversionJob{ConversionID: "normal-1", ClientID: "ip:a", Priority: models.PriorityNormal}))
+		require.NoError(t, q.push(models.ConversionJob{ConversionID: "normal-2", ClientID: "ip:b", Priority: models.PriorityNormal}))
+		require.NoError(t, q.push(models.ConversionJob{ConversionID: "preview", ClientID: "ip:a", Priority: models.PriorityInteractive}))
+
+		assert.Equal(t, []string{"preview", "normal-1", "normal-2"}, popAll(t, q))
+	})
+
+	t.Run("bulk jobs get a smaller share than normal jobs", func(t *testing.T) {
+		q := newJobQueue(20)
+		for i := 1; i <= 3; i++ {
+			require.NoError(t, q.push(models.ConversionJob{ConversionID: fmt.Sprintf("bulk-%d", i), ClientID: "ip:a", Priority: models.PriorityBulk}))
+		}
+		for i := 1; i <= 4; i++ {
+			require.NoError(t, q.push(models.ConversionJob{ConversionID: fmt.Sprintf("normal-%d", i), ClientID: "ip:b", Priority: models.PriorityNormal}))
+		}
+
+		// Normal jobs are weighted four times as heavily as bulk jobs.
+		assert.Equal(t, []string{"normal-1", "normal-2", "normal-3", "bulk-1", "normal-4", "bulk-2", "bulk-3"}, popAll(t, q))
+	})
+
+	t.Run("a late client is interleaved with earlier batches", func(t *testing.T) {
+		q := newJobQueue(20)
+		for i := 1; i <= 4; i++ {
+			require.NoError(t, q.push(models.ConversionJob{ConversionID: fmt.Sprintf("a-%d", i), ClientID: "ip:a", Priority: models.PriorityNormal}))
+		}
+		for i := 0; i < 3; i++ {
+			_, ok := q.pop()
+			require.True(t, ok)
+		}
+
+		// Client b starts from the current virtual time: it shares the workers with the rest
+		// of client a's batch rather than waiting for it or claiming the time it was idle.
+		require.NoError(t, q.push(models.ConversionJob{ConversionID: "b-1", ClientID: "ip:b", Priority: models.PriorityNormal}))
+		require.NoError(t, q.push(models.ConversionJob{ConversionID: "b-2", ClientID: "ip:b", Priority: models.PriorityNormal}))
+		require.NoError(t, q.push(models.ConversionJob{ConversionID: "b-3", ClientID: "ip:b", Priority: models.PriorityNormal}))
+		assert.Equal(t, []string{"b-1", "a-4", "b-2", "b-3"}, popAll(t, q))
+	})
+}
+
+func TestQueueReasons(t *testing.T) {
+	store := NewStore()
+	converter := NewVideoConverter(1, 10, store)
+
+	queue := func(id, client, priority string) {
+		status := newQueuedStatus()
+		store.SetStatus(id, status)
+		require.NoError(t, converter.QueueJob(models.ConversionJob{ConversionID: id, ClientID: client, Priority: priority, Status: status}))
+	}
+	queue("a-1", "ip:a", models.PriorityNormal)
+	queue("a-2", "ip:a", models.PriorityNormal)
+	queue("b-1", "ip:b", models.PriorityNormal)
+	queue("preview", "ip:b", models.PriorityInteractive)
+
+	tests := []struct {
+		id       string
+		position int
+		reason   string
+	}{
+		{"preview", 1, "interactive priority, next to run; workers are shared fairly between 2 clients"},
+		{"a-1", 2, "normal priority, 1 jobs ahead; workers are shared fairly between 2 clients"},
+		{"b-1", 3, "normal priority, 2 jobs ahead (1 from the same client); workers are shared fairly between 2 clients"},
+		{"a-2", 4, "normal priority, 3 jobs ahead (1 from the same client); workers are shared fairly between 2 clients"},
+	}
+	for _, tt := range tests {
+		status, ok := store.GetStatus(tt.id)
+		require.True(t, ok)
+		assert.Equal(t, tt.position, status.QueuePosition, tt.id)
+		assert.Equal(t, tt.reason, status.QueueReason, tt.id)
+	}
+
+	_, ok := converter.queue.pop()
+	require.True(t, ok)
+	converter.updateQueuePositions()
+	status, _ := store.GetStatus("preview")
+	assert.Zero(t, status.QueuePosition)
+	assert.Empty(t, status.QueueReason)
+}
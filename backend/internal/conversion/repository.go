@@ -0,0 +1,260 @@
+package conversion
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+// Repository persists job records so that job history survives restarts.
+// Implementations must be safe for concurrent use.
+type Repository interface {
+	// Save stores the latest state of a job, replacing any previous record.
+	Save(record models.JobRecord) error
+	// Delete removes the record of a job. Deleting an unknown job is not an error.
+	Delete(id string) error
+	// LoadAll returns every stored record, oldest job first.
+	LoadAll() ([]models.JobRecord, error)
+}
+
+// MemoryRepository is a Repository that keeps records in memory only.
+type MemoryRepository struct {
+	mu      sync.Mutex
+	records map[string]models.JobRecord
+}
+
+// NewMemoryRepository creates an empty in-memory repository.
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{records: make(map[string]models.JobRecord)}
+}
+
+// Save stores a record in memory.
+func (r *MemoryRepository) Save(record models.JobRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[record.ID] = record
+	return nil
+}
+
+// Delete removes a record from memory.
+func (r *MemoryRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.records, id)
+	return nil
+}
+
+// LoadAll returns all records held in memory.
+func (r *MemoryRepository) LoadAll() ([]models.JobRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return sortedRecords(r.records), nil
+}
+
+// journalEntry is a single line of the JSON journal.
+type journalEntry struct {
+	Op     string            `json:"op"` // "put" or "delete"
+	ID     string            `json:"id"`
+	Record *models.JobRecord `json:"record,omitempty"`
+}
+
+// JSONRepository is a Repository backed by an append-only journal of JSON lines.
+// Each line records the latest state of one job or its removal. The journal is
+// replayed and compacted when opened, and compacted again once it has grown
+// well beyond the number of jobs it describes.
+type JSONRepository struct {
+	path    string
+	mu      sync.Mutex
+	file    *os.File
+	records map[string]models.JobRecord
+	entries int // Lines in the journal file
+}
+
+// OpenJSONRepository opens (or creates) the journal at path and replays it.
+func OpenJSONRepository(path string) (*JSONRepository, error) {
+	r := &JSONRepository{path: path, records: make(map[string]models.JobRecord)}
+	if err := r.replay(); err != nil {
+		return nil, err
+	}
+	if err := r.compact(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// replay reads the journal into memory. A malformed line, typically the result of a
+// crash in the middle of a write, is skipped.
+func (r *JSONRepository) replay() error {
+	file, err := os.Open(r.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open job journal: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			log.Printf("WARN: Error closing job journal %s: %v", r.path, closeErr)
+		}
+	}()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Printf("WARN: Skipping malformed job journal line %d: %v", line, err)
+			continue
+		}
+		switch {
+		case entry.Op == "put" && entry.Record != nil:
+			r.records[entry.ID] = *entry.Record
+		case entry.Op == "delete":
+			delete(r.records, entry.ID)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read job journal: %w", err)
+	}
+	return nil
+}
+
+// compact rewrites the journal with one entry per stored job and reopens it for appending.
+func (r *JSONRepository) compact() error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			log.Printf("WARN: Error closing job journal %s: %v", r.path, err)
+		}
+		r.file = nil
+	}
+
+	tmpPath := r.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, constants.FilePermissions)
+	if err != nil {
+		return fmt.Errorf("failed to create job journal: %w", err)
+	}
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	records := sortedRecords(r.records)
+	for i := range records {
+		if err := encoder.Encode(journalEntry{Op: "put", ID: records[i].ID, Record: &records[i]}); err != nil {
+			_ = tmp.Close()
+			return fmt.Errorf("failed to write job journal: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write job journal: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to sync job journal: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close job journal: %w", err)
+	}
+	if err := os.Rename(tmpPath, r.path); err != nil {
+		return fmt.Errorf("failed to replace job journal: %w", err)
+	}
+
+	file, err := os.OpenFile(r.path, os.O_APPEND|os.O_WRONLY, constants.FilePermissions)
+	if err != nil {
+		return fmt.Errorf("failed to open job journal: %w", err)
+	}
+	r.file = file
+	r.entries = len(records)
+	return nil
+}
+
+// append writes one entry to the journal, compacting it when it has grown too large.
+func (r *JSONRepository) append(entry journalEntry) error {
+	if r.file == nil {
+		return fmt.Errorf("job journal is closed")
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode job %s: %w", entry.ID, err)
+	}
+	if _, err := r.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to append to job journal: %w", err)
+	}
+	r.entries++
+
+	if r.entries >= constants.JobJournalCompactMinEntries && r.entries > constants.JobJournalCompactRatio*len(r.records) {
+		return r.compact()
+	}
+	return nil
+}
+
+// Save appends the latest state of a job to the journal.
+func (r *JSONRepository) Save(record models.JobRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[record.ID] = record
+	return r.append(journalEntry{Op: "put", ID: record.ID, Record: &record})
+}
+
+// Delete appends the removal of a job to the journal.
+func (r *JSONRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.records[id]; !exists {
+		return nil
+	}
+	delete(r.records, id)
+	return r.append(journalEntry{Op: "delete", ID: id})
+}
+
+// LoadAll returns the records replayed from the journal and saved since.
+func (r *JSONRepository) LoadAll() ([]models.JobRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return sortedRecords(r.records), nil
+}
+
+// Close closes the journal file. The repository must not be used afterwards.
+func (r *JSONRepository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// sortedRecords returns the records ordered by job creation time, oldest first.
+func sortedRecords(records map[string]models.JobRecord) []models.JobRecord {
+	sorted := make([]models.JobRecord, 0, len(records))
+	for _, record := range records {
+		sorted = append(sorted, record)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		ci, cj := recordCreatedAt(sorted[i]), recordCreatedAt(sorted[j])
+		if !ci.Equal(cj) {
+			return ci.Before(cj)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted
+}
+
+// recordCreatedAt returns when a job was created: the start of its first phase,
+// or the time of its last update for records without phase history.
+func recordCreatedAt(record models.JobRecord) time.Time {
+	if len(record.Status.Phases) > 0 {
+		return record.Status.Phases[0].StartedAt
+	}
+	return record.UpdatedAt
+}
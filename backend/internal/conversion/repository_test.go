@@ -0,0 +1,146 @@
+package conversion
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRecord(id string, created time.Time) models.JobRecord {
+	return models.JobRecord{
+		ID: id,
+		Job: &models.ConversionJob{
+			ConversionID:     id,
+			TargetFormat:     "mp4",
+			UploadedFilePath: "/uploads/" + id + ".mov",
+			OutputFilePath:   "/converted/" + id + ".mp4",
+			Filters:          &models.FilterOptions{MaxHeight: 720},
+		},
+		Status: models.ConversionStatus{
+			Format: "mp4",
+			Phase:  models.PhaseQueued,
+			Phases: []models.PhaseRecord{{Phase: models.PhaseQueued, StartedAt: created}},
+		},
+		UpdatedAt: created,
+	}
+}
+
+func TestJSONRepository(t *testing.T) {
+	t.Run("records survive reopening", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jobs.journal")
+		repo, err := OpenJSONRepository(path)
+		require.NoError(t, err)
+
+		now := time.Now().UTC().Truncate(time.Second)
+		require.NoError(t, repo.Save(testRecord("second", now.Add(time.Minute))))
+		require.NoError(t, repo.Save(testRecord("first", now)))
+		require.NoError(t, repo.Save(testRecord("gone", now)))
+		require.NoError(t, repo.Delete("gone"))
+
+		done := testRecord("first", now)
+		done.Status.Complete = true
+		done.Status.Phase = models.PhaseSucceeded
+		require.NoError(t, repo.Save(done))
+		require.NoError(t, repo.Close())
+
+		reopened, err := OpenJSONRepository(path)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		records, err := reopened.LoadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, "first", records[0].ID, "records are ordered by creation time")
+		assert.Equal(t, models.PhaseSucceeded, records[0].Status.Phase)
+		assert.True(t, records[0].Status.Complete)
+		assert.Equal(t, "second", records[1].ID)
+		require.NotNil(t, records[1].Job)
+		require.NotNil(t, records[1].Job.Filters)
+		assert.Equal(t, 720, records[1].Job.Filters.MaxHeight)
+		assert.Nil(t, records[1].Job.Status)
+	})
+
+	t.Run("opening compacts the journal", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jobs.journal")
+		repo, err := OpenJSONRepository(path)
+		require.NoError(t, err)
+		for i := 0; i < 5; i++ {
+			require.NoError(t, repo.Save(testRecord("job", time.Now())))
+		}
+		require.NoError(t, repo.Close())
+
+		reopened, err := OpenJSONRepository(path)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, 1, countLines(data))
+	})
+
+	t.Run("torn trailing write is skipped", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jobs.journal")
+		repo, err := OpenJSONRepository(path)
+		require.NoError(t, err)
+		require.NoError(t, repo.Save(testRecord("job", time.Now())))
+		require.NoError(t, repo.Close())
+
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+		require.NoError(t, err)
+		_, err = file.WriteString(`{"op":"put","id":"half","record":{"id":"ha`)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+
+		reopened, err := OpenJSONRepository(path)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		records, err := reopened.LoadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, "job", records[0].ID)
+	})
+}
+
+func countLines(data []byte) int {
+	lines := 0
+	for _, b := range data {
+		if b == '\n' {
+			lines++
+		}
+	}
+	return lines
+}
+
+func TestStorePersistence(t *testing.T) {
+	repo := NewMemoryRepository()
+	store := NewStoreWithRepository(repo)
+
+	store.SetStatus("job", newQueuedStatus())
+	store.TrackJob(models.ConversionJob{ConversionID: "job", TargetFormat: "mp4", Status: &models.ConversionStatus{}})
+	store.SetPhase("job", models.PhaseEncoding, 100)
+	store.SetProgressPercentage("job", 42)
+
+	records, err := repo.LoadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.NotNil(t, records[0].Job)
+	assert.Equal(t, "mp4", records[0].Job.TargetFormat)
+	assert.Nil(t, records[0].Job.Status)
+	assert.Equal(t, models.PhaseEncoding, records[0].Status.Phase)
+	assert.Zero(t, records[0].Status.Progress, "live progress is not persisted")
+
+	store.UpdateStatusOnSuccess("job")
+	records, _ = repo.LoadAll()
+	assert.Equal(t, models.PhaseSucceeded, records[0].Status.Phase)
+	assert.Equal(t, 100.0, records[0].Status.Progress)
+
+	store.DeleteStatus("job")
+	records, _ = repo.LoadAll()
+	assert.Empty(t, records)
+}
@@ -0,0 +1,103 @@
+package conversion
+
+import (
+	"log"
+	"os"
+
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+// interruptedMessage is the error recorded on jobs a restart stopped for good.
+const interruptedMessage = "Interrupted by a server restart"
+
+// RestoreJobs loads the jobs persisted by a previous run into the store. Finished jobs
+// are kept as history. Jobs that were still queued or running are requeued from the
+// start when their input file is still present, and marked as interrupted otherwise.
+// Drive downloads that were cut short resume from the bytes already downloaded.
+// It must be called after Start and before new jobs are accepted.
+func (c *VideoConverter) RestoreJobs() error {
+	records, err := c.store.LoadRecords()
+	if err != nil {
+		return err
+	}
+
+	requeued, interrupted := 0, 0
+	for _, record := range records {
+		if record.Status.Complete {
+			continue
+		}
+		if c.requeueRecord(record) {
+			requeued++
+		} else {
+			interrupted++
+		}
+	}
+	log.Printf("Restored %d jobs from the job store (%d requeued, %d interrupted)", len(records), requeued, interrupted)
+	return nil
+}
+
+// requeueRecord requeues an unfinished job from a previous run or resumes its Drive
+// download, and otherwise marks it as interrupted and removes its leftover files. It
+// reports whether the job was requeued.
+func (c *VideoConverter) requeueRecord(record models.JobRecord) bool {
+	id := record.ID
+
+	var job models.ConversionJob
+	if record.Job != nil {
+		job = *record.Job
+	} else {
+		// The job never reached the queue, e.g. its Drive download was cut short.
+		job = models.ConversionJob{ConversionID: id, UploadedFilePath: record.Status.InputPath, OutputFilePath: record.Status.OutputPath}
+	}
+
+	// A partially written output would make FFmpeg refuse to start again.
+	removeStaleOutput(id, job.OutputFilePath)
+
+	if record.Job != nil && job.DownloadPending {
+		// Whatever was downloaded before the restart is kept, and the download resumes from there.
+		if job.Status = c.store.ResetForRequeue(id); job.Status != nil {
+			if err := c.SubmitDriveJob(job); err == nil {
+				log.Printf("Resumed download of job %s interrupted by a restart", id)
+				return true
+			}
+			log.Printf("WARN [job %s]: Could not resume download interrupted by a restart: queue is full", id)
+		}
+		// The partial source cannot be converted, so it must not be requeued below.
+		c.store.UpdateStatusWithError(id, models.ErrorCodeInterrupted, interruptedMessage)
+		c.removePartialDownload(job)
+		log.Printf("Marked job %s as interrupted", id)
+		return false
+	}
+
+	if record.Job != nil && fileExists(job.UploadedFilePath) {
+		job.Status = c.store.ResetForRequeue(id)
+		if job.Status != nil {
+			if err := c.QueueJob(job); err == nil {
+				log.Printf("Requeued job %s interrupted by a restart", id)
+				return true
+			}
+			log.Printf("WARN [job %s]: Could not requeue job interrupted by a restart: queue is full", id)
+		}
+	}
+
+	c.store.UpdateStatusWithError(id, models.ErrorCodeInterrupted, interruptedMessage)
+	c.removeInputFiles(job)
+	log.Printf("Marked job %s as interrupted", id)
+	return false
+}
+
+// removeStaleOutput removes the output an interrupted job left behind.
+func removeStaleOutput(conversionID, outputPath string) {
+	if outputPath == "" {
+		return
+	}
+	if err := os.Remove(outputPath); err != nil && !os.IsNotExist(err) {
+		log.Printf("WARN [job %s]: Failed to remove stale output file %s: %v", conversionID, outputPath, err)
+	}
+}
+
+// fileExists reports whether path names an existing regular file.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
@@ -0,0 +1,87 @@
+package conversion
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreJobs(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	finished := testRecord("finished", now)
+	finished.Status.Complete = true
+	finished.Status.Phase = models.PhaseSucceeded
+
+	// Mid-encode with its input still present: requeued from the start.
+	encoding := testRecord("encoding", now.Add(time.Second))
+	encoding.Job.UploadedFilePath = filepath.Join(dir, "encoding.mov")
+	encoding.Job.OutputFilePath = filepath.Join(dir, "encoding.mp4")
+	encoding.Status.Phase = models.PhaseEncoding
+	encoding.Status.Progress = 60
+	encoding.Status.Phases = append(encoding.Status.Phases, models.PhaseRecord{Phase: models.PhaseEncoding, StartedAt: now})
+	require.NoError(t, os.WriteFile(encoding.Job.UploadedFilePath, []byte("input"), 0o644))
+	require.NoError(t, os.WriteFile(encoding.Job.OutputFilePath, []byte("partial"), 0o644))
+
+	// Input gone: marked interrupted.
+	missing := testRecord("missing", now.Add(2*time.Second))
+	missing.Job.UploadedFilePath = filepath.Join(dir, "missing.mov")
+
+	// Drive download cut short before the job was queued: marked interrupted, partial download removed.
+	downloading := testRecord("downloading", now.Add(3*time.Second))
+	downloading.Job = nil
+	downloading.Status.InputPath = filepath.Join(dir, "downloading.mov")
+	downloading.Status.Phase = models.PhaseDownloading
+	require.NoError(t, os.WriteFile(downloading.Status.InputPath, []byte("partial"), 0o644))
+
+	repo := NewMemoryRepository()
+	for _, record := range []models.JobRecord{finished, encoding, missing, downloading} {
+		require.NoError(t, repo.Save(record))
+	}
+
+	store := NewStoreWithRepository(repo)
+	converter := NewVideoConverter(1, 10, store) // Workers are not started, so requeued jobs stay in the queue
+	require.NoError(t, converter.RestoreJobs())
+
+	status, ok := store.GetStatus("finished")
+	require.True(t, ok)
+	assert.Equal(t, models.PhaseSucceeded, status.Phase)
+
+	status, ok = store.GetStatus("encoding")
+	require.True(t, ok)
+	assert.False(t, status.Complete)
+	assert.Equal(t, models.PhaseQueued, status.Phase)
+	assert.Zero(t, status.Progress)
+	require.Len(t, status.Phases, 3)
+	assert.NotNil(t, status.Phases[1].EndedAt, "the interrupted phase is closed")
+	assert.NoFileExists(t, filepath.Join(dir, "encoding.mp4"), "stale output is removed")
+	assert.Equal(t, 1, status.QueuePosition)
+	require.Equal(t, []string{"encoding"}, converter.queue.ids())
+	queued, _ := converter.queue.pop()
+	assert.Equal(t, "encoding", queued.ConversionID)
+	require.NotNil(t, queued.Status)
+	assert.Equal(t, models.PhaseQueued, queued.Status.Phase)
+
+	for _, id := range []string{"missing", "downloading"} {
+		status, ok = store.GetStatus(id)
+		require.True(t, ok, id)
+		assert.True(t, status.Complete, id)
+		assert.Equal(t, models.ErrorCodeInterrupted, status.ErrorCode, id)
+		assert.Equal(t, models.PhaseFailed, status.Phase, id)
+	}
+	assert.NoFileExists(t, filepath.Join(dir, "downloading.mov"))
+
+	records, err := repo.LoadAll()
+	require.NoError(t, err)
+	for _, record := range records {
+		if record.ID == "missing" {
+			assert.Equal(t, models.ErrorCodeInterrupted, record.Status.ErrorCode, "outcome is persisted")
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package conversion
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceRetention(t *testing.T) {
+	finish := map[string]func(store *Store, id string){
+		"succeeded": func(store *Store, id string) { store.UpdateStatusOnSuccess(id) },
+		"failed": func(store *Store, id string) {
+			store.UpdateStatusWithError(id, models.ErrorCodeConversionFailed, "boom")
+		},
+		"canceled": func(store *Store, id string) { store.UpdateStatusCanceled(id) },
+	}
+
+	tests := []struct {
+		policy  string
+		outcome string
+		kept    bool
+	}{
+		{models.SourceRetentionNone, "succeeded", false},
+		{models.SourceRetentionNone, "failed", false},
+		{models.SourceRetentionFailed, "succeeded", false},
+		{models.SourceRetentionFailed, "failed", true},
+		{models.SourceRetentionFailed, "canceled", false},
+		{models.SourceRetentionAll, "succeeded", true},
+		{models.SourceRetentionAll, "failed", true},
+		{models.SourceRetentionAll, "canceled", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy+"/"+tt.outcome, func(t *testing.T) {
+			store := NewStore()
+			converter := NewVideoConverter(1, 10, store)
+			converter.SetSourceRetention(tt.policy)
+
+			dir := t.TempDir()
+			job := models.ConversionJob{ConversionID: "job", UploadedFilePath: filepath.Join(dir, "input.mov")}
+			require.NoError(t, os.WriteFile(job.UploadedFilePath, []byte("input"), 0o644))
+			require.NoError(t, os.WriteFile(TransformsFilePath(job.UploadedFilePath), []byte("transforms"), 0o644))
+			store.SetStatus("job", newQueuedStatus())
+			finish[tt.outcome](store, "job")
+
+			converter.removeInputFiles(job)
+
+			if tt.kept {
+				assert.FileExists(t, job.UploadedFilePath)
+			} else {
+				assert.NoFileExists(t, job.UploadedFilePath)
+			}
+			assert.NoFileExists(t, TransformsFilePath(job.UploadedFilePath), "intermediate files are always removed")
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package conversion
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+// ThumbnailURLPrefix is the URL prefix under which scene thumbnails are served.
+// It mirrors the API thumbnail route.
+const ThumbnailURLPrefix = "/api/conversion/thumbnail/"
+
+var showinfoPTSRegex = regexp.MustCompile(`pts_time:\s*([0-9]+(?:\.[0-9]+)?)`)
+
+// ValidateSceneOptions checks the options of a scene detection job.
+func ValidateSceneOptions(job models.ConversionJob) error {
+	if job.Stabilization != nil {
+		return fmt.Errorf("stabilization is not supported for scene detection jobs")
+	}
+	opts := job.Scenes
+	if opts == nil {
+		return nil
+	}
+	if opts.Threshold < 0 || opts.Threshold >= 1 {
+		return fmt.Errorf("scene threshold must be between 0 and 1")
+	}
+	switch opts.Split {
+	case models.SceneSplitNone, models.SceneSplitScenes:
+	case models.SceneSplitChunks:
+		if opts.ChunkSeconds < constants.SceneMinChunkSeconds {
+			return fmt.Errorf("chunkSeconds must be at least %g", constants.SceneMinChunkSeconds)
+		}
+	default:
+		return fmt.Errorf("unsupported scene split mode '%s'", opts.Split)
+	}
+	return nil
+}
+
+// sceneOptions returns the job's scene options with defaults applied.
+func sceneOptions(job models.ConversionJob) models.SceneOptions {
+	opts := models.SceneOptions{}
+	if job.Scenes != nil {
+		opts = *job.Scenes
+	}
+	if opts.Threshold == 0 {
+		opts.Threshold = constants.SceneDefaultThreshold
+	}
+	return opts
+}
+
+// buildSceneDetectionArgs builds the analysis pass that logs the timestamp of every frame
+// whose scene change score exceeds the threshold. showinfo writes at info level to stderr.
+func buildSceneDetectionArgs(job models.ConversionJob) []string {
+	opts := sceneOptions(job)
+	return []string{
+		"-i", job.UploadedFilePath,
+		"-threads", strconv.Itoa(ffmpegThreadCount()),
+		"-progress", "pipe:1",
+		"-nostats",
+		"-v", "info",
+		"-vf", fmt.Sprintf("select='gt(scene,%s)',showinfo", formatFilterFloat(opts.Threshold)),
+		"-an",
+		"-f", "null", "-",
+	}
+}
+
+// buildThumbnailArgs builds the command extracting a single scaled frame at the given time.
+func buildThumbnailArgs(inputPath string, at float64, thumbnailPath string) []string {
+	return []string{
+		"-ss", formatSeconds(at),
+		"-i", inputPath,
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale=%d:-2", constants.SceneThumbnailWidth),
+		"-q:v", "4",
+		"-v", "warning",
+		"-y",
+		thumbnailPath,
+	}
+}
+
+// sceneClipPath returns the output path of the clip with the given index, derived from the job output path.
+func sceneClipPath(job models.ConversionJob, index int) string {
+	ext := filepath.Ext(job.OutputFilePath)
+	return fmt.Sprintf("%s-scene-%03d%s", strings.TrimSuffix(job.OutputFilePath, ext), index, ext)
+}
+
+// buildSceneClipArgs builds the encode of a single scene, reusing the regular encode settings
+// with an input seek so filters, quality and audio handling stay identical.
+func buildSceneClipArgs(job models.ConversionJob, scene models.SceneInfo) ([]string, error) {
+	clipJob := job
+	clipJob.OutputFilePath = sceneClipPath(job, scene.Index)
+	encodeArgs, err := buildFFmpegArgs(clipJob, resolveJobQuality(job))
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{"-ss", formatSeconds(scene.Start), "-t", formatSeconds(scene.End - scene.Start)}, encodeArgs...), nil
+}
+
+// parseSceneCuts extracts the scene change timestamps logged by showinfo, sorted and de-duplicated.
+func parseSceneCuts(ffmpegOutput string) []float64 {
+	var cuts []float64
+	for _, line := range strings.Split(ffmpegOutput, "\n") {
+		if !strings.Contains(line, "Parsed_showinfo") {
+			continue
+		}
+		match := showinfoPTSRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if value, err := strconv.ParseFloat(match[1], 64); err == nil {
+			cuts = append(cuts, value)
+		}
+	}
+	sort.Float64s(cuts)
+	return cuts
+}
+
+// buildScenes turns cut timestamps into contiguous scenes covering [0, duration].
+// Scenes shorter than the minimum length are merged into the previous scene.
+func buildScenes(cuts []float64, duration float64) []models.SceneInfo {
+	boundaries := []float64{0}
+	for _, cut := range cuts {
+		if cut-boundaries[len(boundaries)-1] < constants.SceneMinSeconds {
+			continue
+		}
+		if duration > 0 && duration-cut < constants.SceneMinSeconds {
+			continue
+		}
+		boundaries = append(boundaries, cut)
+	}
+	if duration > 0 {
+		boundaries = append(boundaries, duration)
+	}
+
+	scenes := make([]models.SceneInfo, 0, len(boundaries))
+	for i := 0; i+1 < len(boundaries); i++ {
+		scenes = append(scenes, models.SceneInfo{Index: i + 1, Start: boundaries[i], End: boundaries[i+1]})
+	}
+	return scenes
+}
+
+// buildChunks splits [0, duration] into consecutive chunks of the given length.
+func buildChunks(duration, chunkSeconds float64) []models.SceneInfo {
+	count := int(math.Ceil(duration / chunkSeconds))
+	chunks := make([]models.SceneInfo, 0, count)
+	for i := 0; i < count; i++ {
+		start := float64(i) * chunkSeconds
+		chunks = append(chunks, models.SceneInfo{Index: i + 1, Start: start, End: math.Min(start+chunkSeconds, duration)})
+	}
+	return chunks
+}
+
+// scenePlanCommands returns the commands of a scene detection job for the execution plan.
+// Per-scene commands depend on the detection result, so they are listed as templates.
+func scenePlanCommands(job models.ConversionJob) ([]models.CommandInvocation, error) {
+	if err := ValidateSceneOptions(job); err != nil {
+		return nil, err
+	}
+	if err := ValidateFilters(job.Filters); err != nil {
+		return nil, err
+	}
+
+	opts := sceneOptions(job)
+	var commands []models.CommandInvocation
+	if opts.Split != models.SceneSplitChunks {
+		commands = append(commands, models.CommandInvocation{
+			Program:     "ffmpeg",
+			Args:        buildSceneDetectionArgs(job),
+			Description: "Detect scene changes",
+		})
+	}
+
+	template := models.SceneInfo{Index: 1, Start: 0, End: 1}
+	commands = append(commands, models.CommandInvocation{
+		Program:     "ffmpeg",
+		Args:        buildThumbnailArgs(job.UploadedFilePath, 0, filepath.Join(job.ThumbnailsDir, "scene-001.jpg")),
+		Description: "Extract a thumbnail for each scene (repeated per scene)",
+	})
+	if opts.Split != models.SceneSplitNone {
+		clipArgs, err := buildSceneClipArgs(job, template)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, models.CommandInvocation{
+			Program:     "ffmpeg",
+			Args:        clipArgs,
+			Description: "Encode each scene to its own file (repeated per scene)",
+		})
+	}
+	return commands, nil
+}
+
+// detectScenes runs a scene detection job: it finds scene boundaries (or fixed chunks),
+// extracts a thumbnail per scene and optionally encodes every scene into its own file.
+func (c *VideoConverter) detectScenes(job models.ConversionJob) {
+	conversionID := job.ConversionID
+	opts := sceneOptions(job)
+
+	status, _ := c.store.GetStatus(conversionID)
+	duration := status.DurationSeconds
+	if duration <= 0 {
+		c.failJob(job, models.ErrorCodeInvalidInput, "Scene detection requires a known video duration")
+		return
+	}
+
+	c.store.SetPhase(conversionID, models.PhaseEncoding, 100)
+
+	splitting := opts.Split != models.SceneSplitNone
+	detectShare := constants.SceneDetectProgressShare
+	if !splitting {
+		detectShare = 100 - constants.SceneThumbnailProgressShare
+	}
+	thumbnailShare := constants.SceneThumbnailProgressShare
+	progressStart := 0.0
+
+	var scenes []models.SceneInfo
+	if opts.Split == models.SceneSplitChunks {
+		scenes = buildChunks(duration, opts.ChunkSeconds)
+		detectShare = 0
+	} else {
+		c.store.SetCurrentStep(conversionID, "Detect scene changes")
+		output, err := c.runFFmpeg(conversionID, buildSceneDetectionArgs(job), progressRange{start: 0, share: detectShare})
+		if err != nil {
+			c.handleFFmpegFailure(job, err, output)
+			return
+		}
+		scenes = buildScenes(parseSceneCuts(output), duration)
+	}
+	progressStart += detectShare
+	log.Printf("Job %s: %d scenes", conversionID, len(scenes))
+
+	// --- Thumbnails ---
+	c.store.SetCurrentStep(conversionID, "Extract scene thumbnails")
+	if err := os.MkdirAll(job.ThumbnailsDir, constants.DirectoryPermissions); err != nil {
+		c.failJob(job, storageErrorCode(err), fmt.Sprintf("Failed to create thumbnail directory: %v", err))
+		return
+	}
+	for i := range scenes {
+		thumbName := fmt.Sprintf("scene-%03d.jpg", scenes[i].Index)
+		midpoint := scenes[i].Start + (scenes[i].End-scenes[i].Start)/2
+		args := buildThumbnailArgs(job.UploadedFilePath, midpoint, filepath.Join(job.ThumbnailsDir, thumbName))
+		if output, err := c.runFFmpeg(conversionID, args, progressRange{}); err != nil {
+			// A missing thumbnail should not fail the whole job.
+			if c.isCanceled(conversionID) {
+				c.handleFFmpegFailure(job, err, output)
+				return
+			}
+			log.Printf("WARN [job %s]: Failed to extract thumbnail for scene %d: %v", conversionID, scenes[i].Index, err)
+		} else {
+			scenes[i].ThumbnailURL = ThumbnailURLPrefix + conversionID + "/" + thumbName
+		}
+		c.store.SetProgressPercentage(conversionID, progressStart+thumbnailShare*float64(i+1)/float64(len(scenes)))
+	}
+	progressStart += thumbnailShare
+	c.store.SetScenes(conversionID, scenes)
+
+	// --- Optional split ---
+	if splitting {
+		encodeShare := 100 - progressStart
+		for i := range scenes {
+			clipArgs, err := buildSceneClipArgs(job, scenes[i])
+			if err != nil {
+				c.failJob(job, models.ErrorCodeInvalidOptions, fmt.Sprintf("Invalid conversion options: %v", err))
+				return
+			}
+			clipPath := sceneClipPath(job, scenes[i].Index)
+			clipDuration := scenes[i].End - scenes[i].Start
+			share := encodeShare * clipDuration / duration
+
+			c.store.SetCurrentStep(conversionID, fmt.Sprintf("Encode scene %d of %d", i+1, len(scenes)))
+			c.store.SetCurrentOutput(conversionID, clipPath)
+			output, err := c.runFFmpeg(conversionID, clipArgs, progressRange{start: progressStart, share: share, duration: clipDuration})
+			if err != nil {
+				c.removeSceneOutputs(job, scenes[:i+1])
+				c.handleFFmpegFailure(job, err, output)
+				return
+			}
+			progressStart += share
+
+			info, err := os.Stat(clipPath)
+			if err != nil || info.Size() == 0 {
+				c.removeSceneOutputs(job, scenes[:i+1])
+				c.failJob(job, models.ErrorCodeConversionFailed, fmt.Sprintf("FFmpeg finished but scene %d output is missing or empty", scenes[i].Index))
+				return
+			}
+			scenes[i].FileName = filepath.Base(clipPath)
+			c.store.AddOutput(conversionID, models.OutputFile{
+				FileName:    scenes[i].FileName,
+				Size:        info.Size(),
+				DownloadURL: DownloadURLPrefix + scenes[i].FileName,
+			})
+		}
+		c.store.SetCurrentOutput(conversionID, "")
+		c.store.SetScenes(conversionID, scenes)
+	}
+
+	c.store.UpdateStatusOnSuccess(conversionID)
+	log.Printf("Scene detection successful for job %s: %d scenes, split=%q", conversionID, len(scenes), opts.Split)
+	c.removeInputFiles(job)
+}
+
+// failJob marks a job as failed with the given error code and message and removes its input files.
+func (c *VideoConverter) failJob(job models.ConversionJob, code, errMsg string) {
+	log.Printf("ERROR [job %s]: %s", job.ConversionID, errMsg)
+	c.store.UpdateStatusWithError(job.ConversionID, code, errMsg)
+	c.removeInputFiles(job)
+}
+
+// removeSceneOutputs deletes clips written for the given scenes after a failed split.
+func (c *VideoConverter) removeSceneOutputs(job models.ConversionJob, scenes []models.SceneInfo) {
+	for _, scene := range scenes {
+		clipPath := sceneClipPath(job, scene.Index)
+		if err := os.Remove(clipPath); err != nil && !os.IsNotExist(err) {
+			log.Printf("WARN [job %s]: Failed to remove scene output %s: %v", job.ConversionID, clipPath, err)
+		}
+	}
+}
+
+// isCanceled reports whether the job was aborted by the user.
+func (c *VideoConverter) isCanceled(conversionID string) bool {
+	status, exists := c.store.GetStatus(conversionID)
+	return exists && status.ErrorCode == models.ErrorCodeAborted
+}
+
+// formatSeconds formats a timestamp in seconds for FFmpeg with millisecond precision.
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 3, 64)
+}
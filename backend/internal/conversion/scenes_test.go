@@ -0,0 +1,95 @@
+package conversion
+
+import (
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSceneCuts(t *testing.T) {
+	output := `Input #0, mov,mp4,m4a,3gp,3g2,mj2, from 'in.mov':
+[Parsed_showinfo_1 @ 0x55d0c] n:   0 pts:  90090 pts_time:3.003   duration:   3003 fmt:yuv420p
+[Parsed_showinfo_1 @ 0x55d0c] n:   1 pts: 450450 pts_time:15.015  duration:   3003 fmt:yuv420p
+[Parsed_showinfo_1 @ 0x55d0c] n:   2 pts: 300300 pts_time:10.01   duration:   3003 fmt:yuv420p
+[Parsed_showinfo_1 @ 0x55d0c] config in time_base: 1/30000, frame_rate: 30000/1001
+frame=  3 fps=0.0 q=-0.0 Lsize=N/A time=00:00:15.01`
+
+	assert.Equal(t, []float64{3.003, 10.01, 15.015}, parseSceneCuts(output))
+	assert.Empty(t, parseSceneCuts("no scenes here"))
+}
+
+func TestBuildScenes(t *testing.T) {
+	scenes := buildScenes([]float64{0.2, 5, 5.5, 12, 19.8}, 20)
+
+	require.Len(t, scenes, 3)
+	assert.Equal(t, models.SceneInfo{Index: 1, Start: 0, End: 5}, scenes[0])
+	assert.Equal(t, models.SceneInfo{Index: 2, Start: 5, End: 12}, scenes[1])
+	assert.Equal(t, models.SceneInfo{Index: 3, Start: 12, End: 20}, scenes[2])
+
+	single := buildScenes(nil, 8)
+	require.Len(t, single, 1)
+	assert.Equal(t, 8.0, single[0].End)
+}
+
+func TestBuildChunks(t *testing.T) {
+	chunks := buildChunks(25, 10)
+
+	require.Len(t, chunks, 3)
+	assert.Equal(t, 0.0, chunks[0].Start)
+	assert.Equal(t, 20.0, chunks[2].Start)
+	assert.Equal(t, 25.0, chunks[2].End)
+}
+
+func TestValidateSceneOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		job     models.ConversionJob
+		wantErr bool
+	}{
+		{"Defaults", models.ConversionJob{}, false},
+		{"Split Scenes", models.ConversionJob{Scenes: &models.SceneOptions{Split: models.SceneSplitScenes, Threshold: 0.3}}, false},
+		{"Chunks", models.ConversionJob{Scenes: &models.SceneOptions{Split: models.SceneSplitChunks, ChunkSeconds: 30}}, false},
+		{"Chunks Without Length", models.ConversionJob{Scenes: &models.SceneOptions{Split: models.SceneSplitChunks}}, true},
+		{"Bad Threshold", models.ConversionJob{Scenes: &models.SceneOptions{Threshold: 1.5}}, true},
+		{"Unknown Split", models.ConversionJob{Scenes: &models.SceneOptions{Split: "frames"}}, true},
+		{"With Stabilization", models.ConversionJob{Stabilization: &models.StabilizationOptions{}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSceneOptions(tt.job)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBuildPlan_Scenes(t *testing.T) {
+	job := models.ConversionJob{
+		JobType:          models.JobTypeScenes,
+		TargetFormat:     "mp4",
+		UploadedFilePath: "/uploads/talk.mov",
+		OutputFilePath:   "/converted/talk-abc.mp4",
+		ThumbnailsDir:    "/data/thumbnails/abc",
+		Scenes:           &models.SceneOptions{Split: models.SceneSplitScenes},
+	}
+
+	plan, err := BuildPlan(job)
+	require.NoError(t, err)
+	require.Len(t, plan.Commands, 4)
+	assert.Contains(t, plan.Commands[1].Args, "select='gt(scene,0.4)',showinfo")
+	assert.Contains(t, plan.Commands[2].Args, "/data/thumbnails/abc/scene-001.jpg")
+	clipArgs := plan.Commands[3].Args
+	assert.Equal(t, "-ss", clipArgs[0])
+	assert.Equal(t, "/converted/talk-abc-scene-001.mp4", clipArgs[len(clipArgs)-1])
+}
+
+func TestBuildPlan_UnknownJobType(t *testing.T) {
+	_, err := BuildPlan(models.ConversionJob{JobType: "transcribe", TargetFormat: "mp4"})
+	assert.Error(t, err)
+}
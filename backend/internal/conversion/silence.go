@@ -0,0 +1,258 @@
+package conversion
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+var (
+	silenceStartRegex = regexp.MustCompile(`silence_start:\s*(-?[0-9]+(?:\.[0-9]+)?)`)
+	silenceEndRegex   = regexp.MustCompile(`silence_end:\s*(-?[0-9]+(?:\.[0-9]+)?)`)
+)
+
+// ValidateSilenceOptions checks the options of a silence removal job.
+func ValidateSilenceOptions(job models.ConversionJob) error {
+	if job.Stabilization != nil {
+		return fmt.Errorf("stabilization is not supported for silence removal jobs")
+	}
+	if job.RemoveSound {
+		return fmt.Errorf("silence removal requires the audio track; removeSound cannot be used")
+	}
+	opts := job.Silence
+	if opts == nil {
+		return nil
+	}
+	if opts.NoiseDB > 0 || opts.NoiseDB < -90 {
+		return fmt.Errorf("silence noiseDb must be between -90 and 0")
+	}
+	if opts.MinSeconds != 0 && opts.MinSeconds < constants.SilenceMinMinSeconds {
+		return fmt.Errorf("silence minSeconds must be at least %g", constants.SilenceMinMinSeconds)
+	}
+	if opts.PaddingSeconds < 0 {
+		return fmt.Errorf("silence paddingSeconds cannot be negative")
+	}
+	return nil
+}
+
+// silenceOptions returns the job's silence options with defaults applied.
+func silenceOptions(job models.ConversionJob) models.SilenceOptions {
+	opts := models.SilenceOptions{}
+	if job.Silence != nil {
+		opts = *job.Silence
+	}
+	if opts.NoiseDB == 0 {
+		opts.NoiseDB = constants.SilenceDefaultNoiseDB
+	}
+	if opts.MinSeconds == 0 {
+		opts.MinSeconds = constants.SilenceDefaultMinSeconds
+	}
+	if opts.PaddingSeconds == 0 {
+		opts.PaddingSeconds = constants.SilenceDefaultPaddingSeconds
+	}
+	return opts
+}
+
+// buildSilenceDetectionArgs builds the analysis pass logging silent stretches of the audio track.
+// silencedetect writes at info level to stderr.
+func buildSilenceDetectionArgs(job models.ConversionJob) []string {
+	opts := silenceOptions(job)
+	return []string{
+		"-i", job.UploadedFilePath,
+		"-threads", strconv.Itoa(ffmpegThreadCount()),
+		"-progress", "pipe:1",
+		"-nostats",
+		"-v", "info",
+		"-af", fmt.Sprintf("silencedetect=noise=%sdB:duration=%s", formatFilterFloat(opts.NoiseDB), formatFilterFloat(opts.MinSeconds)),
+		"-vn",
+		"-f", "null", "-",
+	}
+}
+
+// parseSilenceRanges extracts the silent ranges logged by silencedetect.
+// A silence still open at the end of the input extends to the given duration.
+func parseSilenceRanges(ffmpegOutput string, duration float64) []models.TimeRange {
+	var ranges []models.TimeRange
+	openStart := -1.0
+	for _, line := range strings.Split(ffmpegOutput, "\n") {
+		if !strings.Contains(line, "silencedetect") {
+			continue
+		}
+		if match := silenceStartRegex.FindStringSubmatch(line); match != nil {
+			if value, err := strconv.ParseFloat(match[1], 64); err == nil {
+				openStart = math.Max(value, 0)
+			}
+			continue
+		}
+		if match := silenceEndRegex.FindStringSubmatch(line); match != nil && openStart >= 0 {
+			if value, err := strconv.ParseFloat(match[1], 64); err == nil {
+				ranges = append(ranges, models.TimeRange{Start: openStart, End: value})
+			}
+			openStart = -1
+		}
+	}
+	if openStart >= 0 && duration > openStart {
+		ranges = append(ranges, models.TimeRange{Start: openStart, End: duration})
+	}
+	return ranges
+}
+
+// padSilenceRanges shrinks each silent range by the padding on both sides, so a little
+// silence is kept around speech. Ranges touching the start or end of the input are not
+// padded on that side. Ranges that vanish after padding are dropped.
+func padSilenceRanges(ranges []models.TimeRange, padding, duration float64) []models.TimeRange {
+	padded := make([]models.TimeRange, 0, len(ranges))
+	for _, r := range ranges {
+		if r.Start > 0 {
+			r.Start += padding
+		}
+		if duration <= 0 || r.End < duration {
+			r.End -= padding
+		}
+		if duration > 0 && r.End > duration {
+			r.End = duration
+		}
+		if r.End-r.Start > 0 {
+			padded = append(padded, r)
+		}
+	}
+	return padded
+}
+
+// keepRanges returns the complement of the removed ranges within [0, duration].
+func keepRanges(removed []models.TimeRange, duration float64) []models.TimeRange {
+	var keep []models.TimeRange
+	cursor := 0.0
+	for _, r := range removed {
+		if r.Start > cursor {
+			keep = append(keep, models.TimeRange{Start: cursor, End: r.Start})
+		}
+		cursor = math.Max(cursor, r.End)
+	}
+	if duration > cursor {
+		keep = append(keep, models.TimeRange{Start: cursor, End: duration})
+	}
+	return keep
+}
+
+// buildSilenceResult summarizes the silence removed from an input of the given duration.
+func buildSilenceResult(removed []models.TimeRange, duration float64, previewOnly bool) models.SilenceResult {
+	result := models.SilenceResult{
+		RemovedRanges:   append([]models.TimeRange{}, removed...),
+		OriginalSeconds: duration,
+		PreviewOnly:     previewOnly,
+	}
+	for _, r := range removed {
+		result.RemovedSeconds += r.End - r.Start
+	}
+	result.OutputSeconds = math.Max(duration-result.RemovedSeconds, 0)
+	return result
+}
+
+// silencePlanCommands returns the commands of a silence removal job for the execution plan.
+// The kept ranges depend on the detection result, so the encode lists a placeholder range.
+func silencePlanCommands(job models.ConversionJob, quality models.QualitySetting) ([]models.CommandInvocation, error) {
+	if err := ValidateSilenceOptions(job); err != nil {
+		return nil, err
+	}
+	if err := ValidateFilters(job.Filters); err != nil {
+		return nil, err
+	}
+
+	commands := []models.CommandInvocation{{
+		Program:     "ffmpeg",
+		Args:        buildSilenceDetectionArgs(job),
+		Description: "Detect silence",
+	}}
+	if silenceOptions(job).PreviewOnly {
+		return commands, nil
+	}
+
+	template := job
+	template.KeepRanges = []models.TimeRange{{Start: 0, End: 1}}
+	encodeArgs, err := buildFFmpegArgs(template, quality)
+	if err != nil {
+		return nil, err
+	}
+	return append(commands,
+		models.CommandInvocation{
+			Program:     "ffmpeg",
+			Args:        encodeArgs,
+			Description: "Encode without silent ranges (kept ranges depend on detection)",
+		},
+		models.CommandInvocation{
+			Program:     "exiftool",
+			Args:        exiftoolCopyArgs(job.UploadedFilePath, job.OutputFilePath),
+			Description: "Copy metadata",
+		},
+	), nil
+}
+
+// removeSilence runs a silence removal job: it detects silent stretches and, unless only a
+// preview was requested, encodes the input without them, keeping audio and video in sync.
+func (c *VideoConverter) removeSilence(job models.ConversionJob) {
+	conversionID := job.ConversionID
+	opts := silenceOptions(job)
+
+	status, _ := c.store.GetStatus(conversionID)
+	duration := status.DurationSeconds
+	if duration <= 0 {
+		c.failJob(job, models.ErrorCodeInvalidInput, "Silence removal requires a known video duration")
+		return
+	}
+
+	detectShare := constants.SilenceDetectProgressShare
+	if opts.PreviewOnly {
+		detectShare = 100
+	}
+
+	c.store.SetPhase(conversionID, models.PhaseEncoding, 100)
+
+	c.store.SetCurrentStep(conversionID, "Detect silence")
+	output, err := c.runFFmpeg(conversionID, buildSilenceDetectionArgs(job), progressRange{start: 0, share: detectShare})
+	if err != nil {
+		c.handleFFmpegFailure(job, err, output)
+		return
+	}
+
+	removed := padSilenceRanges(parseSilenceRanges(output, duration), opts.PaddingSeconds, duration)
+	result := buildSilenceResult(removed, duration, opts.PreviewOnly)
+	c.store.SetSilenceResult(conversionID, result)
+	log.Printf("Job %s: %d silent ranges, %.2fs of %.2fs removable", conversionID, len(removed), result.RemovedSeconds, duration)
+
+	if opts.PreviewOnly {
+		c.store.UpdateStatusOnSuccess(conversionID)
+		c.removeInputFiles(job)
+		return
+	}
+
+	keep := keepRanges(removed, duration)
+	if len(keep) == 0 {
+		c.failJob(job, models.ErrorCodeInvalidInput, "The whole video is silent; nothing would remain after removing silence")
+		return
+	}
+	if len(removed) > 0 {
+		job.KeepRanges = keep
+	}
+
+	encodeArgs, err := buildFFmpegArgs(job, resolveJobQuality(job))
+	if err != nil {
+		c.failJob(job, models.ErrorCodeInvalidOptions, fmt.Sprintf("Invalid conversion options: %v", err))
+		return
+	}
+
+	c.store.SetCurrentStep(conversionID, "Encode without silent ranges")
+	output, err = c.runFFmpeg(conversionID, encodeArgs, progressRange{start: detectShare, share: 100 - detectShare, duration: result.OutputSeconds})
+	if err != nil {
+		c.handleFFmpegFailure(job, err, output)
+		return
+	}
+
+	c.finalizeOutput(job)
+}
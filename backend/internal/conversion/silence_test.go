@@ -0,0 +1,151 @@
+package conversion
+
+import (
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSilenceRanges(t *testing.T) {
+	output := `Input #0, mov,mp4,m4a,3gp,3g2,mj2, from 'talk.mov':
+[silencedetect @ 0x5581] silence_start: -0.0213
+[silencedetect @ 0x5581] silence_end: 1.52 | silence_duration: 1.5413
+[silencedetect @ 0x5581] silence_start: 10.25
+[silencedetect @ 0x5581] silence_end: 12.75 | silence_duration: 2.5
+[silencedetect @ 0x5581] silence_start: 28.4
+size=N/A time=00:00:30.00 bitrate=N/A speed= 412x`
+
+	ranges := parseSilenceRanges(output, 30)
+
+	assert.Equal(t, []models.TimeRange{
+		{Start: 0, End: 1.52},
+		{Start: 10.25, End: 12.75},
+		{Start: 28.4, End: 30},
+	}, ranges)
+	assert.Empty(t, parseSilenceRanges("no silence", 30))
+}
+
+func TestPadSilenceRanges(t *testing.T) {
+	ranges := []models.TimeRange{
+		{Start: 0, End: 2},
+		{Start: 10, End: 12},
+		{Start: 15, End: 15.1},
+		{Start: 28, End: 30},
+	}
+
+	padded := padSilenceRanges(ranges, 0.25, 30)
+
+	assert.Equal(t, []models.TimeRange{
+		{Start: 0, End: 1.75},
+		{Start: 10.25, End: 11.75},
+		{Start: 28.25, End: 30},
+	}, padded)
+}
+
+func TestKeepRanges(t *testing.T) {
+	removed := []models.TimeRange{
+		{Start: 0, End: 1.5},
+		{Start: 10, End: 12},
+		{Start: 28, End: 30},
+	}
+
+	assert.Equal(t, []models.TimeRange{
+		{Start: 1.5, End: 10},
+		{Start: 12, End: 28},
+	}, keepRanges(removed, 30))
+	assert.Equal(t, []models.TimeRange{{Start: 0, End: 30}}, keepRanges(nil, 30))
+	assert.Empty(t, keepRanges([]models.TimeRange{{Start: 0, End: 30}}, 30))
+}
+
+func TestBuildSilenceResult(t *testing.T) {
+	result := buildSilenceResult([]models.TimeRange{{Start: 0, End: 1.5}, {Start: 10, End: 12}}, 30, true)
+
+	assert.InDelta(t, 3.5, result.RemovedSeconds, 1e-9)
+	assert.InDelta(t, 26.5, result.OutputSeconds, 1e-9)
+	assert.Equal(t, 30.0, result.OriginalSeconds)
+	assert.True(t, result.PreviewOnly)
+	assert.Len(t, result.RemovedRanges, 2)
+}
+
+func TestValidateSilenceOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		job     models.ConversionJob
+		wantErr bool
+	}{
+		{"Defaults", models.ConversionJob{}, false},
+		{"Custom", models.ConversionJob{Silence: &models.SilenceOptions{NoiseDB: -40, MinSeconds: 1, PaddingSeconds: 0.2}}, false},
+		{"Positive Noise", models.ConversionJob{Silence: &models.SilenceOptions{NoiseDB: 3}}, true},
+		{"Too Short", models.ConversionJob{Silence: &models.SilenceOptions{MinSeconds: 0.01}}, true},
+		{"Negative Padding", models.ConversionJob{Silence: &models.SilenceOptions{PaddingSeconds: -1}}, true},
+		{"Without Audio", models.ConversionJob{RemoveSound: true}, true},
+		{"With Stabilization", models.ConversionJob{Stabilization: &models.StabilizationOptions{}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSilenceOptions(tt.job)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBuildFFmpegArgs_KeepRanges(t *testing.T) {
+	job := models.ConversionJob{
+		TargetFormat:     "mp4",
+		UploadedFilePath: "/uploads/talk.mov",
+		OutputFilePath:   "/converted/talk.mp4",
+		KeepRanges:       []models.TimeRange{{Start: 1.5, End: 10}, {Start: 12, End: 28}},
+	}
+
+	args, err := buildFFmpegArgs(job, resolveJobQuality(job))
+	require.NoError(t, err)
+
+	assert.Contains(t, args, "select='between(t,1.500,10.000)+between(t,12.000,28.000)',setpts=N/FRAME_RATE/TB")
+	assert.Contains(t, args, "aselect='between(t,1.500,10.000)+between(t,12.000,28.000)',asetpts=N/SR/TB")
+	assert.NotContains(t, args, "copy")
+}
+
+func TestBuildPlan_Silence(t *testing.T) {
+	job := models.ConversionJob{
+		JobType:          models.JobTypeSilence,
+		TargetFormat:     "mp4",
+		UploadedFilePath: "/uploads/talk.mov",
+		OutputFilePath:   "/converted/talk.mp4",
+	}
+
+	plan, err := BuildPlan(job)
+	require.NoError(t, err)
+	require.Len(t, plan.Commands, 4)
+	assert.Contains(t, plan.Commands[1].Args, "silencedetect=noise=-30dB:duration=0.5")
+	assert.Equal(t, "exiftool", plan.Commands[3].Program)
+
+	job.Silence = &models.SilenceOptions{PreviewOnly: true}
+	plan, err = BuildPlan(job)
+	require.NoError(t, err)
+	assert.Len(t, plan.Commands, 2)
+}
+
+func TestHasSingleDownload(t *testing.T) {
+	done := models.ConversionStatus{Complete: true, OutputPath: "/converted/talk.mp4"}
+	assert.True(t, HasSingleDownload(done))
+
+	preview := done
+	preview.JobType = models.JobTypeSilence
+	preview.Silence = &models.SilenceResult{PreviewOnly: true}
+	assert.False(t, HasSingleDownload(preview))
+
+	scenes := done
+	scenes.JobType = models.JobTypeScenes
+	assert.False(t, HasSingleDownload(scenes))
+
+	failed := done
+	failed.Error = "boom"
+	assert.False(t, HasSingleDownload(failed))
+}
@@ -0,0 +1,73 @@
+package conversion
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+// TransformsFilePath returns the path of the vidstab transforms file generated for an input file.
+// It lives next to the input so it is cleaned up together with the upload.
+func TransformsFilePath(inputPath string) string {
+	return inputPath + ".trf"
+}
+
+// ValidateStabilization checks that stabilization options are within the supported ranges.
+// A nil value means stabilization is disabled and is always valid.
+func ValidateStabilization(opts *models.StabilizationOptions) error {
+	if opts == nil {
+		return nil
+	}
+	if opts.Smoothing < 0 || opts.Smoothing > constants.StabilizationMaxSmoothing {
+		return fmt.Errorf("stabilization smoothing must be between 0 and %d", constants.StabilizationMaxSmoothing)
+	}
+	if math.IsNaN(opts.Zoom) || math.IsInf(opts.Zoom, 0) || opts.Zoom < -constants.StabilizationMaxZoom || opts.Zoom > constants.StabilizationMaxZoom {
+		return fmt.Errorf("stabilization zoom must be between -%g and %g percent", constants.StabilizationMaxZoom, constants.StabilizationMaxZoom)
+	}
+	return nil
+}
+
+// buildStabilizationAnalysisArgs builds the vidstabdetect pass, which writes the
+// camera motion transforms to a file and discards the decoded output.
+func buildStabilizationAnalysisArgs(job models.ConversionJob) []string {
+	detect := fmt.Sprintf("vidstabdetect=shakiness=%d:accuracy=%d:result=%s",
+		constants.StabilizationShakiness,
+		constants.StabilizationAccuracy,
+		escapeFilterValue(TransformsFilePath(job.UploadedFilePath)),
+	)
+	return []string{
+		"-i", job.UploadedFilePath,
+		"-threads", strconv.Itoa(ffmpegThreadCount()),
+		"-progress", "pipe:1",
+		"-nostats",
+		"-v", "warning",
+		"-vf", detect,
+		"-an",
+		"-f", "null", "-",
+	}
+}
+
+// stabilizationTransformFilters returns the filters that apply previously detected transforms.
+// A light unsharp pass compensates for the softening introduced by the transform interpolation.
+func stabilizationTransformFilters(job models.ConversionJob) []string {
+	smoothing := job.Stabilization.Smoothing
+	if smoothing == 0 {
+		smoothing = constants.StabilizationDefaultSmoothing
+	}
+	transform := fmt.Sprintf("vidstabtransform=input=%s:smoothing=%d:zoom=%s",
+		escapeFilterValue(TransformsFilePath(job.UploadedFilePath)),
+		smoothing,
+		strconv.FormatFloat(job.Stabilization.Zoom, 'f', -1, 64),
+	)
+	return []string{transform, "unsharp=5:5:0.8:3:3:0.4"}
+}
+
+// escapeFilterValue escapes a value for use inside an FFmpeg filtergraph option.
+func escapeFilterValue(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `:`, `\:`, `'`, `\'`, `,`, `\,`, `;`, `\;`, `[`, `\[`, `]`, `\]`)
+	return replacer.Replace(value)
+}
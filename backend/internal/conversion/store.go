@@ -1,43 +1,139 @@
 package conversion
 
 import (
+	"context"
+	"log"
+	"math"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/gatanasi/video-converter/internal/constants"
 	"github.com/gatanasi/video-converter/internal/models"
 )
 
 // Store manages the state of active conversions and their statuses.
+// Job options, phase changes and results are written to a Repository so that job
+// history survives restarts; live progress is kept in memory only.
 // It is safe for concurrent use.
 type Store struct {
 	activeCmds      map[string]*exec.Cmd
+	jobCancels      map[string]context.CancelFunc // Cancel work that runs without an FFmpeg process, such as Drive downloads
 	activeCmdsMutex sync.RWMutex
 
 	statuses      map[string]*models.ConversionStatus
+	jobs          map[string]models.ConversionJob // Options of queued jobs, guarded by statusesMutex
+	batches       map[string][]string             // Job IDs of each batch in submission order, guarded by statusesMutex
 	statusesMutex sync.RWMutex
 
+	repo         Repository
+	persistMutex sync.Mutex // Keeps repository writes in the order the changes happened
+
 	subscribers      map[chan StoreEvent]struct{}
 	subscribersMutex sync.RWMutex
 }
 
+// DownloadURLPrefix is the URL prefix under which converted files are downloaded.
+// It mirrors the API download route.
+const DownloadURLPrefix = "/download/"
+
+// AbortedByUserMessage is the error recorded on jobs canceled by the user.
+const AbortedByUserMessage = "Conversion aborted by user"
+
 // StoreEvent represents a change in conversion status suitable for streaming to clients.
 type StoreEvent struct {
 	Type         string                           `json:"type"`
-	ConversionID string                           `json:"conversionId"`
+	ConversionID string                           `json:"conversionId,omitempty"`
 	Status       *models.ConversionStatusResponse `json:"status,omitempty"`
+	BatchID      string                           `json:"batchId,omitempty"` // Set on batch events
+	Batch        *models.BatchStatusResponse      `json:"batch,omitempty"`
 }
 
-// NewStore creates a new conversion store.
+// NewStore creates a new conversion store that keeps job history in memory only.
 func NewStore() *Store {
+	return NewStoreWithRepository(NewMemoryRepository())
+}
+
+// NewStoreWithRepository creates a new conversion store that persists jobs to repo.
+// Call LoadRecords to restore the jobs it already holds.
+func NewStoreWithRepository(repo Repository) *Store {
 	return &Store{
 		activeCmds:  make(map[string]*exec.Cmd),
+		jobCancels:  make(map[string]context.CancelFunc),
 		statuses:    make(map[string]*models.ConversionStatus),
+		jobs:        make(map[string]models.ConversionJob),
+		batches:     make(map[string][]string),
+		repo:        repo,
 		subscribers: make(map[chan StoreEvent]struct{}),
 	}
 }
 
+// LoadRecords restores the jobs held by the repository into the store and returns
+// their records, oldest first. It should be called once, before the store is used.
+func (s *Store) LoadRecords() ([]models.JobRecord, error) {
+	records, err := s.repo.LoadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	s.statusesMutex.Lock()
+	for _, record := range records {
+		status := record.Status
+		s.statuses[record.ID] = &status
+		if status.BatchID != "" {
+			s.batches[status.BatchID] = append(s.batches[status.BatchID], record.ID)
+		}
+		if record.Job != nil {
+			s.jobs[record.ID] = *record.Job
+		}
+	}
+	s.statusesMutex.Unlock()
+	return records, nil
+}
+
+// TrackJob records the options of a job so that it can be persisted with its status.
+func (s *Store) TrackJob(job models.ConversionJob) {
+	job.Status = nil
+	s.statusesMutex.Lock()
+	s.jobs[job.ConversionID] = job
+	s.statusesMutex.Unlock()
+
+	s.persist(job.ConversionID)
+}
+
+// GetJob returns the options a job was queued with. The returned job has no status.
+func (s *Store) GetJob(id string) (models.ConversionJob, bool) {
+	s.statusesMutex.RLock()
+	defer s.statusesMutex.RUnlock()
+	job, exists := s.jobs[id]
+	return job, exists
+}
+
+// persist writes the current state of a job to the repository. Failures are logged
+// rather than returned so that a storage problem never interrupts a running job.
+func (s *Store) persist(id string) {
+	s.persistMutex.Lock()
+	defer s.persistMutex.Unlock()
+
+	s.statusesMutex.RLock()
+	status, exists := s.statuses[id]
+	if !exists {
+		s.statusesMutex.RUnlock()
+		return
+	}
+	record := models.JobRecord{ID: id, Status: copyStatus(status), UpdatedAt: time.Now()}
+	if job, ok := s.jobs[id]; ok {
+		record.Job = &job
+	}
+	s.statusesMutex.RUnlock()
+
+	if err := s.repo.Save(record); err != nil {
+		log.Printf("WARN [job %s]: Failed to persist job state: %v", id, err)
+	}
+}
+
 // Subscribe registers a new listener for store events.
 func (s *Store) Subscribe() chan StoreEvent {
 	ch := make(chan StoreEvent, constants.SSESubscriberBufferSize)
@@ -76,12 +172,15 @@ func (s *Store) publishStatus(id string) {
 		return
 	}
 
-	response := s.buildStatusResponse(id, status)
+	response := s.BuildStatusResponse(id, status)
 	s.publish(StoreEvent{
 		Type:         "status",
 		ConversionID: id,
 		Status:       &response,
 	})
+	if status.BatchID != "" {
+		s.publishBatch(status.BatchID)
+	}
 }
 
 func (s *Store) publishRemoval(id string) {
@@ -98,29 +197,66 @@ func (s *Store) GetAllStatuses() map[string]models.ConversionStatus {
 
 	snapshot := make(map[string]models.ConversionStatus, len(s.statuses))
 	for id, status := range s.statuses {
-		snapshot[id] = *status
+		snapshot[id] = copyStatus(status)
 	}
 	return snapshot
 }
 
-func (s *Store) buildStatusResponse(id string, status models.ConversionStatus) models.ConversionStatusResponse {
+// BuildStatusResponse returns the API representation of a job status, as sent by the
+// status endpoint and in status events.
+func (s *Store) BuildStatusResponse(id string, status models.ConversionStatus) models.ConversionStatusResponse {
 	response := models.ConversionStatusResponse{
-		ID:       id,
-		FileName: filepath.Base(status.OutputPath),
-		Progress: status.Progress,
-		Complete: status.Complete,
-		Error:    status.Error,
-		Format:   status.Format,
-		Quality:  status.Quality,
+		ID:            id,
+		FileName:      filepath.Base(status.OutputPath),
+		Progress:      status.Progress,
+		Complete:      status.Complete,
+		Error:         status.Error,
+		ErrorCode:     status.ErrorCode,
+		Format:        status.Format,
+		Quality:       status.Quality,
+		JobType:       status.JobType,
+		Phase:         status.Phase,
+		Phases:        status.Phases,
+		PredecessorID: status.PredecessorID,
+		BatchID:       status.BatchID,
+		Scenes:        status.Scenes,
+		Outputs:       status.Outputs,
+		Silence:       status.Silence,
+		Metrics:       status.Metrics,
+		CRFSearch:     status.CRFSearch,
+		DriveUpload:   status.DriveUpload,
+	}
+	// Live progress is only reported while the job runs.
+	if !status.Complete {
+		response.CurrentStep = status.CurrentStep
+		response.Stats = status.Stats
+		response.QueuePosition = status.QueuePosition
+		response.QueueReason = status.QueueReason
+		response.Download = status.Download
+		response.Upload = status.Upload
 	}
 
-	if status.Complete && status.Error == "" && status.OutputPath != "" {
-		response.DownloadURL = "/download/" + filepath.Base(status.OutputPath)
+	if HasSingleDownload(status) {
+		response.DownloadURL = DownloadURLPrefix + filepath.Base(status.OutputPath)
 	}
 
 	return response
 }
 
+// HasSingleDownload reports whether a finished job produced a single downloadable output file.
+// Scene jobs publish their files as grouped outputs and silence previews produce no file.
+// Jobs whose only failure was the Drive upload keep their output.
+func HasSingleDownload(status models.ConversionStatus) bool {
+	failed := status.Error != "" && status.ErrorCode != models.ErrorCodeDriveUpload
+	if !status.Complete || failed || status.OutputPath == "" {
+		return false
+	}
+	if status.JobType == models.JobTypeScenes {
+		return false
+	}
+	return status.Silence == nil || !status.Silence.PreviewOnly
+}
+
 // RegisterActiveCmd tracks a running FFmpeg command.
 func (s *Store) RegisterActiveCmd(id string, cmd *exec.Cmd) {
 	s.activeCmdsMutex.Lock()
@@ -143,6 +279,48 @@ func (s *Store) GetActiveCmd(id string) (*exec.Cmd, bool) {
 	return cmd, exists
 }
 
+// activeCmdIDs returns the IDs of conversions with a running FFmpeg process.
+func (s *Store) activeCmdIDs() []string {
+	s.activeCmdsMutex.RLock()
+	defer s.activeCmdsMutex.RUnlock()
+	ids := make([]string, 0, len(s.activeCmds))
+	for id := range s.activeCmds {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// JobContext returns a context that is canceled when the job is aborted, for work that
+// runs without an FFmpeg process. The returned release function must be called once the
+// work is done. If the job has already been aborted, the context is canceled immediately.
+func (s *Store) JobContext(id string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.activeCmdsMutex.Lock()
+	s.jobCancels[id] = cancel
+	s.activeCmdsMutex.Unlock()
+
+	if status, exists := s.GetStatus(id); exists && status.ErrorCode == models.ErrorCodeAborted {
+		cancel()
+	}
+	return ctx, func() {
+		s.activeCmdsMutex.Lock()
+		delete(s.jobCancels, id)
+		s.activeCmdsMutex.Unlock()
+		cancel()
+	}
+}
+
+// cancelJobContext cancels the context handed out by JobContext for a job, if any.
+func (s *Store) cancelJobContext(id string) {
+	s.activeCmdsMutex.RLock()
+	cancel, exists := s.jobCancels[id]
+	s.activeCmdsMutex.RUnlock()
+	if exists {
+		cancel()
+	}
+}
+
 // GetActiveConversionsInfo returns details for all currently active conversions.
 func (s *Store) GetActiveConversionsInfo() []models.ActiveConversionInfo {
 	s.activeCmdsMutex.RLock()
@@ -160,12 +338,32 @@ func (s *Store) GetActiveConversionsInfo() []models.ActiveConversionInfo {
 				Format:   status.Format,
 				Progress: status.Progress,
 				Quality:  status.Quality,
+
+				Phase:       status.Phase,
+				CurrentStep: status.CurrentStep,
+				Stats:       status.Stats,
 			})
 		}
 	}
 	return activeJobs
 }
 
+// GetUnfinishedInputFilenames returns the set of input filenames (basenames) of jobs that
+// have not finished. Inputs linked from the library or moved from a finished job keep
+// their original modification time, so the file cleanup must not judge them by age.
+func (s *Store) GetUnfinishedInputFilenames() map[string]struct{} {
+	s.statusesMutex.RLock()
+	defer s.statusesMutex.RUnlock()
+
+	names := make(map[string]struct{})
+	for id, job := range s.jobs {
+		if status, ok := s.statuses[id]; ok && !status.Complete && job.UploadedFilePath != "" {
+			names[filepath.Base(job.UploadedFilePath)] = struct{}{}
+		}
+	}
+	return names
+}
+
 // GetActiveOutputFilenames returns a set of output filenames (basenames) belonging
 // to conversions that are still in progress. Callers can use this to exclude
 // incomplete files from directory listings.
@@ -179,6 +377,9 @@ func (s *Store) GetActiveOutputFilenames() map[string]struct{} {
 	for id := range s.activeCmds {
 		if status, ok := s.statuses[id]; ok && !status.Complete {
 			names[filepath.Base(status.OutputPath)] = struct{}{}
+			if status.CurrentOutput != "" {
+				names[filepath.Base(status.CurrentOutput)] = struct{}{}
+			}
 		}
 	}
 	return names
@@ -187,9 +388,13 @@ func (s *Store) GetActiveOutputFilenames() map[string]struct{} {
 // SetStatus adds or updates the status for a conversion ID.
 func (s *Store) SetStatus(id string, status *models.ConversionStatus) {
 	s.statusesMutex.Lock()
+	if _, exists := s.statuses[id]; !exists && status.BatchID != "" {
+		s.batches[status.BatchID] = append(s.batches[status.BatchID], id)
+	}
 	s.statuses[id] = status
 	s.statusesMutex.Unlock()
 
+	s.persist(id)
 	s.publishStatus(id)
 }
 
@@ -203,44 +408,306 @@ func (s *Store) GetStatus(id string) (models.ConversionStatus, bool) {
 		return models.ConversionStatus{}, false
 	}
 	// Return a copy to prevent race conditions if caller modifies it
+	return copyStatus(status), true
+}
+
+// copyStatus returns a copy of a status whose phase history does not share
+// memory with the stored status. Callers must hold the statuses lock.
+func copyStatus(status *models.ConversionStatus) models.ConversionStatus {
 	statusCopy := *status
-	return statusCopy, true
+	statusCopy.Phases = append([]models.PhaseRecord(nil), status.Phases...)
+	return statusCopy
 }
 
 // DeleteStatus removes the status entry for a given ID.
 func (s *Store) DeleteStatus(id string) {
 	s.statusesMutex.Lock()
-	_, existed := s.statuses[id]
+	status, existed := s.statuses[id]
+	batchID := ""
 	if existed {
 		delete(s.statuses, id)
+		delete(s.jobs, id)
+		batchID = status.BatchID
+		s.removeFromBatch(batchID, id)
 	}
 	s.statusesMutex.Unlock()
 
 	if existed {
+		s.persistMutex.Lock()
+		if err := s.repo.Delete(id); err != nil {
+			log.Printf("WARN [job %s]: Failed to delete persisted job: %v", id, err)
+		}
+		s.persistMutex.Unlock()
 		s.publishRemoval(id)
+		if batchID != "" {
+			s.publishBatch(batchID)
+		}
 	}
 }
 
+// PruneFinished removes the jobs that finished more than maxAge ago, with their persisted
+// records, and returns how many were removed. Their files expire after the same age in
+// the file cleanup.
+func (s *Store) PruneFinished(maxAge time.Duration) int {
+	cutoff := time.Now().Add(-maxAge)
+	var pruned []string
+	batches := make(map[string]struct{})
+	s.statusesMutex.Lock()
+	for id, status := range s.statuses {
+		if !finishedBefore(status, cutoff) {
+			continue
+		}
+		delete(s.statuses, id)
+		delete(s.jobs, id)
+		s.removeFromBatch(status.BatchID, id)
+		if status.BatchID != "" {
+			batches[status.BatchID] = struct{}{}
+		}
+		pruned = append(pruned, id)
+	}
+	s.statusesMutex.Unlock()
+
+	for _, id := range pruned {
+		s.persistMutex.Lock()
+		if err := s.repo.Delete(id); err != nil {
+			log.Printf("WARN [job %s]: Failed to delete persisted job: %v", id, err)
+		}
+		s.persistMutex.Unlock()
+		s.publishRemoval(id)
+	}
+	for batchID := range batches {
+		s.publishBatch(batchID)
+	}
+	return len(pruned)
+}
+
+// finishedBefore reports whether a job finished, that is its last phase ended, before cutoff.
+func finishedBefore(status *models.ConversionStatus, cutoff time.Time) bool {
+	n := len(status.Phases)
+	if !status.Complete || n == 0 {
+		return false
+	}
+	ended := status.Phases[n-1].EndedAt
+	return ended != nil && ended.Before(cutoff)
+}
+
 // UpdateStatusWithError updates the status to indicate completion with an error.
-func (s *Store) UpdateStatusWithError(id, errorMsg string) {
+// code is one of the models.ErrorCode constants.
+func (s *Store) UpdateStatusWithError(id, code, errorMsg string) {
 	s.statusesMutex.Lock()
 	updated := false
 	if status, exists := s.statuses[id]; exists {
 		// Only update if not already marked complete
 		if !status.Complete {
 			status.Error = errorMsg
+			status.ErrorCode = code
 			status.Complete = true
 			status.Progress = 0 // Reset progress on error
+			endPhase(status, models.PhaseFailed, false)
 			updated = true
 		}
 	}
 	s.statusesMutex.Unlock()
 
 	if updated {
+		s.persist(id)
+		s.publishStatus(id)
+	}
+}
+
+// UpdateStatusCanceled marks a conversion as complete because the user aborted it.
+// It reports whether the conversion was still running and is now canceled.
+func (s *Store) UpdateStatusCanceled(id string) bool {
+	s.statusesMutex.Lock()
+	updated := false
+	if status, exists := s.statuses[id]; exists && !status.Complete {
+		status.Error = AbortedByUserMessage
+		status.ErrorCode = models.ErrorCodeAborted
+		status.Complete = true
+		status.Progress = 0
+		endPhase(status, models.PhaseCanceled, false)
+		updated = true
+	}
+	s.statusesMutex.Unlock()
+
+	if updated {
+		s.persist(id)
+		s.publishStatus(id)
+	}
+	return updated
+}
+
+// SetPaused moves a running conversion into the paused phase or, with paused false, back
+// into the phase it was paused in. The progress range of the interrupted phase is kept so
+// that progress continues where it stopped. It reports whether the status changed.
+func (s *Store) SetPaused(id string, paused bool) bool {
+	s.statusesMutex.Lock()
+	updated := false
+	if status, exists := s.statuses[id]; exists && !status.Complete && (status.Phase == models.PhasePaused) != paused {
+		next := models.PhasePaused
+		if !paused {
+			next = pausedFromPhase(status)
+		}
+		endPhase(status, next, false)
+		status.Phases = append(status.Phases, models.PhaseRecord{Phase: next, Progress: phaseProgress(status), StartedAt: time.Now()})
+		status.Stats = nil // Live statistics resume with the next FFmpeg progress report
+		updated = true
+	}
+	s.statusesMutex.Unlock()
+
+	if updated {
+		s.persist(id)
+		s.publishStatus(id)
+	}
+	return updated
+}
+
+// pausedFromPhase returns the phase a paused conversion was in before it was paused.
+func pausedFromPhase(status *models.ConversionStatus) string {
+	for i := len(status.Phases) - 1; i >= 0; i-- {
+		if phase := status.Phases[i].Phase; phase != models.PhasePaused {
+			return phase
+		}
+	}
+	return models.PhaseEncoding
+}
+
+// pausedTime returns how long a conversion has spent paused in total, including a pause in progress.
+func (s *Store) pausedTime(id string) time.Duration {
+	s.statusesMutex.RLock()
+	defer s.statusesMutex.RUnlock()
+	status, exists := s.statuses[id]
+	if !exists {
+		return 0
+	}
+	var total time.Duration
+	for _, record := range status.Phases {
+		if record.Phase != models.PhasePaused {
+			continue
+		}
+		if record.EndedAt != nil {
+			total += record.EndedAt.Sub(record.StartedAt)
+		} else {
+			total += time.Since(record.StartedAt)
+		}
+	}
+	return total
+}
+
+// SetPhase moves a conversion into a new lifecycle phase, closing the current one.
+// progressEnd is the overall progress at which the new phase is expected to end; progress
+// reported while the phase runs is mapped onto the phase between the current overall
+// progress and progressEnd. Phases that report no progress may pass the current progress.
+func (s *Store) SetPhase(id, phase string, progressEnd float64) {
+	s.statusesMutex.Lock()
+	updated := false
+	if status, exists := s.statuses[id]; exists && !status.Complete {
+		endPhase(status, phase, true)
+		status.Phases = append(status.Phases, models.PhaseRecord{Phase: phase, StartedAt: time.Now()})
+		status.PhaseProgressStart = status.Progress
+		status.PhaseProgressEnd = progressEnd
+		updated = true
+	}
+	s.statusesMutex.Unlock()
+
+	if updated {
+		s.persist(id)
+		s.publishStatus(id)
+	}
+}
+
+// SetQueuePositions records the queue position and scheduling reason of pending jobs.
+// Jobs no longer in the queue have their position and reason cleared.
+func (s *Store) SetQueuePositions(slots []QueueSlot) {
+	bySlot := make(map[string]QueueSlot, len(slots))
+	for _, slot := range slots {
+		bySlot[slot.ID] = slot
+	}
+
+	var changed []string
+	s.statusesMutex.Lock()
+	for id, status := range s.statuses {
+		slot := bySlot[id]
+		if status.QueuePosition != slot.Position || status.QueueReason != slot.Reason {
+			status.QueuePosition = slot.Position
+			status.QueueReason = slot.Reason
+			changed = append(changed, id)
+		}
+	}
+	s.statusesMutex.Unlock()
+
+	for _, id := range changed {
 		s.publishStatus(id)
 	}
 }
 
+// ResetForRequeue clears the progress and results of an unfinished job so that it can
+// run again from the start, and moves it back to the queued phase. It returns the status
+// to share with the requeued job, or nil if the job is unknown or already complete.
+func (s *Store) ResetForRequeue(id string) *models.ConversionStatus {
+	s.statusesMutex.Lock()
+	status, exists := s.statuses[id]
+	if !exists || status.Complete {
+		s.statusesMutex.Unlock()
+		return nil
+	}
+	status.Progress = 0
+	status.Error = ""
+	status.ErrorCode = ""
+	status.CurrentStep = ""
+	status.CurrentOutput = ""
+	status.Stats = nil
+	status.Scenes = nil
+	status.Outputs = nil
+	status.Silence = nil
+	status.Metrics = nil
+	status.CRFSearch = nil
+	status.Upload = nil
+	status.DriveUpload = nil
+	if status.Phase != models.PhaseQueued {
+		endPhase(status, models.PhaseQueued, false)
+		status.Phases = append(status.Phases, models.PhaseRecord{Phase: models.PhaseQueued, StartedAt: time.Now()})
+	}
+	status.PhaseProgressStart = 0
+	status.PhaseProgressEnd = 0
+	s.statusesMutex.Unlock()
+
+	s.persist(id)
+	s.publishStatus(id)
+	return status
+}
+
+// endPhase closes the running phase of a status and records next as the current phase.
+// A phase that finished normally is reported as fully complete; one interrupted by a
+// failure or cancellation keeps the progress it had reached. Callers must hold the statuses lock.
+func endPhase(status *models.ConversionStatus, next string, finished bool) {
+	if n := len(status.Phases); n > 0 && status.Phases[n-1].EndedAt == nil {
+		now := time.Now()
+		current := &status.Phases[n-1]
+		current.EndedAt = &now
+		if finished {
+			current.Progress = 100
+		}
+	}
+	status.Phase = next
+}
+
+// phaseProgress maps overall job progress onto the progress of the current phase.
+func phaseProgress(status *models.ConversionStatus) float64 {
+	span := status.PhaseProgressEnd - status.PhaseProgressStart
+	if span <= 0 {
+		return 0
+	}
+	progress := (status.Progress - status.PhaseProgressStart) / span * 100
+	if progress < 0 {
+		return 0
+	}
+	if progress > 100 {
+		return 100
+	}
+	return progress
+}
+
 // SetProgressPercentage updates the progress percentage for a conversion.
 // It caps the progress at 99.0% until explicitly marked as 100% on success.
 func (s *Store) SetProgressPercentage(id string, percentage float64) {
@@ -257,6 +724,9 @@ func (s *Store) SetProgressPercentage(id string, percentage float64) {
 				progress = constants.ProgressMaxBeforeCompletion
 			}
 			status.Progress = progress
+			if n := len(status.Phases); n > 0 && status.Phases[n-1].EndedAt == nil {
+				status.Phases[n-1].Progress = phaseProgress(status)
+			}
 			updated = true
 		}
 	}
@@ -267,6 +737,198 @@ func (s *Store) SetProgressPercentage(id string, percentage float64) {
 	}
 }
 
+// SetDownloadProgress records how much of a job's source has been downloaded. total is 0
+// while the size is unknown.
+func (s *Store) SetDownloadProgress(id string, written, total int64) {
+	s.statusesMutex.Lock()
+	updated := false
+	if status, exists := s.statuses[id]; exists && !status.Complete {
+		status.Download = &models.DownloadProgress{BytesDownloaded: written, TotalBytes: total}
+		if n := len(status.Phases); n > 0 && status.Phases[n-1].EndedAt == nil && total > 0 {
+			status.Phases[n-1].Progress = math.Min(float64(written)/float64(total)*100, 100)
+		}
+		updated = true
+	}
+	s.statusesMutex.Unlock()
+
+	if updated {
+		s.publishStatus(id)
+	}
+}
+
+// SetDuration records the duration of a job's input, as probed before it runs.
+func (s *Store) SetDuration(id string, seconds float64) {
+	s.statusesMutex.Lock()
+	updated := false
+	if status, exists := s.statuses[id]; exists {
+		status.DurationSeconds = seconds
+		updated = true
+	}
+	s.statusesMutex.Unlock()
+
+	if updated {
+		s.persist(id)
+		s.publishStatus(id)
+	}
+}
+
+// SetUploadProgress records how much of a job's output has been uploaded to Google Drive.
+func (s *Store) SetUploadProgress(id string, uploaded, total int64) {
+	s.statusesMutex.Lock()
+	updated := false
+	if status, exists := s.statuses[id]; exists && !status.Complete {
+		status.Upload = &models.UploadProgress{BytesUploaded: uploaded, TotalBytes: total}
+		if n := len(status.Phases); n > 0 && status.Phases[n-1].EndedAt == nil && total > 0 {
+			status.Phases[n-1].Progress = math.Min(float64(uploaded)/float64(total)*100, 100)
+		}
+		updated = true
+	}
+	s.statusesMutex.Unlock()
+
+	if updated {
+		s.publishStatus(id)
+	}
+}
+
+// SetDriveUpload records the Google Drive file a job's output was uploaded to.
+func (s *Store) SetDriveUpload(id string, result models.DriveUploadResult) {
+	s.statusesMutex.Lock()
+	updated := false
+	if status, exists := s.statuses[id]; exists {
+		status.DriveUpload = &result
+		updated = true
+	}
+	s.statusesMutex.Unlock()
+
+	if updated {
+		s.persist(id)
+		s.publishStatus(id)
+	}
+}
+
+// SetEncodingStats records the live statistics of the FFmpeg pass a conversion is running.
+func (s *Store) SetEncodingStats(id string, stats models.EncodingStats) {
+	s.statusesMutex.Lock()
+	updated := false
+	if status, exists := s.statuses[id]; exists && !status.Complete {
+		status.Stats = &stats
+		updated = true
+	}
+	s.statusesMutex.Unlock()
+
+	if updated {
+		s.publishStatus(id)
+	}
+}
+
+// SetCurrentStep records which pipeline step a conversion is currently running.
+// Statistics of the previous step are cleared.
+func (s *Store) SetCurrentStep(id, step string) {
+	s.statusesMutex.Lock()
+	updated := false
+	if status, exists := s.statuses[id]; exists && !status.Complete {
+		status.CurrentStep = step
+		status.Stats = nil
+		updated = true
+	}
+	s.statusesMutex.Unlock()
+
+	if updated {
+		s.publishStatus(id)
+	}
+}
+
+// SetCurrentOutput records the output file a multi-output job is currently writing,
+// so it can be hidden from listings until it is complete. Pass an empty path to clear it.
+func (s *Store) SetCurrentOutput(id, path string) {
+	s.statusesMutex.Lock()
+	if status, exists := s.statuses[id]; exists {
+		status.CurrentOutput = path
+	}
+	s.statusesMutex.Unlock()
+}
+
+// SetSilenceResult records the silence detected (and removed) by a silence removal job.
+func (s *Store) SetSilenceResult(id string, result models.SilenceResult) {
+	s.statusesMutex.Lock()
+	updated := false
+	if status, exists := s.statuses[id]; exists {
+		status.Silence = &result
+		updated = true
+	}
+	s.statusesMutex.Unlock()
+
+	if updated {
+		s.persist(id)
+		s.publishStatus(id)
+	}
+}
+
+// SetQualityMetrics records the quality scores measured for a job.
+func (s *Store) SetQualityMetrics(id string, metrics models.QualityMetrics) {
+	s.statusesMutex.Lock()
+	updated := false
+	if status, exists := s.statuses[id]; exists {
+		status.Metrics = &metrics
+		updated = true
+	}
+	s.statusesMutex.Unlock()
+
+	if updated {
+		s.persist(id)
+		s.publishStatus(id)
+	}
+}
+
+// SetCRFSearch records the (possibly partial) CRF search of a job.
+func (s *Store) SetCRFSearch(id string, result models.CRFSearchResult) {
+	s.statusesMutex.Lock()
+	updated := false
+	if status, exists := s.statuses[id]; exists {
+		result.Trials = append([]models.CRFTrial(nil), result.Trials...)
+		status.CRFSearch = &result
+		updated = true
+	}
+	s.statusesMutex.Unlock()
+
+	if updated {
+		s.persist(id)
+		s.publishStatus(id)
+	}
+}
+
+// SetScenes records the scenes detected for a scene detection job.
+func (s *Store) SetScenes(id string, scenes []models.SceneInfo) {
+	s.statusesMutex.Lock()
+	updated := false
+	if status, exists := s.statuses[id]; exists {
+		status.Scenes = append([]models.SceneInfo(nil), scenes...)
+		updated = true
+	}
+	s.statusesMutex.Unlock()
+
+	if updated {
+		s.persist(id)
+		s.publishStatus(id)
+	}
+}
+
+// AddOutput appends a finished output file to a job with grouped outputs.
+func (s *Store) AddOutput(id string, output models.OutputFile) {
+	s.statusesMutex.Lock()
+	updated := false
+	if status, exists := s.statuses[id]; exists {
+		status.Outputs = append(status.Outputs, output)
+		updated = true
+	}
+	s.statusesMutex.Unlock()
+
+	if updated {
+		s.persist(id)
+		s.publishStatus(id)
+	}
+}
+
 // UpdateStatusOnSuccess marks the conversion as complete and successful.
 func (s *Store) UpdateStatusOnSuccess(id string) {
 	s.statusesMutex.Lock()
@@ -277,12 +939,15 @@ func (s *Store) UpdateStatusOnSuccess(id string) {
 			status.Complete = true
 			status.Progress = 100.0
 			status.Error = "" // Ensure no previous error lingers
+			status.ErrorCode = ""
+			endPhase(status, models.PhaseSucceeded, true)
 			updated = true
 		}
 	}
 	s.statusesMutex.Unlock()
 
 	if updated {
+		s.persist(id)
 		s.publishStatus(id)
 	}
 }
@@ -0,0 +1,161 @@
+package conversion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newQueuedStatus returns a status in the queued phase, as created by the API handlers.
+func newQueuedStatus() *models.ConversionStatus {
+	return &models.ConversionStatus{
+		Phase:  models.PhaseQueued,
+		Phases: []models.PhaseRecord{{Phase: models.PhaseQueued, StartedAt: time.Now()}},
+	}
+}
+
+func TestStorePhases(t *testing.T) {
+	t.Run("successful lifecycle", func(t *testing.T) {
+		store := NewStore()
+		store.SetStatus("job", newQueuedStatus())
+
+		store.SetPhase("job", models.PhaseProbing, 0)
+		store.SetPhase("job", models.PhaseEncoding, 80)
+		store.SetProgressPercentage("job", 40)
+
+		status, ok := store.GetStatus("job")
+		require.True(t, ok)
+		assert.Equal(t, models.PhaseEncoding, status.Phase)
+		require.Len(t, status.Phases, 3)
+		assert.Equal(t, 100.0, status.Phases[1].Progress, "finished phases are complete")
+		assert.NotNil(t, status.Phases[1].EndedAt)
+		assert.Equal(t, 50.0, status.Phases[2].Progress, "progress is mapped onto the phase span")
+		assert.Nil(t, status.Phases[2].EndedAt)
+
+		store.SetPhase("job", models.PhaseFinalizing, 100)
+		store.SetProgressPercentage("job", 70)
+		status, _ = store.GetStatus("job")
+		assert.Equal(t, 50.0, status.Phases[3].Progress)
+
+		store.UpdateStatusOnSuccess("job")
+		status, _ = store.GetStatus("job")
+		assert.Equal(t, models.PhaseSucceeded, status.Phase)
+		require.Len(t, status.Phases, 4)
+		for _, phase := range status.Phases {
+			assert.NotNil(t, phase.EndedAt, phase.Phase)
+			assert.Equal(t, 100.0, phase.Progress, phase.Phase)
+			assert.False(t, phase.EndedAt.Before(phase.StartedAt), phase.Phase)
+		}
+		assert.True(t, models.IsTerminalPhase(status.Phase))
+	})
+
+	t.Run("failure keeps interrupted phase progress", func(t *testing.T) {
+		store := NewStore()
+		store.SetStatus("job", newQueuedStatus())
+		store.SetPhase("job", models.PhaseEncoding, 100)
+		store.SetProgressPercentage("job", 30)
+
+		store.UpdateStatusWithError("job", models.ErrorCodeConversionFailed, "boom")
+
+		status, _ := store.GetStatus("job")
+		assert.Equal(t, models.PhaseFailed, status.Phase)
+		assert.Equal(t, models.ErrorCodeConversionFailed, status.ErrorCode)
+		last := status.Phases[len(status.Phases)-1]
+		assert.Equal(t, models.PhaseEncoding, last.Phase)
+		assert.Equal(t, 30.0, last.Progress)
+		assert.NotNil(t, last.EndedAt)
+	})
+
+	t.Run("cancellation", func(t *testing.T) {
+		store := NewStore()
+		store.SetStatus("job", newQueuedStatus())
+		store.SetPhase("job", models.PhaseEncoding, 100)
+
+		store.UpdateStatusCanceled("job")
+
+		status, _ := store.GetStatus("job")
+		assert.Equal(t, models.PhaseCanceled, status.Phase)
+		assert.Equal(t, AbortedByUserMessage, status.Error)
+		assert.Equal(t, models.ErrorCodeAborted, status.ErrorCode)
+		assert.True(t, status.Complete)
+
+		// Later transitions do not reopen a finished job.
+		store.SetPhase("job", models.PhaseFinalizing, 100)
+		store.UpdateStatusWithError("job", models.ErrorCodeInternal, "late failure")
+		status, _ = store.GetStatus("job")
+		assert.Equal(t, models.PhaseCanceled, status.Phase)
+		assert.Equal(t, models.ErrorCodeAborted, status.ErrorCode)
+		assert.Len(t, status.Phases, 2)
+	})
+
+	t.Run("phase without progress span", func(t *testing.T) {
+		store := NewStore()
+		store.SetStatus("job", newQueuedStatus())
+		store.SetPhase("job", models.PhaseDownloading, 0)
+		store.SetProgressPercentage("job", 10)
+
+		status, _ := store.GetStatus("job")
+		assert.Equal(t, 0.0, status.Phases[1].Progress)
+	})
+
+	t.Run("returned status does not share phase history", func(t *testing.T) {
+		store := NewStore()
+		store.SetStatus("job", newQueuedStatus())
+
+		status, _ := store.GetStatus("job")
+		status.Phases[0].Phase = "modified"
+
+		stored, _ := store.GetStatus("job")
+		assert.Equal(t, models.PhaseQueued, stored.Phases[0].Phase)
+	})
+
+	t.Run("events carry phases", func(t *testing.T) {
+		store := NewStore()
+		events := store.Subscribe()
+		defer store.Unsubscribe(events)
+
+		store.SetStatus("job", newQueuedStatus())
+		<-events
+		store.SetPhase("job", models.PhaseProbing, 0)
+
+		event := <-events
+		require.NotNil(t, event.Status)
+		assert.Equal(t, models.PhaseProbing, event.Status.Phase)
+		assert.Len(t, event.Status.Phases, 2)
+	})
+}
+
+func TestStorePruneFinished(t *testing.T) {
+	repo := NewMemoryRepository()
+	store := NewStoreWithRepository(repo)
+	finished := func(id string, age time.Duration) {
+		ended := time.Now().Add(-age)
+		store.SetStatus(id, &models.ConversionStatus{
+			Complete: true,
+			Phase:    models.PhaseSucceeded,
+			Phases:   []models.PhaseRecord{{Phase: models.PhaseEncoding, StartedAt: ended.Add(-time.Minute), EndedAt: &ended}},
+		})
+	}
+	finished("old", 2*time.Hour)
+	finished("recent", time.Minute)
+	store.SetStatus("running", &models.ConversionStatus{
+		Phase:  models.PhaseEncoding,
+		Phases: []models.PhaseRecord{{Phase: models.PhaseEncoding, StartedAt: time.Now().Add(-3 * time.Hour)}},
+	})
+
+	assert.Equal(t, 1, store.PruneFinished(time.Hour))
+
+	_, exists := store.GetStatus("old")
+	assert.False(t, exists)
+	assert.Len(t, store.GetAllStatuses(), 2, "recent and running jobs are kept")
+	records, err := repo.LoadAll()
+	require.NoError(t, err)
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+	assert.ElementsMatch(t, []string{"recent", "running"}, ids)
+}
@@ -0,0 +1,72 @@
+package conversion
+
+import (
+	"context"
+	"errors"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/gatanasi/video-converter/internal/drive"
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+// DriveUploader uploads a converted file to Google Drive, as drive.Client.UploadFile does.
+type DriveUploader func(ctx context.Context, path string, opts drive.UploadOptions) (*models.DriveUploadResult, error)
+
+// errUploadsDisabled is the upload error of jobs with a Drive destination when no uploader is set.
+var errUploadsDisabled = errors.New("google drive uploads are not configured")
+
+// uploadFailedMessage is the error recorded on jobs whose output could not be uploaded.
+const uploadFailedMessage = "Converted, but failed to upload the output to Google Drive; it can still be downloaded"
+
+// outputMimeTypes maps output file extensions to the MIME type uploaded files get on Drive.
+var outputMimeTypes = map[string]string{
+	".mp4": "video/mp4",
+	".mov": "video/quicktime",
+}
+
+// SetDriveUploader sets the function used to upload the outputs of jobs with a Drive
+// destination. It must be called before such jobs run.
+func (c *VideoConverter) SetDriveUploader(uploader DriveUploader) {
+	c.uploader = uploader
+}
+
+// uploadOutput uploads the output of a job to its Drive destination as the uploading
+// phase, and reports whether the job should go on to succeed. A failed upload fails the
+// job but keeps the output, which remains downloadable; a canceled one stops the job.
+func (c *VideoConverter) uploadOutput(job models.ConversionJob) bool {
+	id := job.ConversionID
+	c.store.SetPhase(id, models.PhaseUploading, 100)
+	c.store.SetCurrentStep(id, "Upload to Google Drive")
+
+	uploader := c.uploader
+	if uploader == nil {
+		uploader = func(context.Context, string, drive.UploadOptions) (*models.DriveUploadResult, error) {
+			return nil, errUploadsDisabled
+		}
+	}
+	name := filepath.Base(job.OutputFilePath)
+	ctx, release := c.store.JobContext(id)
+	result, err := uploader(ctx, job.OutputFilePath, drive.UploadOptions{
+		Name:     name,
+		MimeType: outputMimeTypes[strings.ToLower(filepath.Ext(name))],
+		FolderID: job.Destination.FolderID,
+		ParentOf: job.FileID, // The source folder is the default destination
+		Progress: func(uploaded, total int64) { c.store.SetUploadProgress(id, uploaded, total) },
+	})
+	release()
+	if c.abortIfCanceled(job) {
+		return false
+	}
+	if err != nil {
+		log.Printf("ERROR [job %s]: Failed to upload output to Google Drive: %v", id, err)
+		c.store.UpdateStatusWithError(id, models.ErrorCodeDriveUpload, uploadFailedMessage)
+		c.removeInputFiles(job)
+		return false
+	}
+
+	c.store.SetDriveUpload(id, *result)
+	log.Printf("Uploaded output of job %s to Google Drive file ID %s", id, result.FileID)
+	return true
+}
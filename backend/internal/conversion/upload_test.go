@@ -0,0 +1,90 @@
+package conversion
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/drive"
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newUploadTestJob registers a converted Drive job with a Drive destination, as it is
+// when it reaches the upload step.
+func newUploadTestJob(t *testing.T, store *Store, id string) models.ConversionJob {
+	t.Helper()
+	job := newDriveTestJob(t, store, id)
+	job.OutputFilePath = filepath.Join(t.TempDir(), id+".mp4")
+	job.Destination = &models.DriveDestination{}
+	job.Status.OutputPath = job.OutputFilePath
+	require.NoError(t, os.WriteFile(job.UploadedFilePath, []byte("source"), 0o644))
+	require.NoError(t, os.WriteFile(job.OutputFilePath, []byte("converted"), 0o644))
+	store.TrackJob(job)
+	return job
+}
+
+func TestUploadOutput(t *testing.T) {
+	t.Run("uploads to the source folder and records the file", func(t *testing.T) {
+		store := NewStore()
+		converter := NewVideoConverter(1, 10, store)
+		var got drive.UploadOptions
+		converter.SetDriveUploader(func(ctx context.Context, path string, opts drive.UploadOptions) (*models.DriveUploadResult, error) {
+			got = opts
+			opts.Progress(9, 9)
+			return &models.DriveUploadResult{FileID: "new-file", Name: opts.Name, FolderID: "source-folder"}, nil
+		})
+		job := newUploadTestJob(t, store, "job")
+
+		require.True(t, converter.uploadOutput(job))
+
+		assert.Equal(t, "job.mp4", got.Name)
+		assert.Equal(t, "video/mp4", got.MimeType)
+		assert.Empty(t, got.FolderID)
+		assert.Equal(t, "drive-job", got.ParentOf)
+		status, _ := store.GetStatus("job")
+		assert.Equal(t, models.PhaseUploading, status.Phase)
+		assert.Equal(t, &models.UploadProgress{BytesUploaded: 9, TotalBytes: 9}, status.Upload)
+		assert.Equal(t, &models.DriveUploadResult{FileID: "new-file", Name: "job.mp4", FolderID: "source-folder"}, status.DriveUpload)
+		assert.Equal(t, "new-file", store.BuildStatusResponse("job", status).DriveUpload.FileID)
+	})
+
+	t.Run("failed upload fails the job but keeps the output", func(t *testing.T) {
+		store := NewStore()
+		converter := NewVideoConverter(1, 10, store)
+		converter.SetDriveUploader(func(ctx context.Context, path string, opts drive.UploadOptions) (*models.DriveUploadResult, error) {
+			return nil, errors.New("insufficient permissions")
+		})
+		job := newUploadTestJob(t, store, "job")
+		job.Destination.FolderID = "read-only"
+
+		require.False(t, converter.uploadOutput(job))
+
+		status, _ := store.GetStatus("job")
+		assert.Equal(t, models.PhaseFailed, status.Phase)
+		assert.Equal(t, models.ErrorCodeDriveUpload, status.ErrorCode)
+		assert.FileExists(t, job.OutputFilePath)
+		assert.True(t, HasSingleDownload(status), "the output can still be downloaded")
+	})
+
+	t.Run("canceling stops the upload", func(t *testing.T) {
+		store := NewStore()
+		converter := NewVideoConverter(1, 10, store)
+		converter.SetDriveUploader(func(ctx context.Context, path string, opts drive.UploadOptions) (*models.DriveUploadResult, error) {
+			require.NoError(t, converter.CancelJob("job"))
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		job := newUploadTestJob(t, store, "job")
+
+		require.False(t, converter.uploadOutput(job))
+
+		status, _ := store.GetStatus("job")
+		assert.Equal(t, models.PhaseCanceled, status.Phase)
+		assert.NoFileExists(t, job.OutputFilePath)
+		assert.NoFileExists(t, job.UploadedFilePath)
+	})
+}
@@ -0,0 +1,275 @@
+package drive
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+)
+
+// ErrUnauthorized is returned when Google rejects the credentials or an access token.
+var ErrUnauthorized = errors.New("google drive authorization failed")
+
+// Credential types of Google credentials files.
+const (
+	credentialsServiceAccount = "service_account"
+	credentialsAuthorizedUser = "authorized_user" // User OAuth credentials, as written by gcloud
+)
+
+// jwtBearerGrantType is the OAuth grant type exchanging a signed JWT for an access token.
+const jwtBearerGrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+// TokenSource supplies OAuth access tokens for the Drive API.
+type TokenSource interface {
+	// Token returns an access token that is valid for at least DriveTokenRefreshMargin.
+	Token(ctx context.Context) (string, error)
+}
+
+// tokenInvalidator is implemented by token sources that cache access tokens, so a token
+// Google rejected before its expiry can be dropped.
+type tokenInvalidator interface {
+	// Invalidate drops token if it is the cached one, so the next Token call fetches a new one.
+	Invalidate(token string)
+}
+
+// credentialsFile is a Google credentials JSON file, either a service account key or
+// user OAuth credentials.
+type credentialsFile struct {
+	Type     string `json:"type"`
+	TokenURI string `json:"token_uri"` // Defaults to DefaultGoogleTokenURL
+
+	// Service account keys
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+
+	// User OAuth credentials
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// LoadCredentials reads a Google credentials file, see ParseCredentials.
+func LoadCredentials(path, subject string) (TokenSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Google credentials file: %w", err)
+	}
+	tokens, err := ParseCredentials(data, subject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Google credentials file %s: %w", path, err)
+	}
+	return tokens, nil
+}
+
+// ParseCredentials returns a token source for a Google credentials JSON file: a
+// service account key, using the JWT bearer flow, or user OAuth credentials holding a
+// refresh token. subject, if set, is the user a service account impersonates through
+// domain-wide delegation. Tokens are cached until shortly before they expire.
+func ParseCredentials(data []byte, subject string) (TokenSource, error) {
+	var creds credentialsFile
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials: %w", err)
+	}
+	tokenURI := creds.TokenURI
+	if tokenURI == "" {
+		tokenURI = constants.DefaultGoogleTokenURL
+	}
+
+	switch creds.Type {
+	case credentialsServiceAccount:
+		if creds.ClientEmail == "" || creds.PrivateKey == "" {
+			return nil, errors.New("service account key is missing client_email or private_key")
+		}
+		key, err := parsePrivateKey(creds.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		return &cachingTokenSource{fetch: func(ctx context.Context) (accessToken, error) {
+			assertion, err := signJWT(key, creds.PrivateKeyID, map[string]any{
+				"iss":   creds.ClientEmail,
+				"sub":   subject,
+				"scope": constants.DriveOAuthScope,
+				"aud":   tokenURI,
+			})
+			if err != nil {
+				return accessToken{}, err
+			}
+			return requestToken(ctx, tokenURI, url.Values{"grant_type": {jwtBearerGrantType}, "assertion": {assertion}})
+		}}, nil
+
+	case credentialsAuthorizedUser:
+		if creds.ClientID == "" || creds.ClientSecret == "" || creds.RefreshToken == "" {
+			return nil, errors.New("user credentials are missing client_id, client_secret or refresh_token")
+		}
+		if subject != "" {
+			return nil, errors.New("only service accounts can impersonate users")
+		}
+		return &cachingTokenSource{fetch: func(ctx context.Context) (accessToken, error) {
+			return requestToken(ctx, tokenURI, url.Values{
+				"grant_type":    {"refresh_token"},
+				"refresh_token": {creds.RefreshToken},
+				"client_id":     {creds.ClientID},
+				"client_secret": {creds.ClientSecret},
+			})
+		}}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported credentials type %q (use %s or %s)", creds.Type, credentialsServiceAccount, credentialsAuthorizedUser)
+	}
+}
+
+// parsePrivateKey parses the PEM encoded RSA private key of a service account.
+func parsePrivateKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("private_key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private_key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private_key is not an RSA key")
+	}
+	return key, nil
+}
+
+// signJWT returns a JWT holding claims, valid for DriveJWTLifetime, signed with RS256.
+// Empty string claims are left out.
+func signJWT(key *rsa.PrivateKey, keyID string, claims map[string]any) (string, error) {
+	header := map[string]string{"alg": "RS256", "typ": "JWT"}
+	if keyID != "" {
+		header["kid"] = keyID
+	}
+	now := time.Now()
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(constants.DriveJWTLifetime).Unix()
+	for name, value := range claims {
+		if value == "" {
+			delete(claims, name)
+		}
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// accessToken is an OAuth access token and the time it expires.
+type accessToken struct {
+	value  string
+	expiry time.Time
+}
+
+// requestToken obtains an access token from a token endpoint.
+func requestToken(ctx context.Context, tokenURI string, form url.Values) (accessToken, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return accessToken{}, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: constants.DriveAPIRequestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return accessToken{}, fmt.Errorf("token request failed: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("WARN: Error closing token response body: %v", closeErr)
+		}
+	}()
+
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, constants.MaxJSONRequestSize))
+	if err != nil {
+		return accessToken{}, fmt.Errorf("failed to read token response: %w", err)
+	}
+	if err := json.Unmarshal(data, &body); err != nil && resp.StatusCode == http.StatusOK {
+		return accessToken{}, fmt.Errorf("failed to parse token response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return accessToken{}, fmt.Errorf("token request failed: %s", resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return accessToken{}, fmt.Errorf("%w: %s: %s %s", ErrUnauthorized, resp.Status, body.Error, body.ErrorDescription)
+	case body.AccessToken == "":
+		return accessToken{}, errors.New("token response holds no access token")
+	}
+	lifetime := time.Duration(body.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = constants.DriveJWTLifetime
+	}
+	return accessToken{value: body.AccessToken, expiry: time.Now().Add(lifetime)}, nil
+}
+
+// cachingTokenSource reuses an access token until it is about to expire. Concurrent
+// callers wait for a single refresh.
+type cachingTokenSource struct {
+	fetch func(ctx context.Context) (accessToken, error)
+
+	mu      sync.Mutex
+	current accessToken
+}
+
+func (s *cachingTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current.value != "" && time.Until(s.current.expiry) > constants.DriveTokenRefreshMargin {
+		return s.current.value, nil
+	}
+	token, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.current = token
+	return token.value, nil
+}
+
+func (s *cachingTokenSource) Invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current.value == token {
+		s.current = accessToken{}
+	}
+}
@@ -0,0 +1,261 @@
+package drive
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTokenServer is an OAuth token endpoint issuing "token-N" access tokens that expire
+// after expiresIn seconds. It records the form of each request.
+type fakeTokenServer struct {
+	expiresIn int
+	reject    bool
+
+	mu    sync.Mutex
+	forms []url.Values
+}
+
+func (f *fakeTokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	f.forms = append(f.forms, r.PostForm)
+	count := len(f.forms)
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if f.reject {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error": "invalid_grant", "error_description": "Token has been expired or revoked."}`))
+		return
+	}
+	_, _ = fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": %d, "token_type": "Bearer"}`, count, f.expiresIn)
+}
+
+func (f *fakeTokenServer) requests() []url.Values {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]url.Values(nil), f.forms...)
+}
+
+func newFakeTokenServer(t *testing.T, fake *fakeTokenServer) string {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return server.URL + "/token"
+}
+
+// serviceAccountKey returns a service account key file using a new RSA key.
+func serviceAccountKey(t *testing.T, tokenURI string) ([]byte, *rsa.PublicKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	data, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"client_email":   "converter@project.iam.gserviceaccount.com",
+		"private_key_id": "key-1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":      tokenURI,
+	})
+	require.NoError(t, err)
+	return data, &key.PublicKey
+}
+
+// verifyJWT checks the RS256 signature of a JWT and returns its header and claims.
+func verifyJWT(t *testing.T, jwt string, key *rsa.PublicKey) (header, claims map[string]any) {
+	t.Helper()
+	parts := strings.Split(jwt, ".")
+	require.Len(t, parts, 3)
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	require.NoError(t, rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature))
+
+	for i, target := range []*map[string]any{&header, &claims} {
+		data, err := base64.RawURLEncoding.DecodeString(parts[i])
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, target))
+	}
+	return header, claims
+}
+
+func TestServiceAccountCredentials(t *testing.T) {
+	fake := &fakeTokenServer{expiresIn: 3600}
+	tokenURI := newFakeTokenServer(t, fake)
+	keyFile, publicKey := serviceAccountKey(t, tokenURI)
+
+	tokens, err := ParseCredentials(keyFile, "editor@example.com")
+	require.NoError(t, err)
+
+	for range 3 {
+		token, err := tokens.Token(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "token-1", token, "the token is cached until it expires")
+	}
+	requests := fake.requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, jwtBearerGrantType, requests[0].Get("grant_type"))
+
+	header, claims := verifyJWT(t, requests[0].Get("assertion"), publicKey)
+	assert.Equal(t, "RS256", header["alg"])
+	assert.Equal(t, "key-1", header["kid"])
+	assert.Equal(t, "converter@project.iam.gserviceaccount.com", claims["iss"])
+	assert.Equal(t, "editor@example.com", claims["sub"])
+	assert.Equal(t, constants.DriveOAuthScope, claims["scope"])
+	assert.Equal(t, tokenURI, claims["aud"])
+	assert.Equal(t, constants.DriveJWTLifetime.Seconds(), claims["exp"].(float64)-claims["iat"].(float64))
+}
+
+func TestAuthorizedUserCredentials(t *testing.T) {
+	t.Run("refreshes tokens about to expire", func(t *testing.T) {
+		fake := &fakeTokenServer{expiresIn: int(constants.DriveTokenRefreshMargin.Seconds()) - 1}
+		tokenURI := newFakeTokenServer(t, fake)
+		tokens, err := ParseCredentials([]byte(`{"type": "authorized_user", "client_id": "id", "client_secret": "secret",
+			"refresh_token": "refresh", "token_uri": "`+tokenURI+`"}`), "")
+		require.NoError(t, err)
+
+		first, err := tokens.Token(context.Background())
+		require.NoError(t, err)
+		second, err := tokens.Token(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "token-1", first)
+		assert.Equal(t, "token-2", second)
+
+		form := fake.requests()[0]
+		assert.Equal(t, "refresh_token", form.Get("grant_type"))
+		assert.Equal(t, "refresh", form.Get("refresh_token"))
+		assert.Equal(t, "id", form.Get("client_id"))
+		assert.Equal(t, "secret", form.Get("client_secret"))
+	})
+
+	t.Run("rejected credentials", func(t *testing.T) {
+		tokenURI := newFakeTokenServer(t, &fakeTokenServer{reject: true})
+		tokens, err := ParseCredentials([]byte(`{"type": "authorized_user", "client_id": "id", "client_secret": "secret",
+			"refresh_token": "revoked", "token_uri": "`+tokenURI+`"}`), "")
+		require.NoError(t, err)
+
+		_, err = tokens.Token(context.Background())
+		assert.ErrorIs(t, err, ErrUnauthorized)
+		assert.Contains(t, err.Error(), "invalid_grant")
+	})
+}
+
+func TestParseCredentials_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		subject string
+		wantErr string
+	}{
+		{"not JSON", `{`, "", "failed to parse"},
+		{"unknown type", `{"type": "external_account"}`, "", "unsupported credentials type"},
+		{"service account without key", `{"type": "service_account", "client_email": "a@b.c"}`, "", "private_key"},
+		{"malformed key", `{"type": "service_account", "client_email": "a@b.c", "private_key": "not a key"}`, "", "PEM"},
+		{"user without refresh token", `{"type": "authorized_user", "client_id": "id", "client_secret": "secret"}`, "", "refresh_token"},
+		{"user impersonation", `{"type": "authorized_user", "client_id": "id", "client_secret": "secret", "refresh_token": "r"}`, "x@y.z", "impersonate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCredentials([]byte(tt.data), tt.subject)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+// staticTokens is a TokenSource returning a fixed token.
+type staticTokens string
+
+func (s staticTokens) Token(context.Context) (string, error) { return string(s), nil }
+
+func TestClientWithTokens(t *testing.T) {
+	var authorization, key string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization, key = r.Header.Get("Authorization"), r.URL.Query().Get("key")
+		if authorization != "Bearer secret-token" {
+			http.Error(w, `{"error":{"code":401,"message":"Invalid Credentials"}}`, http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(models.GoogleDriveFileList{Files: []*models.GoogleDriveFile{video("private", 1)}})
+	}))
+	defer server.Close()
+
+	listing, err := NewClientWithTokens(staticTokens("secret-token"), server.URL).ListVideos(context.Background(), ListOptions{FolderID: "team"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"private"}, fileIDs(listing.Files))
+	assert.Empty(t, key, "the API key is not sent")
+
+	_, err = NewClientWithTokens(staticTokens("expired"), server.URL).ListVideos(context.Background(), ListOptions{FolderID: "team"})
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+// rotatingTokens returns a caching token source handing out the given tokens in turn.
+func rotatingTokens(tokens ...string) (*cachingTokenSource, *int) {
+	fetches := 0
+	return &cachingTokenSource{fetch: func(context.Context) (accessToken, error) {
+		token := tokens[min(fetches, len(tokens)-1)]
+		fetches++
+		return accessToken{value: token, expiry: time.Now().Add(time.Hour)}, nil
+	}}, &fetches
+}
+
+func TestClientWithTokens_RevokedToken(t *testing.T) {
+	var authorizations []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			http.Error(w, `{"error":{"code":401,"message":"Invalid Credentials"}}`, http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(models.GoogleDriveFileList{Files: []*models.GoogleDriveFile{video("private", 1)}})
+	}))
+	defer server.Close()
+
+	t.Run("retries once with a fresh token", func(t *testing.T) {
+		authorizations = nil
+		tokens, fetches := rotatingTokens("revoked", "secret-token")
+
+		listing, err := NewClientWithTokens(tokens, server.URL).ListVideos(context.Background(), ListOptions{FolderID: "team"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"private"}, fileIDs(listing.Files))
+		assert.Equal(t, []string{"Bearer revoked", "Bearer secret-token"}, authorizations)
+		assert.Equal(t, 2, *fetches)
+
+		token, err := tokens.Token(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "secret-token", token, "the fresh token is cached")
+	})
+
+	t.Run("gives up when the fresh token is rejected too", func(t *testing.T) {
+		authorizations = nil
+		tokens, _ := rotatingTokens("revoked")
+
+		_, err := NewClientWithTokens(tokens, server.URL).ListVideos(context.Background(), ListOptions{FolderID: "team"})
+		assert.ErrorIs(t, err, ErrUnauthorized)
+		assert.Len(t, authorizations, 2)
+	})
+}
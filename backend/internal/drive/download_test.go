@@ -0,0 +1,216 @@
+package drive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fastRetries shortens the delays between download and upload attempts for the duration of a test.
+func fastRetries(t *testing.T) {
+	t.Helper()
+	base, maxDelay := transferRetryBaseDelay, transferRetryMaxDelay
+	transferRetryBaseDelay, transferRetryMaxDelay = time.Millisecond, 2*time.Millisecond
+	t.Cleanup(func() { transferRetryBaseDelay, transferRetryMaxDelay = base, maxDelay })
+}
+
+// rangeServer serves content with Range support. Its first dropAfter responses are cut
+// off halfway through, and each request's Range header is recorded.
+type rangeServer struct {
+	content   string
+	dropAfter int
+
+	mu     sync.Mutex
+	ranges []string
+}
+
+func (s *rangeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.ranges = append(s.ranges, r.Header.Get("Range"))
+	drop := len(s.ranges) <= s.dropAfter
+	s.mu.Unlock()
+
+	start := 0
+	if header := r.Header.Get("Range"); header != "" {
+		start, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(header, "bytes="), "-"))
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(s.content)-1, len(s.content)))
+	}
+	body := s.content[start:]
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if start > 0 {
+		w.WriteHeader(http.StatusPartialContent)
+	}
+	if drop {
+		_, _ = w.Write([]byte(body[:len(body)/2]))
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler) // Drop the connection mid-body
+	}
+	_, _ = w.Write([]byte(body))
+}
+
+func (s *rangeServer) requestRanges() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.ranges...)
+}
+
+func TestDownloadWithRetries(t *testing.T) {
+	content := strings.Repeat("0123456789", 1000)
+
+	t.Run("downloads the whole file and reports progress", func(t *testing.T) {
+		server := httptest.NewServer(&rangeServer{content: content})
+		defer server.Close()
+		dest := filepath.Join(t.TempDir(), "video.mov")
+
+		var lastWritten, lastTotal int64
+		err := NewClient("", server.URL).downloadWithRetries(context.Background(), server.URL, "test file", dest, DownloadOptions{
+			MaxFileSize: 1 << 20,
+			Progress:    func(written, total int64) { lastWritten, lastTotal = written, total },
+		})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+		assert.Equal(t, int64(len(content)), lastWritten)
+		assert.Equal(t, int64(len(content)), lastTotal)
+	})
+
+	t.Run("resumes with a range request after a dropped connection", func(t *testing.T) {
+		fastRetries(t)
+		handler := &rangeServer{content: content, dropAfter: 2}
+		server := httptest.NewServer(handler)
+		defer server.Close()
+		dest := filepath.Join(t.TempDir(), "video.mov")
+
+		err := NewClient("", server.URL).downloadWithRetries(context.Background(), server.URL, "test file", dest, DownloadOptions{MaxFileSize: 1 << 20})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+		assert.Equal(t, []string{"", "bytes=5000-", "bytes=7500-"}, handler.requestRanges())
+	})
+
+	t.Run("resumes a partial file left by an earlier run", func(t *testing.T) {
+		handler := &rangeServer{content: content}
+		server := httptest.NewServer(handler)
+		defer server.Close()
+		dest := filepath.Join(t.TempDir(), "video.mov")
+		require.NoError(t, os.WriteFile(dest, []byte(content[:1234]), 0o644))
+
+		require.NoError(t, NewClient("", server.URL).downloadWithRetries(context.Background(), server.URL, "test file", dest, DownloadOptions{MaxFileSize: 1 << 20}))
+
+		data, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+		assert.Equal(t, []string{"bytes=1234-"}, handler.requestRanges())
+	})
+
+	t.Run("retries server errors", func(t *testing.T) {
+		fastRetries(t)
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests < 3 {
+				http.Error(w, `{"error":{"code":503,"message":"Backend Error"}}`, http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte("video"))
+		}))
+		defer server.Close()
+
+		err := NewClient("", server.URL).downloadWithRetries(context.Background(), server.URL, "test file", filepath.Join(t.TempDir(), "v.mov"), DownloadOptions{MaxFileSize: 1 << 20})
+		require.NoError(t, err)
+		assert.Equal(t, 3, requests)
+	})
+
+	t.Run("gives up after repeated failures", func(t *testing.T) {
+		fastRetries(t)
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		err := NewClient("", server.URL).downloadWithRetries(context.Background(), server.URL, "test file", filepath.Join(t.TempDir(), "v.mov"), DownloadOptions{MaxFileSize: 1 << 20})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "attempts")
+		assert.Equal(t, 5, requests)
+	})
+
+	t.Run("does not retry a missing file", func(t *testing.T) {
+		fastRetries(t)
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			http.Error(w, `{"error":{"code":404,"message":"File not found"}}`, http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		err := NewClient("", server.URL).downloadWithRetries(context.Background(), server.URL, "test file", filepath.Join(t.TempDir(), "v.mov"), DownloadOptions{MaxFileSize: 1 << 20})
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("rejects files over the size limit", func(t *testing.T) {
+		server := httptest.NewServer(&rangeServer{content: content})
+		defer server.Close()
+		dest := filepath.Join(t.TempDir(), "video.mov")
+
+		err := NewClient("", server.URL).downloadWithRetries(context.Background(), server.URL, "test file", dest, DownloadOptions{MaxFileSize: 100})
+		assert.ErrorIs(t, err, ErrFileTooLarge)
+		assert.NoFileExists(t, dest)
+	})
+
+	t.Run("stops when canceled", func(t *testing.T) {
+		fastRetries(t)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := NewClient("", server.URL).downloadWithRetries(ctx, server.URL, "test file", filepath.Join(t.TempDir(), "v.mov"), DownloadOptions{MaxFileSize: 1 << 20})
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		header    string
+		wantStart int64
+		wantSize  int64
+		wantOK    bool
+	}{
+		{"bytes 100-199/1000", 100, 1000, true},
+		{"bytes */1000", -1, 1000, true},
+		{"bytes 0-99/*", 0, 0, false},
+		{"items 0-1/2", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			start, size, ok := parseContentRange(tt.header)
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				assert.Equal(t, tt.wantStart, start)
+				assert.Equal(t, tt.wantSize, size)
+			}
+		})
+	}
+}
@@ -2,75 +2,319 @@
 package drive
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gatanasi/video-converter/internal/constants"
 	"github.com/gatanasi/video-converter/internal/utils"
 )
 
-const (
-	driveAPIBaseURL = "https://www.googleapis.com/drive/v3/files"
+// filesPath is the path of the files resource of the Drive API, relative to the API base URL.
+const filesPath = "/drive/v3/files"
+
+// Client calls the Google Drive API.
+type Client struct {
+	baseURL string
+	apiKey  string
+	tokens  TokenSource // Authorizes requests instead of the API key when set
+}
+
+// NewClient returns a Drive API client authenticated with apiKey, which can only access
+// publicly shared files. baseURL is the root of the Google APIs, normally
+// constants.DefaultDriveAPIBaseURL; tests point it at a local fake server.
+func NewClient(apiKey, baseURL string) *Client {
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), apiKey: apiKey}
+}
+
+// NewClientWithTokens returns a Drive API client authorizing its requests with OAuth
+// access tokens, which can access the private files shared with the account.
+func NewClientWithTokens(tokens TokenSource, baseURL string) *Client {
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), tokens: tokens}
+}
+
+// filesURL returns the URL of the files resource, or of one file if fileID is not empty,
+// with the API key added to query unless the client uses access tokens.
+func (c *Client) filesURL(fileID string, query url.Values) string {
+	endpoint := c.baseURL + filesPath
+	if fileID != "" {
+		endpoint += "/" + url.PathEscape(fileID)
+	}
+	if c.tokens == nil {
+		query.Set("key", c.apiKey)
+	}
+	return endpoint + "?" + query.Encode()
+}
+
+// authorize adds an access token to a request when the client uses access tokens.
+func (c *Client) authorize(req *http.Request) error {
+	if c.tokens == nil {
+		return nil
+	}
+	token, err := c.tokens.Token(req.Context())
+	if err != nil {
+		return fmt.Errorf("failed to obtain Google Drive access token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// do sends an authorized request. When Google rejects a cached access token, which happens
+// when it is revoked before it expires, the token is dropped and the request is sent once
+// more with a fresh one. The first response is returned if the request cannot be repeated.
+func (c *Client) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	resp, err := client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	invalidator, ok := c.tokens.(tokenInvalidator)
+	token, bearer := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok || !bearer || (req.Body != nil && req.GetBody == nil) {
+		return resp, nil
+	}
+	invalidator.Invalidate(token)
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry.Body = body
+	}
+	if err := c.authorize(retry); err != nil {
+		return resp, nil
+	}
+	if closeErr := resp.Body.Close(); closeErr != nil {
+		log.Printf("WARN: Error closing rejected response body: %v", closeErr)
+	}
+	log.Printf("Google Drive rejected the access token, retrying %s %s with a new one", req.Method, req.URL.Path)
+	return client.Do(retry)
+}
+
+var (
+	// ErrNotFound is returned when a file or folder does not exist or is not visible to the API key.
+	ErrNotFound = errors.New("not found on Google Drive")
+	// ErrQuotaExceeded is returned when Google Drive rejects a request because a rate or download quota was exceeded.
+	ErrQuotaExceeded = errors.New("google drive quota exceeded")
+	// ErrFileTooLarge is returned when a download exceeds the maximum allowed file size.
+	ErrFileTooLarge = errors.New("file exceeds maximum size")
+)
+
+// APIError is an error response returned by the Google Drive API.
+// Use errors.Is with ErrNotFound, ErrQuotaExceeded or ErrUnauthorized to classify it.
+type APIError struct {
+	StatusCode int
+	Reason     string // Reason of the first error detail, e.g. "downloadQuotaExceeded"
+	msg        string
+}
+
+func (e *APIError) Error() string {
+	return e.msg
+}
+
+// Is reports whether the API error matches one of the package sentinel errors.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrQuotaExceeded:
+		if e.StatusCode == http.StatusTooManyRequests {
+			return true
+		}
+		reason := strings.ToLower(e.Reason)
+		return e.StatusCode == http.StatusForbidden && (strings.Contains(reason, "quota") || strings.Contains(reason, "limitexceeded"))
+	}
+	return false
+}
+
+// DownloadOptions configures DownloadFile.
+type DownloadOptions struct {
+	MaxFileSize int64
+	// Progress, if set, is called as the download advances with the bytes on disk and the
+	// total size of the file, or 0 while the size is unknown. Calls are throttled.
+	Progress func(written, total int64)
+}
+
+// Delays between download and upload attempts; variables so tests can shorten them.
+var (
+	transferRetryBaseDelay = constants.DriveTransferRetryBaseDelay
+	transferRetryMaxDelay  = constants.DriveTransferRetryMaxDelay
 )
 
+// retryableError marks a transfer failure that may succeed when retried, such as a
+// dropped connection or a server error.
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
 // DownloadFile downloads a file from Google Drive, respecting size limits.
-func DownloadFile(fileID, apiKey, destinationPath string, maxFileSize int64) error {
+// A partial file already at destinationPath, e.g. from a download interrupted by a
+// restart, is resumed rather than downloaded again. Transient failures are retried with
+// exponential backoff, resuming with HTTP Range requests from the bytes already written.
+// The download stops when ctx is canceled; the partial file is left for the caller.
+func (c *Client) DownloadFile(ctx context.Context, fileID, destinationPath string, opts DownloadOptions) error {
 	log.Printf("Attempting download: File ID %s to %s", fileID, destinationPath)
-	downloadURL := fmt.Sprintf("%s/%s?alt=media&key=%s", driveAPIBaseURL, fileID, apiKey)
+	downloadURL := c.filesURL(fileID, url.Values{"alt": {"media"}, "supportsAllDrives": {"true"}})
+	return c.downloadWithRetries(ctx, downloadURL, fmt.Sprintf("file ID %s", fileID), destinationPath, opts)
+}
+
+// downloadWithRetries downloads downloadURL to destinationPath, retrying transient failures.
+func (c *Client) downloadWithRetries(ctx context.Context, downloadURL, source, destinationPath string, opts DownloadOptions) error {
+	client := &http.Client{Timeout: constants.DriveAPIDownloadTimeout}
+	return withRetries(ctx, "download of "+source, func() (bool, error) {
+		return c.downloadAttempt(ctx, client, downloadURL, source, destinationPath, opts)
+	})
+}
+
+// withRetries runs attempt until it succeeds, retrying retryableErrors with exponential
+// backoff. attempt reports whether it made progress; the attempt budget is renewed
+// whenever it does. transfer names the transfer in errors and logs.
+func withRetries(ctx context.Context, transfer string, attempt func() (bool, error)) error {
+	delay := transferRetryBaseDelay
+	failures := 0
+	for {
+		progressed, err := attempt()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var retryable *retryableError
+		if !errors.As(err, &retryable) {
+			return err
+		}
+		if progressed {
+			failures = 0
+			delay = transferRetryBaseDelay
+		}
+		failures++
+		if failures >= constants.DriveTransferMaxAttempts {
+			return fmt.Errorf("%s failed after %d attempts: %w", transfer, failures, err)
+		}
+
+		log.Printf("WARN: Interrupted %s (%v), retrying in %s", transfer, err, delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		delay = min(delay*2, transferRetryMaxDelay)
+	}
+}
+
+// downloadAttempt continues the download from the end of the partial file, if any.
+// It reports whether it wrote any bytes.
+func (c *Client) downloadAttempt(ctx context.Context, client *http.Client, downloadURL, source, destinationPath string, opts DownloadOptions) (bool, error) {
+	var offset int64
+	if info, err := os.Stat(destinationPath); err == nil {
+		offset = info.Size()
+	}
 
-	req, err := http.NewRequest("GET", downloadURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create download request for file ID %s: %w", fileID, err)
+		return false, fmt.Errorf("failed to create download request for %s: %w", source, err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	if err := c.authorize(req); err != nil {
+		if errors.Is(err, ErrUnauthorized) {
+			return false, err
+		}
+		return false, &retryableError{err} // The token endpoint may be briefly unreachable
 	}
 
-	client := &http.Client{Timeout: constants.DriveAPIDownloadTimeout}
-	resp, err := client.Do(req)
+	resp, err := c.do(client, req)
 	if err != nil {
-		return fmt.Errorf("download request failed for file ID %s: %w", fileID, err)
+		return false, &retryableError{fmt.Errorf("download request failed for %s: %w", source, err)}
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
-			log.Printf("WARN: Error closing response body for file ID %s download: %v", fileID, closeErr)
+			log.Printf("WARN: Error closing response body for %s download: %v", source, closeErr)
 		}
 	}()
 
-	if resp.StatusCode != http.StatusOK {
-		return handleDriveAPIError(resp, fmt.Sprintf("download failed for file ID %s", fileID))
+	var total int64 // 0 while unknown
+	flags := os.O_WRONLY | os.O_CREATE
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// The whole file was sent, either because nothing was written yet or because the
+		// server ignored the range; start over.
+		offset = 0
+		flags |= os.O_TRUNC
+		if resp.ContentLength > 0 {
+			total = resp.ContentLength
+		}
+	case http.StatusPartialContent:
+		start, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			return false, &retryableError{fmt.Errorf("unexpected Content-Range %q resuming %s at byte %d", resp.Header.Get("Content-Range"), source, offset)}
+		}
+		total = size
+		flags |= os.O_APPEND
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file already holds every byte, or more than the file now has.
+		if _, size, ok := parseContentRange(resp.Header.Get("Content-Range")); ok && size == offset {
+			reportProgress(opts, offset, size)
+			return false, nil
+		}
+		if err := os.Truncate(destinationPath, 0); err != nil {
+			return false, fmt.Errorf("failed to restart download of %s: %w", source, err)
+		}
+		return false, &retryableError{fmt.Errorf("partial download of %s does not match the file, restarting", source)}
+	default:
+		apiErr := handleDriveAPIError(resp, fmt.Sprintf("download failed for %s", source))
+		if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+			return false, &retryableError{apiErr}
+		}
+		return false, apiErr
 	}
 
-	// Check Content-Length header against max size *before* writing to disk
-	contentLength := resp.ContentLength // Can be -1 if unknown
-	if contentLength > 0 && contentLength > maxFileSize {
-		return fmt.Errorf("file ID %s exceeds maximum size: %d bytes > %d bytes (reported by Content-Length)",
-			fileID, contentLength, maxFileSize)
+	// Check the reported size against max size *before* writing to disk
+	if total > opts.MaxFileSize {
+		return false, fmt.Errorf("%w: %s is %d bytes, limit is %d bytes (reported by the server)",
+			ErrFileTooLarge, source, total, opts.MaxFileSize)
 	}
 
-	// Create the destination file
-	out, err := os.Create(destinationPath)
+	out, err := os.OpenFile(destinationPath, flags, constants.FilePermissions)
 	if err != nil {
-		return fmt.Errorf("failed to create output file %s: %w", destinationPath, err)
+		return false, fmt.Errorf("failed to open output file %s: %w", destinationPath, err)
 	}
 	defer func() {
 		if closeErr := out.Close(); closeErr != nil { // Ensure file handle is closed
-			log.Printf("WARN: Error closing output file %s for file ID %s: %v", destinationPath, fileID, closeErr)
+			log.Printf("WARN: Error closing output file %s for %s: %v", destinationPath, source, closeErr)
 		}
 	}()
 
-	// Use io.LimitedReader to enforce maxFileSize during download, even if Content-Length was wrong/missing
-	limitedReader := &io.LimitedReader{R: resp.Body, N: maxFileSize + 1} // Read one extra byte to detect oversize
-
-	written, err := io.Copy(out, limitedReader)
+	// Use io.LimitedReader to enforce MaxFileSize during download, even if the reported size was wrong/missing
+	body := &readErrorRecorder{r: resp.Body}
+	limitedReader := &io.LimitedReader{R: body, N: opts.MaxFileSize - offset + 1} // Read one extra byte to detect oversize
+	progress := &progressWriter{w: out, written: offset, total: total, opts: opts}
+	written, err := io.Copy(progress, limitedReader)
+	progress.flush()
 	if err != nil {
-		// Clean up partially written file on copy error
-		if removeErr := os.Remove(destinationPath); removeErr != nil && !os.IsNotExist(removeErr) {
-			log.Printf("WARN: Failed to remove partially written file %s: %v", destinationPath, removeErr)
+		if body.err != nil {
+			return written > 0, &retryableError{fmt.Errorf("download of %s interrupted after %s: %w", source, utils.FormatBytesToMB(offset+written), body.err)}
 		}
-		return fmt.Errorf("failed to write file %s during download: %w", destinationPath, err)
+		return written > 0, fmt.Errorf("failed to write file %s during download: %w", destinationPath, err)
 	}
 
 	// Check if the limit was hit
@@ -79,52 +323,89 @@ func DownloadFile(fileID, apiKey, destinationPath string, maxFileSize int64) err
 		if removeErr := os.Remove(destinationPath); removeErr != nil && !os.IsNotExist(removeErr) {
 			log.Printf("WARN: Failed to remove oversized file %s: %v", destinationPath, removeErr)
 		}
-		return fmt.Errorf("file ID %s download exceeded maximum size of %d bytes", fileID, maxFileSize)
+		return true, fmt.Errorf("%w: %s download exceeded %d bytes", ErrFileTooLarge, source, opts.MaxFileSize)
+	}
+	if total > 0 && offset+written < total {
+		return written > 0, &retryableError{fmt.Errorf("download of %s ended early at %d of %d bytes: %w", source, offset+written, total, io.ErrUnexpectedEOF)}
 	}
 
-	log.Printf("Successfully downloaded %s for file ID %s to %s", utils.FormatBytesToMB(written), fileID, destinationPath)
-	return nil
+	log.Printf("Successfully downloaded %s for %s to %s", utils.FormatBytesToMB(offset+written), source, destinationPath)
+	return written > 0, nil
 }
 
-// ListVideos lists video files within a specific Google Drive folder.
-func ListVideos(folderID string, apiKey string) ([]byte, error) {
-	queryParams := url.Values{}
-	// Query for video mime types within the specified parent folder
-	queryParams.Set("q", fmt.Sprintf("'%s' in parents and mimeType contains 'video'", folderID))
-	// Request specific fields to minimize response size
-	queryParams.Set("fields", "files(id,name,mimeType,modifiedTime,size)")
-	queryParams.Set("orderBy", "name") // Order results by name
-	queryParams.Set("key", apiKey)
-
-	listURL := driveAPIBaseURL + "?" + queryParams.Encode()
-
-	req, err := http.NewRequest("GET", listURL, nil)
+// parseContentRange parses a Content-Range header of the form "bytes start-end/size" or
+// "bytes */size". start is -1 for the second form.
+func parseContentRange(header string) (start, size int64, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	rangePart, sizePart, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, false
+	}
+	size, err := strconv.ParseInt(sizePart, 10, 64)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create list request for folder %s: %w", folderID, err)
+		return 0, 0, false
 	}
-
-	client := &http.Client{Timeout: constants.DriveAPIRequestTimeout}
-	resp, err := client.Do(req)
+	if rangePart == "*" {
+		return -1, size, true
+	}
+	startPart, _, found := strings.Cut(rangePart, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err = strconv.ParseInt(startPart, 10, 64)
 	if err != nil {
-		return nil, fmt.Errorf("list request failed for folder %s: %w", folderID, err)
+		return 0, 0, false
 	}
-	defer func() {
-		if closeErr := resp.Body.Close(); closeErr != nil {
-			log.Printf("WARN: Error closing response body for folder %s list: %v", folderID, closeErr)
-		}
-	}()
+	return start, size, true
+}
 
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read list response body for folder %s: %w", folderID, err)
+// readErrorRecorder remembers the error of the underlying reader, so that failures of
+// the connection can be told apart from failures writing the file.
+type readErrorRecorder struct {
+	r   io.Reader
+	err error
+}
+
+func (r *readErrorRecorder) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
 	}
+	return n, err
+}
+
+// progressWriter reports download progress while writing, at most once per
+// ProgressUpdateThrottle.
+type progressWriter struct {
+	w          io.Writer
+	written    int64
+	total      int64
+	opts       DownloadOptions
+	lastReport time.Time
+}
 
-	if resp.StatusCode != http.StatusOK {
-		// Reuse error handling logic, passing the raw body bytes
-		return nil, handleDriveAPIErrorResponse(resp.StatusCode, resp.Status, bodyBytes, fmt.Sprintf("list videos failed for folder %s", folderID))
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	if time.Since(p.lastReport) >= constants.ProgressUpdateThrottle {
+		p.flush()
 	}
+	return n, err
+}
+
+// flush reports the current progress.
+func (p *progressWriter) flush() {
+	p.lastReport = time.Now()
+	reportProgress(p.opts, p.written, p.total)
+}
 
-	return bodyBytes, nil
+func reportProgress(opts DownloadOptions, written, total int64) {
+	if opts.Progress != nil {
+		opts.Progress(written, total)
+	}
 }
 
 // handleDriveAPIError parses Google Drive API error responses.
@@ -139,6 +420,9 @@ func handleDriveAPIErrorResponse(statusCode int, status string, bodyBytes []byte
 		Error struct {
 			Message string `json:"message"`
 			Code    int    `json:"code"`
+			Errors  []struct {
+				Reason string `json:"reason"`
+			} `json:"errors"`
 		} `json:"error"`
 	}
 
@@ -162,5 +446,9 @@ func handleDriveAPIErrorResponse(statusCode int, status string, bodyBytes []byte
 	// Log the detailed error
 	log.Printf("Google Drive API Error: %s", errMsg)
 
-	return fmt.Errorf("%s", errMsg) // Use a constant format string with the variable as argument
+	apiErr := &APIError{StatusCode: statusCode, msg: errMsg}
+	if len(googleError.Error.Errors) > 0 {
+		apiErr.Reason = googleError.Error.Errors[0].Reason
+	}
+	return apiErr
 }
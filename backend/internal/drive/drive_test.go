@@ -0,0 +1,64 @@
+package drive
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleDriveAPIErrorResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		notFound   bool
+		quota      bool
+	}{
+		{
+			name:       "file not found",
+			statusCode: http.StatusNotFound,
+			body:       `{"error":{"code":404,"message":"File not found: abc.","errors":[{"reason":"notFound"}]}}`,
+			notFound:   true,
+		},
+		{
+			name:       "download quota exceeded",
+			statusCode: http.StatusForbidden,
+			body:       `{"error":{"code":403,"message":"The download quota for this file has been exceeded.","errors":[{"reason":"downloadQuotaExceeded"}]}}`,
+			quota:      true,
+		},
+		{
+			name:       "user rate limit",
+			statusCode: http.StatusForbidden,
+			body:       `{"error":{"code":403,"message":"User Rate Limit Exceeded","errors":[{"reason":"userRateLimitExceeded"}]}}`,
+			quota:      true,
+		},
+		{
+			name:       "too many requests",
+			statusCode: http.StatusTooManyRequests,
+			body:       `not json`,
+			quota:      true,
+		},
+		{
+			name:       "permission denied",
+			statusCode: http.StatusForbidden,
+			body:       `{"error":{"code":403,"message":"The caller does not have permission","errors":[{"reason":"forbidden"}]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := handleDriveAPIErrorResponse(tt.statusCode, http.StatusText(tt.statusCode), []byte(tt.body), "download failed for file ID abc")
+
+			assert.Equal(t, tt.notFound, errors.Is(err, ErrNotFound))
+			assert.Equal(t, tt.quota, errors.Is(err, ErrQuotaExceeded))
+			assert.Contains(t, err.Error(), "download failed for file ID abc")
+
+			var apiErr *APIError
+			if assert.True(t, errors.As(err, &apiErr)) {
+				assert.Equal(t, tt.statusCode, apiErr.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package drive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+// folderMimeType is the MIME type of Google Drive folders.
+const folderMimeType = "application/vnd.google-apps.folder"
+
+// fileFields are the file fields requested when listing, see models.GoogleDriveFile.
+const fileFields = "id,name,mimeType,modifiedTime,size,thumbnailLink,videoMediaMetadata(width,height,durationMillis)"
+
+// ListOptions selects the videos returned by ListVideos. Zero values disable a filter.
+type ListOptions struct {
+	FolderID  string
+	Recursive bool // Also list the videos of subfolders, as a tree
+
+	NameContains   string    // Only videos whose name contains this text, matched by Drive
+	MinSize        int64     // Only videos of at least this many bytes
+	MaxSize        int64     // Only videos of at most this many bytes
+	ModifiedAfter  time.Time // Only videos modified after this time
+	ModifiedBefore time.Time // Only videos modified before this time
+}
+
+// ListVideos lists the videos of a Google Drive folder, following every page of results.
+// With opts.Recursive, subfolders are listed too, breadth first, down to
+// DriveListMaxDepth levels and up to DriveListMaxFolders folders; subfolders beyond
+// those limits are returned unlisted and marked as truncated. Subfolders without any
+// matching video are left out of the tree.
+func (c *Client) ListVideos(ctx context.Context, opts ListOptions) (*models.GoogleDriveFolder, error) {
+	type pendingFolder struct {
+		folder *models.GoogleDriveFolder
+		depth  int
+	}
+	root := newFolder(opts.FolderID, "")
+	visited := map[string]bool{opts.FolderID: true} // A folder may have several parents
+	pending := []pendingFolder{{root, 0}}
+
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+
+		files, err := c.listFolder(ctx, current.folder.ID, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if file.MimeType != folderMimeType {
+				if matchesSize(file, opts) {
+					current.folder.Files = append(current.folder.Files, file)
+				}
+				continue
+			}
+			if visited[file.ID] {
+				continue
+			}
+			visited[file.ID] = true
+			subfolder := newFolder(file.ID, file.Name)
+			current.folder.Folders = append(current.folder.Folders, subfolder)
+			if current.depth+1 >= constants.DriveListMaxDepth || len(visited) > constants.DriveListMaxFolders {
+				subfolder.Truncated = true
+				continue
+			}
+			pending = append(pending, pendingFolder{subfolder, current.depth + 1})
+		}
+	}
+
+	pruneEmptyFolders(root)
+	return root, nil
+}
+
+func newFolder(id, name string) *models.GoogleDriveFolder {
+	return &models.GoogleDriveFolder{
+		ID:      id,
+		Name:    name,
+		Files:   []*models.GoogleDriveFile{},
+		Folders: []*models.GoogleDriveFolder{},
+	}
+}
+
+// listFolder returns the matching videos of a folder and, for recursive listings, its
+// subfolders.
+func (c *Client) listFolder(ctx context.Context, folderID string, opts ListOptions) ([]*models.GoogleDriveFile, error) {
+	query := url.Values{}
+	query.Set("q", listQuery(folderID, opts))
+	// Request specific fields to minimize response size
+	query.Set("fields", "nextPageToken,files("+fileFields+")")
+	query.Set("orderBy", "name")
+	// Folders of shared drives are only listed when the request opts into shared drives
+	query.Set("supportsAllDrives", "true")
+	query.Set("includeItemsFromAllDrives", "true")
+	query.Set("corpora", "allDrives")
+	query.Set("pageSize", strconv.Itoa(constants.DriveListPageSize))
+
+	var files []*models.GoogleDriveFile
+	for {
+		var page models.GoogleDriveFileList
+		if err := c.getJSON(ctx, c.filesURL("", query), fmt.Sprintf("list videos failed for folder %s", folderID), &page); err != nil {
+			return nil, err
+		}
+		files = append(files, page.Files...)
+		if page.NextPageToken == "" || page.NextPageToken == query.Get("pageToken") {
+			return files, nil
+		}
+		query.Set("pageToken", page.NextPageToken)
+	}
+}
+
+// listQuery builds the Drive search query listing the children of a folder. Name and
+// date filters run on Drive; the size filter runs locally, since Drive cannot search by
+// size.
+func listQuery(folderID string, opts ListOptions) string {
+	// Query for video mime types within the specified parent folder
+	conditions := []string{"mimeType contains 'video'"}
+	if opts.NameContains != "" {
+		conditions = append(conditions, fmt.Sprintf("name contains '%s'", escapeQueryValue(opts.NameContains)))
+	}
+	if !opts.ModifiedAfter.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("modifiedTime > '%s'", opts.ModifiedAfter.UTC().Format(time.RFC3339)))
+	}
+	if !opts.ModifiedBefore.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("modifiedTime < '%s'", opts.ModifiedBefore.UTC().Format(time.RFC3339)))
+	}
+	selection := strings.Join(conditions, " and ")
+	if opts.Recursive {
+		selection = fmt.Sprintf("(%s) or mimeType = '%s'", selection, folderMimeType)
+	}
+	return fmt.Sprintf("'%s' in parents and trashed = false and (%s)", escapeQueryValue(folderID), selection)
+}
+
+// escapeQueryValue escapes a string for use inside a quoted Drive query value.
+func escapeQueryValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+}
+
+// matchesSize reports whether a video is within the size limits of opts. Videos of
+// unknown size only match when there are no limits.
+func matchesSize(file *models.GoogleDriveFile, opts ListOptions) bool {
+	if opts.MinSize <= 0 && opts.MaxSize <= 0 {
+		return true
+	}
+	size, err := strconv.ParseInt(file.Size, 10, 64)
+	if err != nil {
+		return false
+	}
+	return (opts.MinSize <= 0 || size >= opts.MinSize) && (opts.MaxSize <= 0 || size <= opts.MaxSize)
+}
+
+// pruneEmptyFolders removes the subfolders that hold no videos at any depth. Truncated
+// folders are kept, since their contents are unknown.
+func pruneEmptyFolders(folder *models.GoogleDriveFolder) {
+	kept := folder.Folders[:0]
+	for _, subfolder := range folder.Folders {
+		pruneEmptyFolders(subfolder)
+		if subfolder.Truncated || len(subfolder.Files) > 0 || len(subfolder.Folders) > 0 {
+			kept = append(kept, subfolder)
+		}
+	}
+	folder.Folders = kept
+}
+
+// getJSON sends a GET request to the Drive API and decodes the JSON response into v.
+func (c *Client) getJSON(ctx context.Context, requestURL, contextMsg string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to create request: %w", contextMsg, err)
+	}
+	if err := c.authorize(req); err != nil {
+		return fmt.Errorf("%s: %w", contextMsg, err)
+	}
+
+	client := &http.Client{Timeout: constants.DriveAPIRequestTimeout}
+	resp, err := c.do(client, req)
+	if err != nil {
+		return fmt.Errorf("%s: request failed: %w", contextMsg, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("WARN: Error closing response body (%s): %v", contextMsg, closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return handleDriveAPIError(resp, contextMsg)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("%s: failed to parse response: %w", contextMsg, err)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package drive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDrive serves the files.list endpoint from a map of folder IDs to their children,
+// pageSize files per page. Like Drive, it only returns folders when the query asks for them.
+type fakeDrive struct {
+	children map[string][]*models.GoogleDriveFile
+	pageSize int
+
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (f *fakeDrive) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r)
+	f.mu.Unlock()
+
+	if r.URL.Path != filesPath || r.URL.Query().Get("key") != "test-key" {
+		http.Error(w, `{"error":{"code":404,"message":"Not found"}}`, http.StatusNotFound)
+		return
+	}
+	query := r.URL.Query().Get("q")
+	parent, _, _ := strings.Cut(strings.TrimPrefix(query, "'"), "' in parents")
+	children, ok := f.children[parent]
+	if !ok {
+		http.Error(w, `{"error":{"code":404,"message":"File not found"}}`, http.StatusNotFound)
+		return
+	}
+
+	var files []*models.GoogleDriveFile
+	for _, file := range children {
+		if file.MimeType != folderMimeType || strings.Contains(query, folderMimeType) {
+			files = append(files, file)
+		}
+	}
+	start, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+	end := min(start+f.pageSize, len(files))
+	page := models.GoogleDriveFileList{Files: files[start:end]}
+	if end < len(files) {
+		page.NextPageToken = strconv.Itoa(end)
+	}
+	_ = json.NewEncoder(w).Encode(page)
+}
+
+func (f *fakeDrive) requestCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.requests)
+}
+
+func video(id string, size int) *models.GoogleDriveFile {
+	return &models.GoogleDriveFile{ID: id, Name: id + ".mov", MimeType: "video/quicktime", Size: strconv.Itoa(size)}
+}
+
+func folder(id string) *models.GoogleDriveFile {
+	return &models.GoogleDriveFile{ID: id, Name: id, MimeType: folderMimeType}
+}
+
+func fileIDs(files []*models.GoogleDriveFile) []string {
+	ids := make([]string, len(files))
+	for i, file := range files {
+		ids[i] = file.ID
+	}
+	return ids
+}
+
+func newFakeDriveClient(t *testing.T, fake *fakeDrive) *Client {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return NewClient("test-key", server.URL)
+}
+
+func TestListVideos(t *testing.T) {
+	t.Run("follows every page", func(t *testing.T) {
+		fake := &fakeDrive{pageSize: 2, children: map[string][]*models.GoogleDriveFile{
+			"root": {video("a", 1), video("b", 1), folder("sub"), video("c", 1), video("d", 1), video("e", 1)},
+		}}
+		client := newFakeDriveClient(t, fake)
+
+		listing, err := client.ListVideos(context.Background(), ListOptions{FolderID: "root"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "c", "d", "e"}, fileIDs(listing.Files))
+		assert.Empty(t, listing.Folders, "subfolders are only listed by recursive listings")
+		assert.Equal(t, 3, fake.requestCount())
+
+		query := fake.requests[0].URL.Query()
+		assert.Equal(t, "true", query.Get("supportsAllDrives"))
+		assert.Equal(t, "true", query.Get("includeItemsFromAllDrives"))
+		assert.Contains(t, query.Get("fields"), "videoMediaMetadata(width,height,durationMillis)")
+		assert.Contains(t, query.Get("fields"), "thumbnailLink")
+	})
+
+	t.Run("lists subfolders as a tree", func(t *testing.T) {
+		fake := &fakeDrive{pageSize: 100, children: map[string][]*models.GoogleDriveFile{
+			"root":  {video("top", 1), folder("a"), folder("empty")},
+			"a":     {video("nested", 1), folder("root"), folder("b")}, // "root" is also a child of "a"
+			"b":     {video("deep", 1)},
+			"empty": {},
+		}}
+		client := newFakeDriveClient(t, fake)
+
+		listing, err := client.ListVideos(context.Background(), ListOptions{FolderID: "root", Recursive: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"top"}, fileIDs(listing.Files))
+		require.Len(t, listing.Folders, 1, "folders without videos are left out")
+		a := listing.Folders[0]
+		assert.Equal(t, "a", a.Name)
+		assert.Equal(t, []string{"nested"}, fileIDs(a.Files))
+		require.Len(t, a.Folders, 1, "folders already listed are not listed again")
+		assert.Equal(t, []string{"deep"}, fileIDs(a.Folders[0].Files))
+		assert.Equal(t, 4, fake.requestCount())
+	})
+
+	t.Run("stops at the depth limit", func(t *testing.T) {
+		children := map[string][]*models.GoogleDriveFile{}
+		for depth := 0; depth <= constants.DriveListMaxDepth+2; depth++ {
+			id := fmt.Sprintf("level-%d", depth)
+			children[id] = []*models.GoogleDriveFile{video("v"+id, 1), folder(fmt.Sprintf("level-%d", depth+1))}
+		}
+		client := newFakeDriveClient(t, &fakeDrive{pageSize: 100, children: children})
+
+		listing, err := client.ListVideos(context.Background(), ListOptions{FolderID: "level-0", Recursive: true})
+		require.NoError(t, err)
+		current, depth := listing, 0
+		for len(current.Folders) > 0 {
+			current, depth = current.Folders[0], depth+1
+		}
+		assert.Equal(t, constants.DriveListMaxDepth, depth)
+		assert.True(t, current.Truncated)
+		assert.Empty(t, current.Files, "truncated folders are not listed")
+	})
+
+	t.Run("filters by size", func(t *testing.T) {
+		unknown := video("unknown", 0)
+		unknown.Size = ""
+		client := newFakeDriveClient(t, &fakeDrive{pageSize: 100, children: map[string][]*models.GoogleDriveFile{
+			"root": {video("small", 10), video("medium", 100), video("large", 1000), unknown},
+		}})
+
+		listing, err := client.ListVideos(context.Background(), ListOptions{FolderID: "root", MinSize: 50, MaxSize: 500})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"medium"}, fileIDs(listing.Files))
+	})
+
+	t.Run("reports Drive errors", func(t *testing.T) {
+		client := newFakeDriveClient(t, &fakeDrive{pageSize: 100})
+
+		_, err := client.ListVideos(context.Background(), ListOptions{FolderID: "missing"})
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestListQuery(t *testing.T) {
+	after := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name string
+		opts ListOptions
+		want string
+	}{
+		{
+			name: "videos only",
+			want: "'folder' in parents and trashed = false and (mimeType contains 'video')",
+		},
+		{
+			name: "name and dates",
+			opts: ListOptions{NameContains: `it's a "clip"`, ModifiedAfter: after, ModifiedBefore: after.Add(24 * time.Hour)},
+			want: `'folder' in parents and trashed = false and (mimeType contains 'video' and name contains 'it\'s a "clip"' and modifiedTime > '2024-01-02T03:04:05Z' and modifiedTime < '2024-01-03T03:04:05Z')`,
+		},
+		{
+			name: "recursive",
+			opts: ListOptions{Recursive: true, NameContains: "trip"},
+			want: "'folder' in parents and trashed = false and ((mimeType contains 'video' and name contains 'trip') or mimeType = 'application/vnd.google-apps.folder')",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, listQuery("folder", tt.opts))
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package drive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+)
+
+// ErrNoThumbnail is returned when Google Drive has no thumbnail for a file.
+var ErrNoThumbnail = errors.New("no thumbnail available on Google Drive")
+
+// thumbnailTypes are the image types accepted from Drive thumbnail links. Other types,
+// SVG in particular, are refused so that the proxy never serves active content.
+var thumbnailTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+	"image/gif":  true,
+}
+
+// Thumbnail is a thumbnail image being fetched from Google Drive.
+type Thumbnail struct {
+	Body        io.ReadCloser // Limited to DriveThumbnailMaxSize bytes; must be closed
+	ContentType string
+}
+
+// GetThumbnail fetches the thumbnail of a Google Drive file. Thumbnail links are
+// short-lived, so the link is looked up for each request rather than cached.
+func (c *Client) GetThumbnail(ctx context.Context, fileID string) (*Thumbnail, error) {
+	var file models.GoogleDriveFile
+	query := url.Values{"fields": {"thumbnailLink"}, "supportsAllDrives": {"true"}}
+	if err := c.getJSON(ctx, c.filesURL(fileID, query), fmt.Sprintf("thumbnail lookup failed for file ID %s", fileID), &file); err != nil {
+		return nil, err
+	}
+	if file.ThumbnailLink == "" {
+		return nil, fmt.Errorf("%w: file ID %s", ErrNoThumbnail, fileID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, file.ThumbnailLink, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create thumbnail request for file ID %s: %w", fileID, err)
+	}
+	if c.isGoogleHost(req.URL) {
+		// Thumbnails of private files are only served to authorized requests
+		if err := c.authorize(req); err != nil {
+			return nil, fmt.Errorf("thumbnail request failed for file ID %s: %w", fileID, err)
+		}
+	}
+	client := &http.Client{Timeout: constants.DriveAPIRequestTimeout}
+	resp, err := c.do(client, req)
+	if err != nil {
+		return nil, fmt.Errorf("thumbnail request failed for file ID %s: %w", fileID, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer closeThumbnailBody(resp, fileID)
+		return nil, handleDriveAPIError(resp, fmt.Sprintf("thumbnail download failed for file ID %s", fileID))
+	}
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !thumbnailTypes[contentType] {
+		closeThumbnailBody(resp, fileID)
+		return nil, fmt.Errorf("thumbnail of file ID %s has unsupported type %q", fileID, contentType)
+	}
+
+	return &Thumbnail{
+		Body: struct {
+			io.Reader
+			io.Closer
+		}{io.LimitReader(resp.Body, constants.DriveThumbnailMaxSize), resp.Body},
+		ContentType: contentType,
+	}, nil
+}
+
+// isGoogleHost reports whether a thumbnail link points at a Google host trusted with the
+// access token: the Drive API itself or Google's content servers.
+func (c *Client) isGoogleHost(link *url.URL) bool {
+	if base, err := url.Parse(c.baseURL); err == nil && link.Host == base.Host {
+		return true
+	}
+	return link.Scheme == "https" && strings.HasSuffix(link.Hostname(), ".googleusercontent.com")
+}
+
+func closeThumbnailBody(resp *http.Response, fileID string) {
+	if closeErr := resp.Body.Close(); closeErr != nil {
+		log.Printf("WARN: Error closing thumbnail response body for file ID %s: %v", fileID, closeErr)
+	}
+}
@@ -0,0 +1,74 @@
+package drive
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newThumbnailServer serves file metadata whose thumbnail links point back at the server,
+// where /thumb/{id} answers with the content type given for the file.
+func newThumbnailServer(t *testing.T, contentTypes map[string]string) *Client {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, ok := strings.CutPrefix(r.URL.Path, "/thumb/"); ok {
+			w.Header().Set("Content-Type", contentTypes[id])
+			_, _ = w.Write([]byte(strings.Repeat("x", constants.DriveThumbnailMaxSize+10)))
+			return
+		}
+		id := strings.TrimPrefix(r.URL.Path, filesPath+"/")
+		assert.Equal(t, "true", r.URL.Query().Get("supportsAllDrives"))
+		switch _, known := contentTypes[id]; {
+		case id == "no-thumbnail":
+			_, _ = w.Write([]byte(`{}`))
+		case known:
+			_, _ = w.Write([]byte(`{"thumbnailLink": "` + server.URL + `/thumb/` + id + `"}`))
+		default:
+			http.Error(w, `{"error":{"code":404,"message":"File not found"}}`, http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return NewClient("test-key", server.URL)
+}
+
+func TestGetThumbnail(t *testing.T) {
+	client := newThumbnailServer(t, map[string]string{"png": "image/png", "svg": "image/svg+xml"})
+
+	t.Run("fetches the thumbnail", func(t *testing.T) {
+		thumbnail, err := client.GetThumbnail(context.Background(), "png")
+		require.NoError(t, err)
+		defer func() { _ = thumbnail.Body.Close() }()
+
+		assert.Equal(t, "image/png", thumbnail.ContentType)
+		data, err := io.ReadAll(thumbnail.Body)
+		require.NoError(t, err)
+		assert.Len(t, data, constants.DriveThumbnailMaxSize, "the body is limited")
+	})
+
+	tests := []struct {
+		name    string
+		fileID  string
+		wantErr error
+	}{
+		{"no thumbnail", "no-thumbnail", ErrNoThumbnail},
+		{"missing file", "missing", ErrNotFound},
+		{"unsupported type", "svg", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.GetThumbnail(context.Background(), tt.fileID)
+			require.Error(t, err)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package drive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gatanasi/video-converter/internal/constants"
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/gatanasi/video-converter/internal/utils"
+)
+
+// uploadPath is the path of the media upload endpoint of the files resource.
+const uploadPath = "/upload/drive/v3/files"
+
+// statusResumeIncomplete is the status Drive answers with while a resumable upload is incomplete.
+const statusResumeIncomplete = 308
+
+// ErrCredentialsRequired is returned when uploading with a client that only has an API
+// key, which cannot create files.
+var ErrCredentialsRequired = errors.New("uploading to Google Drive requires service account or OAuth credentials")
+
+// uploadChunkSize is the size of the chunks sent by resumable uploads; a variable so
+// tests can shorten it.
+var uploadChunkSize int64 = constants.DriveUploadChunkSize
+
+// UploadOptions configures UploadFile.
+type UploadOptions struct {
+	Name     string // Name of the new Drive file
+	MimeType string
+	FolderID string // Folder receiving the file
+	ParentOf string // When FolderID is empty, the file is created in the folder of this Drive file
+	// Progress, if set, is called after each chunk with the bytes Drive has received and
+	// the size of the file.
+	Progress func(uploaded, total int64)
+}
+
+// CanUpload reports whether the client has the credentials needed to upload files.
+func (c *Client) CanUpload() bool {
+	return c.tokens != nil
+}
+
+// uploadSession is the state of a resumable upload.
+type uploadSession struct {
+	url    string // Session URI; empty until the session is started, or after it expired
+	offset int64  // Bytes Drive has received
+}
+
+// UploadFile uploads a local file to Google Drive with the resumable upload protocol. The
+// file is sent in chunks; transient failures are retried with exponential backoff,
+// resuming from the last byte Drive acknowledged. The upload stops when ctx is canceled.
+func (c *Client) UploadFile(ctx context.Context, path string, opts UploadOptions) (*models.DriveUploadResult, error) {
+	if !c.CanUpload() {
+		return nil, ErrCredentialsRequired
+	}
+	folderID := opts.FolderID
+	if folderID == "" {
+		parent, err := c.parentFolder(ctx, opts.ParentOf)
+		if err != nil {
+			return nil, err
+		}
+		folderID = parent
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s for upload: %w", path, err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			log.Printf("WARN: Error closing uploaded file %s: %v", path, closeErr)
+		}
+	}()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s for upload: %w", path, err)
+	}
+	size := info.Size()
+
+	log.Printf("Attempting upload: %s (%s) to folder %s", path, utils.FormatBytesToMB(size), folderID)
+	target := fmt.Sprintf("%s to folder %s", opts.Name, folderID)
+	client := &http.Client{Timeout: constants.DriveUploadChunkTimeout}
+	session := &uploadSession{}
+	var result *models.DriveUploadResult
+	err = withRetries(ctx, "upload of "+target, func() (bool, error) {
+		var progressed bool
+		var err error
+		result, progressed, err = c.uploadAttempt(ctx, client, session, file, size, folderID, opts)
+		return progressed, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.FolderID = folderID
+	log.Printf("Successfully uploaded %s as Drive file ID %s", target, result.FileID)
+	return result, nil
+}
+
+// parentFolder returns the first parent folder of a Drive file.
+func (c *Client) parentFolder(ctx context.Context, fileID string) (string, error) {
+	if fileID == "" {
+		return "", errors.New("no destination folder and no source file to take it from")
+	}
+	var file struct {
+		Parents []string `json:"parents"`
+	}
+	query := url.Values{"fields": {"parents"}, "supportsAllDrives": {"true"}}
+	if err := c.getJSON(ctx, c.filesURL(fileID, query), fmt.Sprintf("parent lookup failed for file ID %s", fileID), &file); err != nil {
+		return "", err
+	}
+	if len(file.Parents) == 0 {
+		return "", fmt.Errorf("file ID %s has no parent folder to upload to", fileID)
+	}
+	return file.Parents[0], nil
+}
+
+// uploadAttempt starts or resumes the upload session and sends the remaining chunks. It
+// returns the created file once Drive has received every byte, and reports whether Drive
+// acknowledged any new bytes.
+func (c *Client) uploadAttempt(ctx context.Context, client *http.Client, session *uploadSession, file *os.File, size int64, folderID string, opts UploadOptions) (*models.DriveUploadResult, bool, error) {
+	start := session.offset
+	if session.url == "" {
+		sessionURL, err := c.startUpload(ctx, client, size, folderID, opts)
+		if err != nil {
+			return nil, false, err
+		}
+		session.url, session.offset, start = sessionURL, 0, 0
+	} else {
+		// Ask Drive how much of the interrupted chunk it received
+		result, err := c.sendChunk(ctx, client, session, nil, 0, size)
+		if err != nil || result != nil {
+			return result, session.offset > start, err
+		}
+	}
+
+	for {
+		offset := session.offset
+		length := min(uploadChunkSize, size-offset)
+		result, err := c.sendChunk(ctx, client, session, io.NewSectionReader(file, offset, length), length, size)
+		if err != nil || result != nil {
+			return result, session.offset > start, err
+		}
+		if session.offset <= offset {
+			return nil, session.offset > start, &retryableError{fmt.Errorf("upload stalled at byte %d", offset)}
+		}
+		if opts.Progress != nil {
+			opts.Progress(session.offset, size)
+		}
+	}
+}
+
+// startUpload creates a resumable upload session and returns its URI.
+func (c *Client) startUpload(ctx context.Context, client *http.Client, size int64, folderID string, opts UploadOptions) (string, error) {
+	metadata, err := json.Marshal(map[string]any{"name": opts.Name, "parents": []string{folderID}})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode upload metadata: %w", err)
+	}
+	query := url.Values{"uploadType": {"resumable"}, "supportsAllDrives": {"true"}, "fields": {"id,name,webViewLink"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+uploadPath+"?"+query.Encode(), bytes.NewReader(metadata))
+	if err != nil {
+		return "", fmt.Errorf("failed to create upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(size, 10))
+	if opts.MimeType != "" {
+		req.Header.Set("X-Upload-Content-Type", opts.MimeType)
+	}
+
+	resp, err := c.doUploadRequest(client, req)
+	if err != nil {
+		return "", err
+	}
+	defer closeUploadBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return "", uploadAPIError(resp, "failed to start upload")
+	}
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return "", &retryableError{errors.New("upload session response has no Location header")}
+	}
+	return location, nil
+}
+
+// sendChunk sends length bytes of chunk from the session offset. A nil chunk sends no
+// bytes and only asks Drive for the upload status. It advances the session offset to the
+// bytes Drive acknowledged and returns the created file once the upload is complete.
+func (c *Client) sendChunk(ctx context.Context, client *http.Client, session *uploadSession, chunk io.Reader, length, size int64) (*models.DriveUploadResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, session.url, chunk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload request: %w", err)
+	}
+	req.ContentLength = length
+	if section, ok := chunk.(*io.SectionReader); ok {
+		// Lets the chunk be sent again with a new access token
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(io.NewSectionReader(section, 0, section.Size())), nil
+		}
+	}
+	if length == 0 {
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+	} else {
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", session.offset, session.offset+length-1, size))
+	}
+
+	resp, err := c.doUploadRequest(client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer closeUploadBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		var file struct {
+			ID          string `json:"id"`
+			Name        string `json:"name"`
+			WebViewLink string `json:"webViewLink"`
+		}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, constants.MaxJSONRequestSize)).Decode(&file); err != nil {
+			return nil, fmt.Errorf("failed to parse upload response: %w", err)
+		}
+		session.offset = size
+		return &models.DriveUploadResult{FileID: file.ID, Name: file.Name, WebViewLink: file.WebViewLink}, nil
+	case statusResumeIncomplete:
+		offset, ok := parseUploadedRange(resp.Header.Get("Range"))
+		if !ok {
+			return nil, &retryableError{fmt.Errorf("unexpected Range %q in upload status", resp.Header.Get("Range"))}
+		}
+		session.offset = offset
+		return nil, nil
+	case http.StatusNotFound, http.StatusGone:
+		// The session expired; start a new one
+		session.url, session.offset = "", 0
+		return nil, &retryableError{fmt.Errorf("upload session expired: %s", resp.Status)}
+	default:
+		return nil, uploadAPIError(resp, "upload failed")
+	}
+}
+
+// doUploadRequest authorizes and sends an upload request, marking failures that may
+// succeed when retried.
+func (c *Client) doUploadRequest(client *http.Client, req *http.Request) (*http.Response, error) {
+	if err := c.authorize(req); err != nil {
+		if errors.Is(err, ErrUnauthorized) {
+			return nil, err
+		}
+		return nil, &retryableError{err} // The token endpoint may be briefly unreachable
+	}
+	resp, err := c.do(client, req)
+	if err != nil {
+		return nil, &retryableError{fmt.Errorf("upload request failed: %w", err)}
+	}
+	return resp, nil
+}
+
+// uploadAPIError returns the error of a failed upload response, retryable for server
+// errors and rate limiting.
+func uploadAPIError(resp *http.Response, contextMsg string) error {
+	apiErr := handleDriveAPIError(resp, contextMsg)
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		return &retryableError{apiErr}
+	}
+	return apiErr
+}
+
+// parseUploadedRange returns the number of bytes Drive received from the Range header of
+// an incomplete upload, of the form "bytes=0-end". A missing header means no bytes.
+func parseUploadedRange(header string) (int64, bool) {
+	if header == "" {
+		return 0, true
+	}
+	spec, found := strings.CutPrefix(header, "bytes=0-")
+	if !found {
+		return 0, false
+	}
+	end, err := strconv.ParseInt(spec, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return end + 1, true
+}
+
+func closeUploadBody(resp *http.Response) {
+	if closeErr := resp.Body.Close(); closeErr != nil {
+		log.Printf("WARN: Error closing upload response body: %v", closeErr)
+	}
+}
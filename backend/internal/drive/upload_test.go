@@ -0,0 +1,240 @@
+package drive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gatanasi/video-converter/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUploads implements the resumable upload protocol of Drive. It drops the
+// connection halfway through the first dropChunks chunks, keeping the bytes read so far,
+// and answers the first expireSessions chunk requests with 404 as if the session expired.
+type fakeUploads struct {
+	dropChunks     int
+	expireSessions int
+	startStatus    int // Status answering session requests; 0 means 200
+
+	mu       sync.Mutex
+	metadata []map[string]any
+	headers  []http.Header
+	received []byte
+	requests int
+}
+
+func (f *fakeUploads) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests++
+	if r.Header.Get("Authorization") != "Bearer upload-token" {
+		http.Error(w, `{"error":{"code":401,"message":"Invalid Credentials"}}`, http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == filesPath+"/source":
+		_, _ = w.Write([]byte(`{"parents": ["source-folder"]}`))
+
+	case r.Method == http.MethodPost && r.URL.Path == uploadPath && r.URL.Query().Get("uploadType") == "resumable":
+		if f.startStatus != 0 {
+			http.Error(w, `{"error":{"code":403,"message":"Insufficient permissions"}}`, f.startStatus)
+			return
+		}
+		var metadata map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&metadata)
+		f.metadata = append(f.metadata, metadata)
+		f.headers = append(f.headers, r.Header.Clone())
+		f.received = nil
+		w.Header().Set("Location", fmt.Sprintf("http://%s/session/%d", r.Host, len(f.metadata)))
+
+	case r.Method == http.MethodPut && r.URL.Path == fmt.Sprintf("/session/%d", len(f.metadata)):
+		if f.expireSessions > 0 {
+			f.expireSessions--
+			http.Error(w, "session expired", http.StatusNotFound)
+			return
+		}
+		var start, end, total int64
+		if _, err := fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total); err == nil {
+			if start != int64(len(f.received)) {
+				http.Error(w, "unexpected offset", http.StatusBadRequest)
+				return
+			}
+			if f.dropChunks > 0 {
+				f.dropChunks--
+				half := make([]byte, (end-start+1)/2)
+				n, _ := io.ReadFull(r.Body, half)
+				f.received = append(f.received, half[:n]...)
+				panic(http.ErrAbortHandler) // Drop the connection mid-chunk
+			}
+			body, _ := io.ReadAll(r.Body)
+			f.received = append(f.received, body...)
+		} else if _, err := fmt.Sscanf(r.Header.Get("Content-Range"), "bytes */%d", &total); err != nil {
+			http.Error(w, "bad Content-Range", http.StatusBadRequest)
+			return
+		}
+		if int64(len(f.received)) < total {
+			if len(f.received) > 0 {
+				w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(f.received)-1))
+			}
+			w.WriteHeader(statusResumeIncomplete)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprintf(w, `{"id": "uploaded-%d", "name": %q, "webViewLink": "https://drive.google.com/file/d/uploaded/view"}`,
+			len(f.metadata), f.metadata[len(f.metadata)-1]["name"])
+
+	default:
+		http.Error(w, `{"error":{"code":404,"message":"Not found"}}`, http.StatusNotFound)
+	}
+}
+
+// smallChunks shortens the upload chunk size for the duration of a test.
+func smallChunks(t *testing.T, size int64) {
+	t.Helper()
+	original := uploadChunkSize
+	uploadChunkSize = size
+	t.Cleanup(func() { uploadChunkSize = original })
+}
+
+func newUploadTest(t *testing.T, fake *fakeUploads) (*Client, string) {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	path := filepath.Join(t.TempDir(), "clip.mp4")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("0123456789", 250)), 0o644))
+	return NewClientWithTokens(staticTokens("upload-token"), server.URL), path
+}
+
+func TestUploadFile(t *testing.T) {
+	content := strings.Repeat("0123456789", 250)
+
+	t.Run("uploads in chunks and reports progress", func(t *testing.T) {
+		smallChunks(t, 1000)
+		fake := &fakeUploads{}
+		client, path := newUploadTest(t, fake)
+
+		var progress []int64
+		result, err := client.UploadFile(context.Background(), path, UploadOptions{
+			Name:     "clip.mp4",
+			MimeType: "video/mp4",
+			FolderID: "target-folder",
+			Progress: func(uploaded, total int64) {
+				assert.Equal(t, int64(len(content)), total)
+				progress = append(progress, uploaded)
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, &models.DriveUploadResult{
+			FileID:      "uploaded-1",
+			Name:        "clip.mp4",
+			FolderID:    "target-folder",
+			WebViewLink: "https://drive.google.com/file/d/uploaded/view",
+		}, result)
+		assert.Equal(t, content, string(fake.received))
+		assert.Equal(t, []int64{1000, 2000}, progress, "the last chunk completes the upload")
+
+		require.Len(t, fake.metadata, 1)
+		assert.Equal(t, map[string]any{"name": "clip.mp4", "parents": []any{"target-folder"}}, fake.metadata[0])
+		assert.Equal(t, "2500", fake.headers[0].Get("X-Upload-Content-Length"))
+		assert.Equal(t, "video/mp4", fake.headers[0].Get("X-Upload-Content-Type"))
+	})
+
+	t.Run("resumes after a dropped connection", func(t *testing.T) {
+		fastRetries(t)
+		smallChunks(t, 1000)
+		fake := &fakeUploads{dropChunks: 2}
+		client, path := newUploadTest(t, fake)
+
+		result, err := client.UploadFile(context.Background(), path, UploadOptions{Name: "clip.mp4", FolderID: "target-folder"})
+		require.NoError(t, err)
+		assert.Equal(t, "uploaded-1", result.FileID, "the session is reused")
+		assert.Equal(t, content, string(fake.received))
+	})
+
+	t.Run("restarts an expired session", func(t *testing.T) {
+		fastRetries(t)
+		fake := &fakeUploads{expireSessions: 1}
+		client, path := newUploadTest(t, fake)
+
+		result, err := client.UploadFile(context.Background(), path, UploadOptions{Name: "clip.mp4", FolderID: "target-folder"})
+		require.NoError(t, err)
+		assert.Equal(t, "uploaded-2", result.FileID)
+		assert.Equal(t, content, string(fake.received))
+	})
+
+	t.Run("resends the request after a revoked token", func(t *testing.T) {
+		smallChunks(t, 1000)
+		fake := &fakeUploads{}
+		_, path := newUploadTest(t, fake)
+		server := httptest.NewServer(fake)
+		t.Cleanup(server.Close)
+		tokens, _ := rotatingTokens("revoked", "upload-token")
+
+		result, err := NewClientWithTokens(tokens, server.URL).UploadFile(context.Background(), path, UploadOptions{Name: "clip.mp4", FolderID: "target-folder"})
+		require.NoError(t, err)
+		assert.Equal(t, "uploaded-1", result.FileID)
+		assert.Equal(t, map[string]any{"name": "clip.mp4", "parents": []any{"target-folder"}}, fake.metadata[0], "the metadata is sent again")
+		assert.Equal(t, content, string(fake.received))
+	})
+
+	t.Run("defaults to the folder of the source file", func(t *testing.T) {
+		fake := &fakeUploads{}
+		client, path := newUploadTest(t, fake)
+
+		result, err := client.UploadFile(context.Background(), path, UploadOptions{Name: "clip.mp4", ParentOf: "source"})
+		require.NoError(t, err)
+		assert.Equal(t, "source-folder", result.FolderID)
+		assert.Equal(t, []any{"source-folder"}, fake.metadata[0]["parents"])
+	})
+
+	t.Run("does not retry rejected uploads", func(t *testing.T) {
+		fastRetries(t)
+		fake := &fakeUploads{startStatus: http.StatusForbidden}
+		client, path := newUploadTest(t, fake)
+
+		_, err := client.UploadFile(context.Background(), path, UploadOptions{Name: "clip.mp4", FolderID: "read-only"})
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+		assert.Equal(t, 1, fake.requests)
+	})
+
+	t.Run("requires credentials", func(t *testing.T) {
+		fake := &fakeUploads{}
+		_, path := newUploadTest(t, fake)
+
+		_, err := NewClient("test-key", "http://127.0.0.1:1").UploadFile(context.Background(), path, UploadOptions{Name: "clip.mp4", FolderID: "f"})
+		assert.ErrorIs(t, err, ErrCredentialsRequired)
+	})
+}
+
+func TestParseUploadedRange(t *testing.T) {
+	tests := []struct {
+		header string
+		want   int64
+		wantOK bool
+	}{
+		{"", 0, true},
+		{"bytes=0-999", 1000, true},
+		{"bytes=100-999", 0, false},
+		{"bytes=0-", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			got, ok := parseUploadedRange(tt.header)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
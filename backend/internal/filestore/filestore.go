@@ -3,6 +3,7 @@ package filestore
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -71,6 +72,12 @@ func fallbackFilename() string {
 
 // CleanupOldFiles removes files older than maxAge from the specified directory
 func CleanupOldFiles(dirPath string, maxAge time.Duration) int {
+	return CleanupOldFilesExcept(dirPath, maxAge, nil)
+}
+
+// CleanupOldFilesExcept is CleanupOldFiles keeping the files named in keep, such as the
+// sources of unfinished jobs, which may be older than the job itself.
+func CleanupOldFilesExcept(dirPath string, maxAge time.Duration, keep map[string]struct{}) int {
 	entries, err := os.ReadDir(dirPath)
 	if err != nil {
 		if !os.IsNotExist(err) { // Log only if it's not a "directory not found" error
@@ -85,6 +92,9 @@ func CleanupOldFiles(dirPath string, maxAge time.Duration) int {
 		if entry.IsDir() {
 			continue // Skip subdirectories
 		}
+		if _, kept := keep[entry.Name()]; kept {
+			continue
+		}
 		info, err := entry.Info()
 		if err != nil {
 			log.Printf("Error getting info for file %s in %s during cleanup: %v", entry.Name(), dirPath, err)
@@ -107,3 +117,77 @@ func CleanupOldFiles(dirPath string, maxAge time.Duration) int {
 	}
 	return removedCount
 }
+
+// CleanupOldDirectories removes subdirectories of dirPath (and their contents) whose
+// modification time is older than maxAge. It is used for per-job working directories.
+func CleanupOldDirectories(dirPath string, maxAge time.Duration) int {
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Error reading directory %s for cleanup: %v", dirPath, err)
+		}
+		return 0
+	}
+
+	now := time.Now()
+	removedCount := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			log.Printf("Error getting info for directory %s in %s during cleanup: %v", entry.Name(), dirPath, err)
+			continue
+		}
+
+		if now.Sub(info.ModTime()) > maxAge {
+			subDir := filepath.Join(dirPath, entry.Name())
+			if err := os.RemoveAll(subDir); err != nil {
+				log.Printf("Error removing old directory %s: %v", subDir, err)
+			} else {
+				removedCount++
+			}
+		}
+	}
+
+	if removedCount > 0 {
+		log.Printf("Removed %d old directories from %s", removedCount, dirPath)
+	}
+	return removedCount
+}
+
+// LinkOrCopyFile makes src available at the new path dst, as a hard link when both are on
+// the same file system so large files are not duplicated, and as a copy otherwise.
+func LinkOrCopyFile(src, dst string) error {
+	err := os.Link(src, dst)
+	if err == nil || os.IsNotExist(err) || os.IsExist(err) {
+		return err
+	}
+	return CopyFile(src, dst)
+}
+
+// CopyFile copies the contents of src to a new file at dst. A partially written dst is
+// removed if the copy fails.
+func CopyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, constants.FilePermissions)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	return nil
+}
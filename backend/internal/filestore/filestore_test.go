@@ -135,4 +135,105 @@ func TestCleanupOldFiles(t *testing.T) {
 		removed := CleanupOldFiles(dir, 24*time.Hour)
 		assert.Equal(t, 0, removed)
 	})
+
+	t.Run("keeps the listed files", func(t *testing.T) {
+		dir := t.TempDir()
+		oldTimestamp := time.Now().Add(-25 * time.Hour)
+		for _, name := range []string{"queued.mov", "old.mov"} {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.WriteFile(path, []byte("video"), 0o644))
+			require.NoError(t, os.Chtimes(path, oldTimestamp, oldTimestamp))
+		}
+
+		removed := CleanupOldFilesExcept(dir, 24*time.Hour, map[string]struct{}{"queued.mov": {}})
+		assert.Equal(t, 1, removed)
+		assert.FileExists(t, filepath.Join(dir, "queued.mov"))
+		assert.NoFileExists(t, filepath.Join(dir, "old.mov"))
+	})
+}
+
+func TestCleanupOldDirectories(t *testing.T) {
+	dir := t.TempDir()
+	oldDir := filepath.Join(dir, "old-job")
+	recentDir := filepath.Join(dir, "recent-job")
+
+	require.NoError(t, os.MkdirAll(oldDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(oldDir, "scene-001.jpg"), []byte("jpeg"), 0o644))
+	oldTimestamp := time.Now().Add(-25 * time.Hour)
+	require.NoError(t, os.Chtimes(oldDir, oldTimestamp, oldTimestamp))
+	require.NoError(t, os.MkdirAll(recentDir, 0o755))
+
+	removed := CleanupOldDirectories(dir, 24*time.Hour)
+	assert.Equal(t, 1, removed)
+
+	_, err := os.Stat(oldDir)
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = os.Stat(recentDir)
+	assert.NoError(t, err)
+}
+
+func TestCopyFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "source.mp4")
+	require.NoError(t, os.WriteFile(src, []byte("video data"), 0o644))
+
+	t.Run("copies contents", func(t *testing.T) {
+		dst := filepath.Join(dir, "copy.mp4")
+		require.NoError(t, CopyFile(src, dst))
+
+		data, err := os.ReadFile(dst)
+		require.NoError(t, err)
+		assert.Equal(t, "video data", string(data))
+	})
+
+	t.Run("refuses to overwrite", func(t *testing.T) {
+		dst := filepath.Join(dir, "existing.mp4")
+		require.NoError(t, os.WriteFile(dst, []byte("keep"), 0o644))
+
+		assert.Error(t, CopyFile(src, dst))
+		data, err := os.ReadFile(dst)
+		require.NoError(t, err)
+		assert.Equal(t, "keep", string(data))
+	})
+
+	t.Run("missing source", func(t *testing.T) {
+		err := CopyFile(filepath.Join(dir, "missing.mp4"), filepath.Join(dir, "never.mp4"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestLinkOrCopyFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "source.mp4")
+	require.NoError(t, os.WriteFile(src, []byte("video data"), 0o644))
+
+	t.Run("links within a file system", func(t *testing.T) {
+		dst := filepath.Join(dir, "link.mp4")
+		require.NoError(t, LinkOrCopyFile(src, dst))
+
+		srcInfo, err := os.Stat(src)
+		require.NoError(t, err)
+		dstInfo, err := os.Stat(dst)
+		require.NoError(t, err)
+		assert.True(t, os.SameFile(srcInfo, dstInfo))
+
+		require.NoError(t, os.Remove(dst))
+		assert.FileExists(t, src, "removing the link keeps the source")
+	})
+
+	t.Run("refuses to overwrite", func(t *testing.T) {
+		dst := filepath.Join(dir, "existing.mp4")
+		require.NoError(t, os.WriteFile(dst, []byte("keep"), 0o644))
+
+		assert.Error(t, LinkOrCopyFile(src, dst))
+		data, err := os.ReadFile(dst)
+		require.NoError(t, err)
+		assert.Equal(t, "keep", string(data))
+	})
+
+	t.Run("missing source", func(t *testing.T) {
+		err := LinkOrCopyFile(filepath.Join(dir, "missing.mp4"), filepath.Join(dir, "never.mp4"))
+		assert.True(t, os.IsNotExist(err))
+	})
 }
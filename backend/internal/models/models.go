@@ -2,21 +2,40 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
 // Config holds application configuration settings.
 type Config struct {
-	Port                 string
-	MaxFileSize          int64
-	UploadsDir           string
-	ConvertedDir         string
-	GoogleDriveAPIKey    string
-	WorkerCount          int
-	AllowedOrigins       []string
-	DefaultDriveFolderId string
+	Port                  string
+	MaxFileSize           int64
+	MaxQueuedJobs         int
+	UploadsDir            string
+	ConvertedDir          string
+	DataDir               string
+	GoogleDriveAPIKey     string
+	GoogleDriveAPIBaseURL string // Root of the Google APIs, overridable to use a local fake server
+
+	GoogleDriveCredentialsFile string // Service account key or user OAuth credentials; replaces the API key
+	GoogleDriveImpersonateUser string // User a service account acts as through domain-wide delegation
+	WorkerCount                int
+	AllowedOrigins             []string
+	DefaultDriveFolderId       string
+	SourceRetention            string // One of the SourceRetention constants
+	TrustClientHeaders         bool   // Identify clients by X-API-Key or X-Forwarded-User, set by an authenticating proxy
 }
 
+// Source retention policies decide whether the input file of a finished job is kept so the
+// job can be retried or re-run without uploading or downloading the source again. Retained
+// sources are removed by the periodic file cleanup, so they only last for constants.FileMaxAge after
+// the job finished. Canceled jobs never retain their source.
+const (
+	SourceRetentionNone   = "none"   // Remove the source as soon as a job finishes
+	SourceRetentionFailed = "failed" // Keep the source of failed jobs
+	SourceRetentionAll    = "all"    // Keep the source of failed and successful jobs
+)
+
 // ConversionResponse is the standard API response structure.
 type ConversionResponse struct {
 	Success      bool   `json:"success"`
@@ -24,19 +43,92 @@ type ConversionResponse struct {
 	DownloadURL  string `json:"downloadUrl,omitempty"` // Used in status response
 	ConversionID string `json:"conversionId,omitempty"`
 	Error        string `json:"error,omitempty"`
-	Details      string `json:"details,omitempty"` // Potentially more detailed error info
+	Details      string `json:"details,omitempty"`   // Potentially more detailed error info
+	ErrorCode    string `json:"errorCode,omitempty"` // One of the ErrorCode constants when Error is set
 }
 
+// Error codes identify why a request or job failed in a machine-readable way.
+// They are always sent alongside a human-readable message.
+const (
+	// Request errors
+	ErrorCodeInvalidRequest     = "INVALID_REQUEST"
+	ErrorCodeMethodNotAllowed   = "METHOD_NOT_ALLOWED"
+	ErrorCodeNotFound           = "NOT_FOUND"
+	ErrorCodeConflict           = "CONFLICT"
+	ErrorCodeFileTooLarge       = "FILE_TOO_LARGE"
+	ErrorCodeQueueFull          = "QUEUE_FULL"
+	ErrorCodeServiceUnavailable = "SERVICE_UNAVAILABLE"
+	ErrorCodeInternal           = "INTERNAL_ERROR"
+
+	// Google Drive errors
+	ErrorCodeDriveNotFound = "DRIVE_NOT_FOUND"
+	ErrorCodeDriveQuota    = "DRIVE_QUOTA"
+	ErrorCodeDriveAuth     = "DRIVE_AUTH" // The server's Google credentials were rejected
+	ErrorCodeDriveError    = "DRIVE_ERROR"
+	ErrorCodeDriveUpload   = "DRIVE_UPLOAD_FAILED" // The output was converted but could not be uploaded to Drive
+
+	// Job errors
+	ErrorCodeInvalidOptions    = "INVALID_OPTIONS"
+	ErrorCodeInvalidInput      = "INVALID_INPUT"
+	ErrorCodeUnsupportedCodec  = "UNSUPPORTED_CODEC"
+	ErrorCodeDiskFull          = "DISK_FULL"
+	ErrorCodeConversionFailed  = "CONVERSION_FAILED"
+	ErrorCodeAborted           = "ABORTED"
+	ErrorCodeInterrupted       = "INTERRUPTED"
+	ErrorCodeSourceUnavailable = "SOURCE_UNAVAILABLE" // The source of a finished job was not retained
+)
+
 // QualitySetting describes the encoder parameters for a named quality option.
 type QualitySetting struct {
-	Name   string
-	Preset string
-	CRF    int
+	Name   string `json:"name"`
+	Preset string `json:"preset"`
+	CRF    int    `json:"crf"`
 }
 
 // DefaultQualityName is the fallback quality option used when none is provided or when an unknown value is supplied.
 const DefaultQualityName = "default"
 
+// Job types supported by the conversion pipeline.
+const (
+	// JobTypeConvert re-encodes a video into a single output file (the default).
+	JobTypeConvert = "convert"
+	// JobTypeScenes detects scene changes and optionally splits the video into clips.
+	JobTypeScenes = "scenes"
+	// JobTypeSilence removes silent stretches from a video (jump-cut editing).
+	JobTypeSilence = "silence"
+)
+
+// Job lifecycle phases. A job passes through the active phases in order (downloading
+// only applies to Google Drive jobs, uploading to jobs with a Drive destination) and ends
+// in exactly one terminal phase. A running job may be paused, after which it returns to
+// the phase it was paused in.
+const (
+	PhaseQueued      = "queued"
+	PhaseDownloading = "downloading"
+	PhaseProbing     = "probing"
+	PhaseEncoding    = "encoding"
+	PhaseFinalizing  = "finalizing"
+	PhaseUploading   = "uploading"
+	PhasePaused      = "paused"
+
+	PhaseSucceeded = "succeeded"
+	PhaseFailed    = "failed"
+	PhaseCanceled  = "canceled"
+)
+
+// Job priorities. Interactive jobs run before all others; normal and bulk jobs share
+// the workers fairly between clients, with bulk jobs getting the smallest share.
+const (
+	PriorityInteractive = "interactive"
+	PriorityNormal      = "normal"
+	PriorityBulk        = "bulk"
+)
+
+// IsTerminalPhase reports whether a phase ends the job lifecycle.
+func IsTerminalPhase(phase string) bool {
+	return phase == PhaseSucceeded || phase == PhaseFailed || phase == PhaseCanceled
+}
+
 // DriveConversionRequest is the payload for starting a conversion from Google Drive.
 type DriveConversionRequest struct {
 	FileID       string `json:"fileId"`
@@ -46,18 +138,303 @@ type DriveConversionRequest struct {
 	Quality      string `json:"quality"`
 	ReverseVideo bool   `json:"reverseVideo"`
 	RemoveSound  bool   `json:"removeSound"`
+
+	Stabilization *StabilizationOptions `json:"stabilization,omitempty"`
+	Filters       *FilterOptions        `json:"filters,omitempty"`
+
+	JobType string          `json:"jobType,omitempty"` // One of the JobType constants; empty means convert
+	Scenes  *SceneOptions   `json:"scenes,omitempty"`
+	Silence *SilenceOptions `json:"silence,omitempty"`
+
+	Metrics *MetricsOptions `json:"metrics,omitempty"` // Measure output quality against the source after encoding
+
+	TargetVMAF float64 `json:"targetVmaf,omitempty"` // Search for the highest CRF meeting this VMAF score; 0 uses the preset CRF
+
+	Priority string `json:"priority,omitempty"` // One of the Priority constants; empty picks a default for the job type
+
+	Destination *DriveDestination `json:"destination,omitempty"` // Upload the output to Google Drive
+}
+
+// DriveDestination is the Google Drive folder receiving the output of a job.
+// An empty FolderID selects the folder of the source file.
+type DriveDestination struct {
+	FolderID string `json:"folderId,omitempty"`
+}
+
+// BatchConversionRequest submits several Google Drive files for conversion at once.
+// Options holds the options shared by all files, in the format of a DriveConversionRequest.
+// Each entry of Files is a DriveConversionRequest for one file; the fields it sets
+// override the shared options for that file.
+type BatchConversionRequest struct {
+	Options json.RawMessage   `json:"options,omitempty"`
+	Files   []json.RawMessage `json:"files"`
+}
+
+// ArchiveRequest selects converted files to download as one ZIP archive, either by
+// name or as the outputs of a batch.
+type ArchiveRequest struct {
+	Files   []string `json:"files,omitempty"`
+	BatchID string   `json:"batchId,omitempty"`
+}
+
+// BatchResponse is returned when a batch is submitted or canceled.
+type BatchResponse struct {
+	Success       bool     `json:"success"`
+	Message       string   `json:"message,omitempty"`
+	BatchID       string   `json:"batchId"`
+	ConversionIDs []string `json:"conversionIds"` // Jobs submitted or canceled, in file order
+}
+
+// BatchOutput is a downloadable file produced by a job of a batch.
+type BatchOutput struct {
+	ConversionID string `json:"conversionId"`
+	FileName     string `json:"fileName"`
+	DownloadURL  string `json:"downloadUrl"`
+}
+
+// BatchStatusResponse aggregates the status of the jobs of a batch.
+type BatchStatusResponse struct {
+	ID            string        `json:"id"`
+	Total         int           `json:"total"`
+	Succeeded     int           `json:"succeeded"`
+	Failed        int           `json:"failed"`
+	Canceled      int           `json:"canceled"`
+	Pending       int           `json:"pending"`  // Jobs still downloading, queued or running
+	Progress      float64       `json:"progress"` // Average progress of all jobs (0-100); finished jobs count as done
+	Complete      bool          `json:"complete"`
+	ConversionIDs []string      `json:"conversionIds"` // In file order
+	Outputs       []BatchOutput `json:"outputs"`
+}
+
+// Scene split modes for scene detection jobs.
+const (
+	SceneSplitNone   = ""       // Only detect scenes and extract thumbnails
+	SceneSplitScenes = "scenes" // One output file per detected scene
+	SceneSplitChunks = "chunks" // One output file per fixed-length chunk
+)
+
+// SceneOptions configures a scene detection job.
+type SceneOptions struct {
+	Threshold    float64 `json:"threshold,omitempty"`    // Scene change score (0-1); 0 uses the default
+	Split        string  `json:"split,omitempty"`        // One of the SceneSplit constants
+	ChunkSeconds float64 `json:"chunkSeconds,omitempty"` // Chunk length when Split is "chunks"
+}
+
+// SceneInfo describes a detected scene (or chunk) within a video.
+type SceneInfo struct {
+	Index        int     `json:"index"`
+	Start        float64 `json:"start"` // Seconds from the start of the input
+	End          float64 `json:"end"`
+	ThumbnailURL string  `json:"thumbnailUrl,omitempty"`
+	FileName     string  `json:"fileName,omitempty"` // Output clip, when the video was split
+}
+
+// SilenceOptions configures a silence removal job.
+type SilenceOptions struct {
+	NoiseDB        float64 `json:"noiseDb,omitempty"`        // Level (dBFS, negative) below which audio counts as silence; 0 uses the default
+	MinSeconds     float64 `json:"minSeconds,omitempty"`     // Minimum silence length to remove; 0 uses the default
+	PaddingSeconds float64 `json:"paddingSeconds,omitempty"` // Silence kept on each side of a cut; 0 uses the default
+	PreviewOnly    bool    `json:"previewOnly,omitempty"`    // Only detect silent ranges, do not encode
+}
+
+// TimeRange is a range of media time in seconds.
+type TimeRange struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// SilenceResult reports the outcome of a silence removal job.
+type SilenceResult struct {
+	RemovedRanges   []TimeRange `json:"removedRanges"`
+	RemovedSeconds  float64     `json:"removedSeconds"`
+	OriginalSeconds float64     `json:"originalSeconds"`
+	OutputSeconds   float64     `json:"outputSeconds"`
+	PreviewOnly     bool        `json:"previewOnly"`
+}
+
+// Quality metric modes.
+const (
+	MetricsModeSampled = "sampled" // Compare a short window from the middle of the video (the default)
+	MetricsModeFull    = "full"    // Compare every frame
+)
+
+// MetricsOptions configures objective quality measurement of a conversion.
+type MetricsOptions struct {
+	Mode string `json:"mode,omitempty"` // One of the MetricsMode constants; empty means sampled
+}
+
+// QualityMetrics holds objective quality scores of an output compared to its source.
+// A score is omitted when it could not be computed (e.g. FFmpeg built without libvmaf).
+type QualityMetrics struct {
+	VMAF            *float64       `json:"vmaf,omitempty"` // 0-100
+	SSIM            *float64       `json:"ssim,omitempty"` // 0-1, all planes
+	PSNR            *float64       `json:"psnr,omitempty"` // dB, average over planes
+	Mode            string         `json:"mode"`
+	ComparedSeconds float64        `json:"comparedSeconds"`
+	Quality         QualitySetting `json:"quality"` // Encoder settings the scores were measured for
+	ComputedAt      time.Time      `json:"computedAt"`
+}
+
+// CRFTrial is a sample encode made while searching for the CRF that meets a target VMAF.
+type CRFTrial struct {
+	CRF         int     `json:"crf"`
+	VMAF        float64 `json:"vmaf"`
+	SizeBytes   int64   `json:"sizeBytes"`
+	BitrateKbps float64 `json:"bitrateKbps"`
+}
+
+// CRFSearchResult reports the per-title CRF search of a job.
+type CRFSearchResult struct {
+	TargetVMAF    float64    `json:"targetVmaf"`
+	SampleSeconds float64    `json:"sampleSeconds"` // Total length of the representative samples
+	Trials        []CRFTrial `json:"trials"`
+	SelectedCRF   int        `json:"selectedCrf,omitempty"`
+	TargetMet     bool       `json:"targetMet"`
+	Error         string     `json:"error,omitempty"` // Set when the search failed and the preset CRF was used
+}
+
+// PreviewResult describes a preview sample encode and the full job projected from it.
+type PreviewResult struct {
+	FileName      string         `json:"fileName"`
+	PreviewURL    string         `json:"previewUrl"`
+	Quality       QualitySetting `json:"quality"`
+	Offset        float64        `json:"offset"`        // Start of the sample within the input, in seconds
+	SampleSeconds float64        `json:"sampleSeconds"` // Length of the encoded sample
+	SampleSize    int64          `json:"sampleSize"`
+	EncodeSeconds float64        `json:"encodeSeconds"` // Wall-clock time the sample took to encode
+	Speed         float64        `json:"speed"`         // Media seconds encoded per wall-clock second
+
+	ProjectedDurationSeconds float64 `json:"projectedDurationSeconds"` // Duration of the full output
+	ProjectedSize            int64   `json:"projectedSize"`            // Full output size, from the sample bitrate
+	ProjectedEncodeSeconds   float64 `json:"projectedEncodeSeconds"`   // Full encode time, from the sample speed
+}
+
+// OutputFile describes one of the files produced by a job with grouped outputs.
+type OutputFile struct {
+	FileName    string `json:"fileName"`
+	Size        int64  `json:"size"`
+	DownloadURL string `json:"downloadUrl"`
+}
+
+// StabilizationOptions configures two-pass vidstab stabilization.
+// Smoothing is the number of frames used for camera path smoothing (0 uses the default),
+// and Zoom is an additional zoom percentage applied to hide moving borders.
+type StabilizationOptions struct {
+	Smoothing int     `json:"smoothing"`
+	Zoom      float64 `json:"zoom"`
+}
+
+// FilterOptions configures optional image-processing filters applied during encoding.
+// Pointer fields are only applied when set, so that zero remains a meaningful value.
+type FilterOptions struct {
+	MaxHeight       int      `json:"maxHeight,omitempty"`       // Downscale to at most this height, keeping aspect ratio
+	Rotate          int      `json:"rotate,omitempty"`          // Clockwise rotation in degrees (90, 180, 270)
+	Denoise         string   `json:"denoise,omitempty"`         // Denoise filter: "hqdn3d" or "nlmeans"
+	DenoiseStrength float64  `json:"denoiseStrength,omitempty"` // Denoise strength (0 uses the default)
+	Sharpen         float64  `json:"sharpen,omitempty"`         // Unsharp luma amount (0 disables)
+	Brightness      *float64 `json:"brightness,omitempty"`      // -1.0 to 1.0
+	Contrast        *float64 `json:"contrast,omitempty"`        // 0.0 to 3.0
+	Saturation      *float64 `json:"saturation,omitempty"`      // 0.0 to 3.0
+	Gamma           *float64 `json:"gamma,omitempty"`           // 0.1 to 10.0
+	LUT             string   `json:"lut,omitempty"`             // Name of a .cube file in the LUT library
+}
+
+// LUTInfo describes a 3D LUT available in the LUT library.
+type LUTInfo struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// EncodingStats holds live statistics of the FFmpeg pass a conversion is running.
+// Values FFmpeg does not report (e.g. size for analysis passes) are left zero.
+type EncodingStats struct {
+	FPS           float64 `json:"fps"`
+	Speed         float64 `json:"speed"`                   // Media seconds encoded per wall-clock second
+	BitrateKbps   float64 `json:"bitrateKbps"`             // Output bitrate so far
+	OutputSize    int64   `json:"outputSize"`              // Bytes written so far
+	ETASeconds    float64 `json:"etaSeconds,omitempty"`    // Estimated time until the current step finishes
+	ProjectedSize int64   `json:"projectedSize,omitempty"` // Estimated final output size of the current step
+}
+
+// DownloadProgress reports how much of a job's source has been downloaded from Google Drive.
+type DownloadProgress struct {
+	BytesDownloaded int64 `json:"bytesDownloaded"`
+	TotalBytes      int64 `json:"totalBytes,omitempty"` // 0 while the size is unknown
+}
+
+// UploadProgress reports how much of a job's output has been uploaded to Google Drive.
+type UploadProgress struct {
+	BytesUploaded int64 `json:"bytesUploaded"`
+	TotalBytes    int64 `json:"totalBytes"`
+}
+
+// DriveUploadResult identifies the Google Drive file a job's output was uploaded to.
+type DriveUploadResult struct {
+	FileID      string `json:"fileId"`
+	Name        string `json:"name"`
+	FolderID    string `json:"folderId"`
+	WebViewLink string `json:"webViewLink,omitempty"`
+}
+
+// PhaseRecord describes one active phase a job went through.
+// EndedAt is nil while the phase is still running.
+type PhaseRecord struct {
+	Phase     string     `json:"phase"`
+	Progress  float64    `json:"progress"` // Progress within the phase (0-100)
+	StartedAt time.Time  `json:"startedAt"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`
 }
 
 // ConversionStatus tracks the state of a single conversion job.
 type ConversionStatus struct {
-	InputPath       string  // Path to the originally downloaded file
-	OutputPath      string  // Path to the target converted file
-	Format          string  // Target format (e.g., "mp4")
-	Quality         string  // Selected quality label (e.g., "default")
-	DurationSeconds float64 // Total duration of the input video in seconds
-	Progress        float64 // Estimated progress (0-100)
-	Complete        bool    // True if finished (successfully or with error)
-	Error           string  // Error message if conversion failed
+	InputPath       string             // Path to the originally downloaded file
+	OutputPath      string             // Path to the target converted file
+	Format          string             // Target format (e.g., "mp4")
+	Quality         string             // Selected quality label (e.g., "default")
+	DurationSeconds float64            // Total duration of the input video in seconds
+	Progress        float64            // Estimated progress (0-100)
+	Complete        bool               // True if finished (successfully or with error)
+	Error           string             // Error message if conversion failed
+	ErrorCode       string             // One of the ErrorCode constants if conversion failed
+	Plan            *ConversionPlan    // Planned tool invocations for this job
+	CurrentStep     string             // Description of the pipeline step currently running
+	JobType         string             // One of the JobType constants; empty means convert
+	CurrentOutput   string             // Output file currently being written, for jobs with several outputs
+	Scenes          []SceneInfo        // Detected scenes for scene detection jobs
+	Outputs         []OutputFile       // Files produced by jobs with grouped outputs
+	Silence         *SilenceResult     // Detected and removed silence for silence removal jobs
+	Metrics         *QualityMetrics    // Objective quality scores, when requested
+	CRFSearch       *CRFSearchResult   // Per-title CRF search, when a target VMAF was requested
+	Stats           *EncodingStats     // Live statistics of the running FFmpeg pass
+	Download        *DownloadProgress  // Progress of the Drive download of the source, if any
+	Upload          *UploadProgress    // Progress of the Drive upload of the output, if any
+	DriveUpload     *DriveUploadResult // Drive file the output was uploaded to
+
+	QueuePosition      int           // 1-based position while waiting in the queue; 0 otherwise
+	QueueReason        string        // Why the job holds its queue position; empty when not queued
+	PredecessorID      string        // Job this one retries or re-runs, if any
+	BatchID            string        // Batch the job was submitted in, if any
+	Phase              string        // Current lifecycle phase, one of the Phase constants
+	Phases             []PhaseRecord // Phases entered so far, oldest first
+	PhaseProgressStart float64       // Overall progress when the current phase started
+	PhaseProgressEnd   float64       // Overall progress at which the current phase is expected to end
+}
+
+// CommandInvocation describes a single external tool invocation within a conversion plan.
+type CommandInvocation struct {
+	Program     string   `json:"program"`
+	Args        []string `json:"args"`
+	Description string   `json:"description,omitempty"`
+}
+
+// ConversionPlan describes the pipeline a conversion job executes, in order.
+type ConversionPlan struct {
+	ConversionID   string              `json:"conversionId,omitempty"`
+	OutputFileName string              `json:"outputFileName"`
+	Quality        QualitySetting      `json:"quality"`
+	Commands       []CommandInvocation `json:"commands"`
+	DryRun         bool                `json:"dryRun"`
 }
 
 // ConversionStatusResponse represents the status information returned to clients.
@@ -67,9 +444,31 @@ type ConversionStatusResponse struct {
 	Progress    float64 `json:"progress"`
 	Complete    bool    `json:"complete"`
 	Error       string  `json:"error,omitempty"`
+	ErrorCode   string  `json:"errorCode,omitempty"`
 	Format      string  `json:"format"`
 	Quality     string  `json:"quality,omitempty"`
 	DownloadURL string  `json:"downloadUrl,omitempty"`
+	CurrentStep string  `json:"currentStep,omitempty"`
+	JobType     string  `json:"jobType,omitempty"`
+
+	QueuePosition int           `json:"queuePosition,omitempty"` // Only set while waiting for a worker
+	QueueReason   string        `json:"queueReason,omitempty"`
+	PredecessorID string        `json:"predecessorId,omitempty"` // Job this one retries or re-runs
+	BatchID       string        `json:"batchId,omitempty"`
+	Phase         string        `json:"phase,omitempty"`
+	Phases        []PhaseRecord `json:"phases,omitempty"`
+
+	Stats    *EncodingStats    `json:"stats,omitempty"` // Only set while the conversion is running
+	Download *DownloadProgress `json:"download,omitempty"`
+	Upload   *UploadProgress   `json:"upload,omitempty"`
+
+	DriveUpload *DriveUploadResult `json:"driveUpload,omitempty"` // Set once the output is on Google Drive
+
+	Scenes    []SceneInfo      `json:"scenes,omitempty"`
+	Outputs   []OutputFile     `json:"outputs,omitempty"`
+	Silence   *SilenceResult   `json:"silence,omitempty"`
+	Metrics   *QualityMetrics  `json:"metrics,omitempty"`
+	CRFSearch *CRFSearchResult `json:"crfSearch,omitempty"`
 }
 
 // ConversionJob represents a job passed to a conversion worker.
@@ -83,9 +482,32 @@ type ConversionJob struct {
 	VideoCRF         int
 	UploadedFilePath string            // Path to the file downloaded from Drive
 	OutputFilePath   string            // Path where the converted file should be saved
-	Status           *ConversionStatus // Pointer to the shared status object
+	Status           *ConversionStatus `json:"-"` // Pointer to the shared status object
 	ReverseVideo     bool
 	RemoveSound      bool
+	Stabilization    *StabilizationOptions
+	Filters          *FilterOptions
+	LUTPath          string // Resolved path of the LUT referenced by Filters.LUT
+	JobType          string // One of the JobType constants; empty means convert
+	Scenes           *SceneOptions
+	ThumbnailsDir    string // Directory receiving per-job thumbnails
+	Silence          *SilenceOptions
+	KeepRanges       []TimeRange // Input ranges kept when encoding; empty keeps everything
+	Metrics          *MetricsOptions
+	MetricsPath      string            // Sidecar file receiving the quality metrics of the output
+	TargetVMAF       float64           // Target VMAF for the per-title CRF search; 0 disables the search
+	Priority         string            // One of the Priority constants
+	ClientID         string            // Identifies the submitting client for fair scheduling
+	DownloadPending  bool              // The source has yet to be downloaded from Google Drive (FileID)
+	Destination      *DriveDestination // Google Drive folder receiving the output, if any
+}
+
+// JobRecord is the persisted state of a job: the options it was queued with and its latest status.
+type JobRecord struct {
+	ID        string           `json:"id"`
+	Job       *ConversionJob   `json:"job,omitempty"` // Nil until the job has been queued
+	Status    ConversionStatus `json:"status"`
+	UpdatedAt time.Time        `json:"updatedAt"`
 }
 
 // GoogleDriveFile represents metadata for a file listed from Google Drive.
@@ -95,11 +517,32 @@ type GoogleDriveFile struct {
 	MimeType     string `json:"mimeType"`
 	ModifiedTime string `json:"modifiedTime"` // RFC3339 format string
 	Size         string `json:"size"`         // String representation of size in bytes
+
+	ThumbnailLink      string                    `json:"thumbnailLink,omitempty"`      // Short-lived link to a thumbnail; browsers use the thumbnail proxy instead
+	VideoMediaMetadata *GoogleDriveVideoMetadata `json:"videoMediaMetadata,omitempty"` // Missing until Drive has processed the video
+}
+
+// GoogleDriveVideoMetadata holds the video properties Google Drive reports for a file.
+type GoogleDriveVideoMetadata struct {
+	Width          int    `json:"width,omitempty"`
+	Height         int    `json:"height,omitempty"`
+	DurationMillis string `json:"durationMillis,omitempty"` // String representation of the duration in milliseconds
 }
 
 // GoogleDriveFileList is the structure returned by the Google Drive API list endpoint.
 type GoogleDriveFileList struct {
-	Files []*GoogleDriveFile `json:"files"`
+	Files         []*GoogleDriveFile `json:"files"`
+	NextPageToken string             `json:"nextPageToken,omitempty"` // Set when more results are available
+}
+
+// GoogleDriveFolder is a Google Drive folder with the videos and subfolders it contains,
+// as returned by recursive listings.
+type GoogleDriveFolder struct {
+	ID        string               `json:"id"`
+	Name      string               `json:"name,omitempty"` // Empty for the listed folder itself
+	Files     []*GoogleDriveFile   `json:"files"`
+	Folders   []*GoogleDriveFolder `json:"folders"`
+	Truncated bool                 `json:"truncated,omitempty"` // Not listed because the depth or folder limit was reached
 }
 
 // FileInfo represents metadata for a locally stored (converted) file.
@@ -108,6 +551,15 @@ type FileInfo struct {
 	Size    int64     `json:"size"`
 	ModTime time.Time `json:"modTime"`
 	URL     string    `json:"url"` // Download URL for the file
+
+	Metrics *QualityMetrics `json:"metrics,omitempty"` // Quality scores recorded when the file was converted
+}
+
+// PauseAllResponse reports the result of pausing or resuming all conversions.
+type PauseAllResponse struct {
+	Success       bool     `json:"success"`
+	Paused        bool     `json:"paused"`        // Whether queued jobs are held back from the workers
+	ConversionIDs []string `json:"conversionIds"` // Running conversions that were paused or resumed
 }
 
 // ActiveConversionInfo represents details of a currently running conversion.
@@ -117,6 +569,10 @@ type ActiveConversionInfo struct {
 	Format   string  `json:"format"`
 	Progress float64 `json:"progress"`
 	Quality  string  `json:"quality,omitempty"`
+
+	Phase       string         `json:"phase,omitempty"`
+	CurrentStep string         `json:"currentStep,omitempty"`
+	Stats       *EncodingStats `json:"stats,omitempty"`
 }
 
 // --- End of Global State Management ---
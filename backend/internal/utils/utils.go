@@ -1,6 +1,10 @@
 package utils
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
 
 // BytesToMB converts bytes to megabytes.
 func BytesToMB(bytes int64) float64 {
@@ -11,3 +15,8 @@ func BytesToMB(bytes int64) float64 {
 func FormatBytesToMB(bytes int64) string {
 	return fmt.Sprintf("%.2f MB", BytesToMB(bytes))
 }
+
+// IsDiskFull reports whether an error was caused by running out of disk space.
+func IsDiskFull(err error) bool {
+	return errors.Is(err, syscall.ENOSPC)
+}
@@ -1,6 +1,10 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -112,3 +116,12 @@ func BenchmarkFormatBytesToMB(b *testing.B) {
 		FormatBytesToMB(bytes)
 	}
 }
+
+func TestIsDiskFull(t *testing.T) {
+	pathErr := &os.PathError{Op: "write", Path: "/tmp/out.mp4", Err: syscall.ENOSPC}
+
+	assert.True(t, IsDiskFull(pathErr))
+	assert.True(t, IsDiskFull(fmt.Errorf("saving upload: %w", pathErr)))
+	assert.False(t, IsDiskFull(errors.New("no space left on device")))
+	assert.False(t, IsDiskFull(nil))
+}
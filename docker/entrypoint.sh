@@ -3,9 +3,10 @@ set -eu
 
 UPLOADS_DIR=${UPLOADS_DIR:-/app/uploads}
 CONVERTED_DIR=${CONVERTED_DIR:-/app/converted}
+DATA_DIR=${DATA_DIR:-/app/data}
 
 # Validate and setup directories
-for dir in "$UPLOADS_DIR" "$CONVERTED_DIR"; do
+for dir in "$UPLOADS_DIR" "$CONVERTED_DIR" "$DATA_DIR"; do
 	# Resolve to absolute path and validate it's within /app/
 	absdir=$(cd / && cd "$(dirname "$dir")" && pwd)/$(basename "$dir")
 	case "$absdir" in